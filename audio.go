@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// audioSwitcher switches the host's default PulseAudio sink away from the TV
+// when it is turned off, and back again when it is turned on, so audio does
+// not silently vanish into a powered-down display. It uses `pactl`, which
+// also works with PipeWire via pipewire-pulse.
+//
+// The methods of audioSwitcher can be called on a nil *audioSwitcher and do
+// nothing, for when audio switching is not enabled. Failing to switch audio
+// is not fatal and is only reported as a warning.
+type audioSwitcher struct {
+	// offSink is the name of the sink to switch to when the TV is off.
+	offSink string
+
+	// tvSink is the default sink at the time the TV was turned off, and
+	// is restored when the TV is turned on again.
+	tvSink string
+}
+
+// tvOff saves the current default sink and switches to the "off" sink.
+func (a *audioSwitcher) tvOff() {
+	if a == nil {
+		return
+	}
+	current, err := pactl("get-default-sink")
+	if err != nil {
+		warnf("could not get default audio sink: %v", err)
+		return
+	}
+	if current == a.offSink {
+		return
+	}
+	if _, err := pactl("set-default-sink", a.offSink); err != nil {
+		warnf("could not switch audio to %s: %v", a.offSink, err)
+		return
+	}
+	a.tvSink = current
+	diag.Event("switched audio sink from %s to %s", current, a.offSink)
+}
+
+// tvOn restores the default sink saved when the TV was turned off.
+func (a *audioSwitcher) tvOn() {
+	if a == nil || a.tvSink == "" {
+		return
+	}
+	if _, err := pactl("set-default-sink", a.tvSink); err != nil {
+		warnf("could not switch audio back to %s: %v", a.tvSink, err)
+		return
+	}
+	diag.Event("switched audio sink back to %s", a.tvSink)
+	a.tvSink = ""
+}
+
+func pactl(args ...string) (string, error) {
+	out, err := exec.Command("pactl", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("pactl %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,48 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"foxygo.at/offscreen/pkg/bravia"
+)
+
+// channelSource returns the tuner source of a channel URI, such as "tv:dvbt"
+// for "tv:dvbt?trip=9018.4161.1056&srvName=BBC%20ONE", or the empty string if
+// the URI is not a tuner channel.
+func channelSource(uri string) string {
+	if !strings.HasPrefix(uri, "tv:") {
+		return ""
+	}
+	source, _, _ := strings.Cut(uri, "?")
+	return source
+}
+
+// stepChannel returns the channel delta channels away from the channel with
+// the given URI, wrapping around at either end of the list.
+func stepChannel(channels []bravia.Channel, uri string, delta int) (bravia.Channel, error) {
+	for i, ch := range channels {
+		if ch.URI == uri {
+			n := len(channels)
+			return channels[((i+delta)%n+n)%n], nil
+		}
+	}
+	return bravia.Channel{}, fmt.Errorf("current channel %s not found in channel list", uri)
+}
+
+// findChannel returns the channel with the given display number (e.g. "7")
+// or title, ignoring case.
+func findChannel(channels []bravia.Channel, name string) (bravia.Channel, error) {
+	for _, ch := range channels {
+		if ch.DispNum == name {
+			return ch, nil
+		}
+	}
+	for _, ch := range channels {
+		if strings.EqualFold(ch.Title, name) {
+			return ch, nil
+		}
+	}
+	return bravia.Channel{}, fmt.Errorf("no channel numbered or titled %q", name)
+}
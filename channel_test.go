@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"foxygo.at/offscreen/pkg/bravia"
+	"github.com/matryer/is"
+)
+
+func TestStepChannel(t *testing.T) {
+	is := is.New(t)
+
+	channels := []bravia.Channel{{URI: "tv:dvbt?a"}, {URI: "tv:dvbt?b"}, {URI: "tv:dvbt?c"}}
+	tests := []struct {
+		uri   string
+		delta int
+		want  string
+	}{
+		{"tv:dvbt?a", 1, "tv:dvbt?b"},
+		{"tv:dvbt?c", 1, "tv:dvbt?a"},
+		{"tv:dvbt?a", -1, "tv:dvbt?c"},
+		{"tv:dvbt?b", -1, "tv:dvbt?a"},
+	}
+	for _, tt := range tests {
+		ch, err := stepChannel(channels, tt.uri, tt.delta)
+		is.NoErr(err)             // stepChannel failed
+		is.Equal(tt.want, ch.URI) // wrong channel
+	}
+	_, err := stepChannel(channels, "tv:dvbt?x", 1)
+	is.True(err != nil) // expected error for unknown channel
+}
+
+func TestFindChannel(t *testing.T) {
+	is := is.New(t)
+
+	channels := []bravia.Channel{{URI: "tv:dvbt?a", DispNum: "1", Title: "BBC ONE"}, {URI: "tv:dvbt?b", DispNum: "7", Title: "SBS"}}
+	ch, err := findChannel(channels, "7")
+	is.NoErr(err)
+	is.Equal("tv:dvbt?b", ch.URI) // wrong channel by number
+	ch, err = findChannel(channels, "bbc one")
+	is.NoErr(err)
+	is.Equal("tv:dvbt?a", ch.URI) // wrong channel by title
+	_, err = findChannel(channels, "9")
+	is.True(err != nil) // expected error for unknown channel
+	is.Equal("tv:dvbt", channelSource("tv:dvbt?trip=1.2.3&srvName=X"))
+	is.Equal("", channelSource("extInput:hdmi?port=1"))
+}
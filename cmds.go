@@ -2,15 +2,26 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"reflect"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
-	"github.com/anoopengineer/edidparser/edid"
-	"github.com/jezek/xgb"
+	"foxygo.at/offscreen/pkg/bravia"
+	"github.com/alecthomas/kong"
 	"github.com/jezek/xgb/randr"
 )
 
@@ -29,253 +40,2112 @@ var (
 // It will typically be wrapped so should be checked with `errors.Is()`.
 var ErrUsage = errors.New("usage error")
 
+// maxRetryBackoff is the longest the CLI waits between retries.
+const maxRetryBackoff = 5 * time.Second
+
+// defaultXIdleTimeout is how long the session must be idle before the screen
+// counts as blanked when the X server has no screen saver, if not given.
+const defaultXIdleTimeout = 10 * time.Minute
+
 // screenFlags is a kong CLI struct to be embedded in command structs that
-// use a [Screen] struct for communicating with an X11 server. It has an
-// [AfterApply] method that creates the [Screen] struct from the flags.
+// use a [ScreenBackend] to watch the screen: a [Screen] communicating with an
+// X11 server or a [WaylandScreen] communicating with a Wayland compositor. It
+// has an [AfterApply] method that creates the backend from the flags.
 //
 // [AfterApply]: https://github.com/alecthomas/kong#hooks-beforereset-beforeresolve-beforeapply-afterapply-and-the-bind-option
 type screenFlags struct {
-	Display      string `env:"DISPLAY" help:"X11 display to connect to"`
+	xFlags
 	Manufacturer string `default:"SNY" help:"EDID manufacturer ID of screen to manage"`
 	ProductCode  uint16 `default:"63747" help:"EDID product code of screen to manage"`
+	EDIDSerial   string `name:"edid-serial" help:"EDID serial number of screen to manage, as shown by list, to tell apart identical screens"`
+	Output       string `help:"Name of the output the screen to manage is connected to, e.g. HDMI-A-1 as shown by list, to identify it by instead of --manufacturer, --product-code and --edid-serial"`
+
+	Backend        string        `name:"screen-backend" default:"auto" enum:"auto,x11,wayland,stdin,dbus,mutter,logind,macos,windows,fake" help:"How to watch the screen: x11 (screen saver), wayland (idle notifications), stdin (idle and resume lines from an idle daemon such as swayidle), fake (synthetic events from --fake-events, for testing), dbus (org.freedesktop.ScreenSaver signals, for KDE Plasma, XFCE, Cinnamon and others), mutter (GNOME's idle monitor), logind (the session being locked or idle, with no display server needed), macos (display sleep), windows (the display turning off or the session being locked), or auto to use macos on macOS, windows on Windows, dbus under KDE Plasma on Wayland, wayland if $WAYLAND_DISPLAY is set and x11 otherwise (use --trigger=screensaver,lock under KDE Plasma on X11)"`
+	WaylandDisplay string        `env:"WAYLAND_DISPLAY" help:"Wayland display to connect to"`
+	FakeEvents     string        `default:"-" placeholder:"FILE" help:"File or FIFO to read synthetic screen events from with --screen-backend=fake, one per line: ss on, ss off, present or absent (- for stdin). The monitor starts present with the screen saver off"`
+	IdleTimeout    time.Duration `default:"10m" help:"How long the session must be idle before the screen counts as blanked with --screen-backend=wayland or mutter"`
+	XIdleTimeout   time.Duration `name:"x-idle-timeout" default:"0s" help:"With --screen-backend=x11, follow the X idle time instead of the screen saver, the screen counting as blanked once the session has been idle this long, for when the screen saver is disabled (0 to follow the screen saver, or 10m of idle time if the X server has none)"`
+
+	Trigger      []string      `default:"screensaver" enum:"screensaver,dpms,lock,lid" help:"What counts as the screen blanking with --screen-backend=x11: screensaver (the X screen saver turning on) or dpms (DPMS putting the monitor to sleep, for setups using xset dpms), or both to follow whichever changes first, and optionally lock (the session being locked) and lid (the laptop lid being closed, also with --screen-backend=logind) as seen by systemd-logind, e.g. screensaver,lock,lid"`
+	DPMSInterval time.Duration `name:"dpms-interval" default:"2s" help:"How often to poll the DPMS power level with --trigger=dpms"`
+	IdleInterval time.Duration `default:"1s" help:"How often to poll the X idle time with --x-idle-timeout, or when the X server has no SCREENSAVER extension"`
+
+	BlankWith     string        `default:"screensaver" enum:"screensaver,dpms,output" help:"How to blank the screen with --screen-backend=x11: screensaver (activate the X screen saver), dpms (force the monitor off with DPMS, which some setups honour more reliably, falling back to the screen saver while DPMS is disabled) or output (turn off only the screen's output, leaving other monitors on)"`
+	WakeOnInput   bool          `help:"Turn the TV on as soon as there is keyboard or mouse input while the screen is blanked with --screen-backend=x11, without waiting for the screen saver to deactivate"`
+	XReconnect    bool          `name:"x-reconnect" default:"true" negatable:"" help:"Reconnect to the X server when the connection is lost, such as when the display manager restarts, instead of exiting"`
+	HotplugSettle time.Duration `default:"500ms" help:"How long monitor hotplug events must settle before checking whether the screen is present with --screen-backend=x11 (0 to check on every event)"`
+	PresenceGrace time.Duration `default:"5s" help:"How long the screen must be gone from RANDR before it is taken to be unplugged with --screen-backend=x11, to ride out HDMI handshake glitches while the TV powers on and off (0 to take it as unplugged straight away)"`
+
+	screen ScreenBackend
+}
+
+// braviaAPI is a kong CLI struct to be embedded in command structs that
+// talk to a Sony Bravia TV set. It contains the parameters to communicate
+// with a TV using the Bravia REST IP control protocol.
+type braviaAPI struct {
+	Hostname string `env:"OFFSCREEN_HOSTNAME" help:"Hostname of Sony Bravia TV, optionally prefixed with https:// to use TLS. If not given, run looks for the TV of the managed monitor on the local network"`
+	PSK      string `env:"OFFSCREEN_PSK" help:"Pre-shared key"`
+	MAC      string `env:"OFFSCREEN_MAC" help:"MAC address of the TV, to wake it with Wake-on-LAN if it is unreachable in standby"`
+	Insecure bool   `env:"OFFSCREEN_INSECURE" help:"Do not verify the TV's TLS certificate when --hostname is an https:// URL (TVs usually have a self-signed certificate)"`
+	Protocol string `env:"OFFSCREEN_PROTOCOL" default:"rest" enum:"rest,simpleip" help:"Protocol to control the TV with: rest, or simpleip (Simple IP control on port 20060) for TVs with the REST API disabled. simpleip only supports the power, input, volume, toggle, wait and watch commands and run"`
+	Serial   string `env:"OFFSCREEN_SERIAL" help:"Serial port of a Bravia professional display to control over RS-232 instead of the network, e.g. /dev/ttyUSB0. Only supports the power, input, volume, toggle, wait and watch commands and run"`
+
+	Retries      int           `default:"2" help:"How many times to retry requests that fail because the TV is unreachable or briefly unresponsive"`
+	RetryBackoff time.Duration `default:"500ms" help:"How long to wait before the first retry, doubling for each subsequent retry"`
+
+	BreakerThreshold int           `default:"3" help:"Fail requests fast after this many requests in a row cannot reach the TV (0 to disable)"`
+	BreakerCooldown  time.Duration `default:"30s" help:"How long to fail requests fast before trying to reach the TV again"`
+
+	InputsTTL time.Duration `default:"1m" help:"How long to cache the TV's inputs and their labels (0 to disable)"`
+
+	Verify          int           `help:"Confirm that power and input changes took effect, requesting them up to this many times (0 to not confirm)"`
+	VerifyInterval  time.Duration `default:"1s" help:"How long to wait after requesting a power or input change before confirming it"`
+	IdempotentPower bool          `help:"Get the TV's power status before turning it on or off and do nothing if it already is, for TVs that return errors otherwise"`
+
+	c *bravia.RESTClient
+}
+
+// client returns a [bravia.RESTClient] for the TV described by the flags. If the
+// MAC address of the TV is not given, it is taken from the cache of
+// previously discovered MAC addresses. Input labels are overridden by the
+// labels file in the config directory, if any. If no PSK is given, the auth
+// cookie from pairing with `tv pair` is used if there is one. The client is
+// created once and the same client is returned on subsequent calls.
+func (b *braviaAPI) client() *bravia.RESTClient {
+	if b.c != nil {
+		return b.c
+	}
+	c := bravia.NewRESTClient(b.Hostname, b.PSK)
+	if b.Insecure {
+		c.SkipTLSVerify()
+	}
+	c.Retry = bravia.RetryPolicy{Attempts: b.Retries + 1, Backoff: b.RetryBackoff, MaxBackoff: maxRetryBackoff}
+	c.Breaker.Threshold = b.BreakerThreshold
+	c.Breaker.Cooldown = b.BreakerCooldown
+	c.InputsTTL = b.InputsTTL
+	c.Verify = bravia.VerifyPolicy{Attempts: b.Verify, Interval: b.VerifyInterval}
+	c.IdempotentPower = b.IdempotentPower
+	c.MAC = b.MAC
+	if c.MAC == "" {
+		c.MAC = cachedMAC(b.Hostname)
+	}
+	c.LabelOverrides = b.labelOverrides(b.Hostname)
+	if c.PSK == "" {
+		c.AuthCookie = cookieCache.get(b.Hostname)
+		diag.AddSecret(c.AuthCookie)
+	}
+	b.c = c
+	return c
+}
+
+// tv returns the control of the TV described by the flags: a
+// [bravia.SerialClient] if --serial is given, otherwise the client for the
+// protocol given by --protocol, the [bravia.RESTClient] from
+// [braviaAPI.client] or a [bravia.SimpleIPClient]. The input label
+// overrides of a display on a serial port are keyed by the port's device
+// path rather than a hostname.
+func (b *braviaAPI) tv() bravia.TV {
+	switch {
+	case b.Serial != "":
+		c := bravia.NewSerialClient(b.Serial)
+		c.LabelOverrides = b.labelOverrides(b.Serial)
+		return c
+	case b.Protocol == "simpleip":
+		c := bravia.NewSimpleIPClient(b.Hostname)
+		c.LabelOverrides = b.labelOverrides(b.Hostname)
+		return c
+	}
+	return b.client()
+}
+
+// labelOverrides returns the input label overrides for the TV with the
+// given key, warning if they cannot be loaded.
+func (b *braviaAPI) labelOverrides(key string) map[string]string {
+	labels, err := loadLabelOverrides(key)
+	if err != nil {
+		warnf("%v", err)
+	}
+	return labels
+}
+
+// BeforeResolve runs before environment variable defaults are applied to
+// the kong structs, allowing us to set build-time values for the Bravia
+// host and PSK before looking in the OFFSCREEN_* env vars.
+// BeforeResolve implements the kong.BeforeResolve interface.
+func (b *braviaAPI) BeforeResolve() error { //nolint:unparam // false positive
+	// Ensure we do not override values set from the environment
+	// at run time (OFFSCREEN_HOSTNAME and OFFSCREEN_PSK).
+	// The kong docs says env settings are not applied until resolve time,
+	// but the code does not actually use a resolver for env values and
+	// instead sets them during the Reset() phase. b.Hostname and/or
+	// b.PSK will be set during Reset() if set from an env var.
+	if b.Hostname == "" {
+		b.Hostname = buildtimeHost
+	}
+	if b.PSK == "" {
+		b.PSK = buildtimePSK
+	}
+	return nil
+}
+
+// RunCmd is the kong CLI struct for the `run` command.
+type RunCmd struct {
+	braviaAPI
+	screenFlags
+
+	Input     string `short:"i" help:"The TV input (label or URI) we are connected to"`
+	OffMode   string `default:"standby" enum:"standby,pictureOff" help:"How to turn the TV off: standby, or pictureOff which blanks the panel and wakes instantly while audio and apps keep running"`
+	AudioSink string `help:"Host audio sink to switch to when the TV is turned off. The previous default sink is restored when the TV is turned back on"`
+
+	Notifications     bool          `default:"true" negatable:"" help:"Subscribe to change notifications from newer TVs so changes made by other hosts or the remote are noticed immediately"`
+	ReconcileInterval time.Duration `default:"0s" help:"How often to poll the TV for changes made by other hosts or the remote (0 to disable)"`
+	OnInputLost       string        `default:"none" enum:"none,blank,lock" help:"What to do to our session when the TV switches away from our input while in use (none,blank,lock). Requires --reconcile-interval or --notifications"`
+
+	InhibitSuspend    bool `help:"Stop the host suspending while the TV is showing our input"`
+	RespectInhibitors bool `default:"true" negatable:"" help:"Do not turn the TV off while an application such as a video player inhibits the screen saver with org.freedesktop.ScreenSaver on the D-Bus session bus"`
+	FollowSession     bool `default:"true" negatable:"" help:"Leave the TV alone while our systemd-logind session is not the active one, such as after switching to another virtual terminal. Only on Linux, and only where logind is running"`
+
+	Scene string `help:"Scene setting to select whenever our input is selected, e.g. game or graphics"`
+	Sound string `placeholder:"TARGET=VALUE,..." help:"Sound settings to apply whenever our input is selected, e.g. \"soundMode=cinema,voiceZoom=2\""`
+
+	PictureSchedule []string `sep:"none" placeholder:"\"HH:MM TARGET=VALUE,...\"" help:"Picture settings to apply from a time of day whenever our input is selected, e.g. \"21:00 brightness=10,colorTemperature=warm2\". Repeat for other times of day"`
+
+	RetargetFile string `type:"path" placeholder:"FILE" help:"File to read the monitor and TV input to manage instead from on SIGHUP, as MONITOR=INPUT with MONITOR as for --monitor, e.g. \"SNY:63747=HDMI 2\", or just =INPUT to keep the monitor. This switches them without restarting, such as after moving the cable to another input of the TV"`
+
+	Monitor []string `sep:"none" placeholder:"MANUFACTURER:PRODUCT-CODE[:EDID-SERIAL]=INPUT@HOSTNAME" help:"Another monitor to manage, connected to INPUT of the TV at HOSTNAME, e.g. \"SNY:63747=HDMI 2@bravia2\". The EDID serial number tells apart identical monitors. The monitor can instead be given by the name of its output, e.g. \"HDMI-A-2=HDMI 1@bravia2\". The TV is controlled with the same --psk and --protocol. Repeat for more monitors. Needs --screen-backend=x11 or wayland"`
+
+	ssInhibitors *screenSaverInhibitors
+	session      *sessionActivity
+}
+
+// ListCmd is the kond CLI struct for the `list` command.
+type ListCmd struct {
+	xFlags
+	JSON bool `help:"Print as JSON"`
+}
+
+// WakeCmd is the kong CLI struct for the `wake` command.
+type WakeCmd struct {
+	screenFlags
+}
+
+// EDIDCmd is the kong CLI struct for the `edid` command.
+type EDIDCmd struct {
+	Dump EDIDCmdDump `cmd:""`
+}
+
+// EDIDCmdDump is the kong CLI struct for the `edid dump` command.
+type EDIDCmdDump struct {
+	xFlags
+	Output string `arg:"" help:"Output the monitor is connected to, e.g. HDMI-A-1 as shown by list"`
+	File   string `short:"o" type:"path" help:"File to write the raw binary EDID to instead of printing it in hex"`
+}
+
+// SonyCmd is the kong CLI struct for the `sony` command.
+type SonyCmd struct {
+	Power     SonyCmdPower     `cmd:""`
+	Input     SonyCmdInput     `cmd:""`
+	Toggle    SonyCmdToggle    `cmd:""`
+	Volume    SonyCmdVolume    `cmd:""`
+	Key       SonyCmdKey       `cmd:""`
+	Keys      SonyCmdKeys      `cmd:""`
+	App       SonyCmdApp       `cmd:""`
+	Info      SonyCmdInfo      `cmd:""`
+	WOL       SonyCmdWOL       `cmd:"" name:"wol"`
+	PowerSave SonyCmdPowerSave `cmd:"" name:"powersave"`
+	API       SonyCmdAPI       `cmd:"" name:"api"`
+	Raw       SonyCmdRaw       `cmd:""`
+	AudioOut  SonyCmdAudioOut  `cmd:"" name:"audio-out"`
+	Sound     SonyCmdSound     `cmd:""`
+	Picture   SonyCmdPicture   `cmd:""`
+	Channel   SonyCmdChannel   `cmd:""`
+	Status    SonyCmdStatus    `cmd:""`
+	Reboot    SonyCmdReboot    `cmd:""`
+	CEC       SonyCmdCEC       `cmd:"" name:"cec"`
+	Pair      SonyCmdPair      `cmd:""`
+	Net       SonyCmdNet       `cmd:""`
+	Rec       SonyCmdRec       `cmd:""`
+	WOLMode   SonyCmdWOLMode   `cmd:"" name:"wolmode"`
+	Browse    SonyCmdBrowse    `cmd:""`
+	Scene     SonyCmdScene     `cmd:""`
+	Batch     SonyCmdBatch     `cmd:""`
+	Playing   SonyCmdPlaying   `cmd:"" name:"now-playing"`
+	Wait      SonyCmdWait      `cmd:""`
+	Watch     SonyCmdWatch     `cmd:""`
+
+	braviaAPI
+}
+
+// SonyCmdPower is the kong CLI struct for the `sony power` command.
+type SonyCmdPower struct {
+	Verbose bool   `short:"v" help:"Show all power status details, and the Wake-on-LAN and power saving modes"`
+	State   string `arg:"" optional:"" default:"" enum:",on,off" help:"Get/set power state"`
+}
+
+// SonyCmdInput is the kong CLI struct for the `sony input` command.
+type SonyCmdInput struct {
+	List  bool   `help:"List inputs with their labels and whether they are connected"`
+	JSON  bool   `help:"List inputs as JSON (with --list)"`
+	Label string `arg:"" optional:"" default:"" help:"Get/set input by label or URI (e.g. extInput:hdmi?port=1, extInput:widi?port=1, tv:dvbt)"`
+}
+
+// SonyCmdVolume is the kong CLI struct for the `sony volume` command.
+type SonyCmdVolume struct {
+	Action string `arg:"" optional:"" default:"get" enum:"get,set,up,down,mute,unmute" help:"Get/set volume, turn it up/down, or mute/unmute"`
+	Level  string `arg:"" optional:"" help:"Volume to set, or how much to turn it up/down by (default 1)"`
+	Target string `default:"speaker" help:"Audio output to control (speaker, headphone)"`
+}
+
+// SonyCmdKey is the kong CLI struct for the `sony key` command.
+type SonyCmdKey struct {
+	List bool   `help:"List the remote control key names of the TV"`
+	Name string `arg:"" optional:"" help:"Remote control key name or IRCC code to send"`
+}
+
+// SonyCmdKeys is the kong CLI struct for the `sony keys` command.
+type SonyCmdKeys struct {
+	Delay time.Duration `default:"500ms" help:"Delay between keys"`
+	File  string        `short:"f" type:"existingfile" help:"Read keys from a macro file"`
+	Keys  []string      `arg:"" optional:"" help:"Key names, IRCC codes or extra pauses (e.g. 2s) to send in order"`
+}
+
+// SonyCmdApp is the kong CLI struct for the `sony app` command.
+type SonyCmdApp struct {
+	Action string `arg:"" optional:"" default:"list" enum:"list,launch,kill" help:"List, launch or kill apps"`
+	Name   string `arg:"" optional:"" help:"Title (or part of it) or URI of the app to launch"`
+}
+
+// SonyCmdInfo is the kong CLI struct for the `sony info` command.
+type SonyCmdInfo struct {
+	JSON bool `help:"Print as JSON"`
+}
+
+// SonyCmdPowerSave is the kong CLI struct for the `sony powersave` command.
+type SonyCmdPowerSave struct {
+	Mode string `arg:"" optional:"" default:"" enum:",off,low,high,pictureOff" help:"Get/set power saving mode"`
+}
+
+// SonyCmdAudioOut is the kong CLI struct for the `sony audio-out` command.
+type SonyCmdAudioOut struct {
+	Terminal string `arg:"" optional:"" help:"Get/set audio output (e.g. speaker, audioSystem, headphone; available outputs vary by model)"`
+}
+
+// SonyCmdSound is the kong CLI struct for the `sony sound` command.
+type SonyCmdSound struct {
+	JSON     bool   `help:"Print as JSON"`
+	Target   string `help:"Only get this sound setting"`
+	Settings string `arg:"" optional:"" placeholder:"TARGET=VALUE,..." help:"Sound settings to set, e.g. \"soundMode=cinema,nightMode=on\""`
+}
+
+// SonyCmdPicture is the kong CLI struct for the `sony picture` command.
+type SonyCmdPicture struct {
+	JSON     bool   `help:"Print as JSON"`
+	Target   string `help:"Only get this picture setting"`
+	Settings string `arg:"" optional:"" placeholder:"on|off|TARGET=VALUE,..." help:"Turn the picture on or off leaving audio and apps running, or picture settings to set, e.g. \"brightness=10,pictureMode=cinema\""`
+}
+
+// SonyCmdChannel is the kong CLI struct for the `sony channel` command.
+type SonyCmdChannel struct {
+	List SonyCmdChannelList `cmd:""`
+	Up   SonyCmdChannelStep `cmd:"" help:"Change to the next channel"`
+	Down SonyCmdChannelStep `cmd:"" help:"Change to the previous channel"`
+	Set  SonyCmdChannelSet  `cmd:""`
+}
+
+// SonyCmdChannelList is the kong CLI struct for the `sony channel list`
+// command.
+type SonyCmdChannelList struct {
+	JSON   bool     `help:"Print as JSON"`
+	Source []string `help:"Tuner sources to list channels of, e.g. tv:dvbt (default all)"`
+}
+
+// SonyCmdChannelStep is the kong CLI struct for the `sony channel up` and
+// `sony channel down` commands.
+type SonyCmdChannelStep struct{}
+
+// SonyCmdChannelSet is the kong CLI struct for the `sony channel set`
+// command.
+type SonyCmdChannelSet struct {
+	Channel string `arg:"" help:"Channel number, title or URI to change to"`
+}
+
+// SonyCmdStatus is the kong CLI struct for the `sony status` command.
+type SonyCmdStatus struct {
+	JSON bool `help:"Print as JSON"`
+}
+
+// SonyCmdReboot is the kong CLI struct for the `sony reboot` command.
+type SonyCmdReboot struct{}
+
+// SonyCmdCEC is the kong CLI struct for the `sony cec` command.
+type SonyCmdCEC struct {
+	Control      string `enum:",on,off" default:"" help:"Turn HDMI-CEC control on or off"`
+	PowerOffSync string `enum:",on,off" default:"" help:"Turn connected devices off when the TV is turned off"`
+	PowerOnSync  string `enum:",on,off" default:"" help:"Turn the TV on when a connected device is turned on"`
+	MHLAutoInput string `name:"mhl-auto-input" enum:",on,off" default:"" help:"Switch to the input of a device connected by MHL, such as a phone, when it is connected"`
+}
+
+// SonyCmdPair is the kong CLI struct for the `sony pair` command.
+type SonyCmdPair struct {
+	PIN string `name:"pin" help:"PIN displayed by the TV (prompted for if not given)"`
+}
+
+// SonyCmdNet is the kong CLI struct for the `sony net` command.
+type SonyCmdNet struct {
+	JSON bool `help:"Print as JSON"`
+}
+
+// SonyCmdRec is the kong CLI struct for the `sony rec` command.
+type SonyCmdRec struct {
+	Status SonyCmdRecStatus `cmd:"" help:"Show whether the TV is recording"`
+	List   SonyCmdRecList   `cmd:"" help:"List scheduled recordings"`
+	Add    SonyCmdRecAdd    `cmd:"" help:"Schedule a recording"`
+	Delete SonyCmdRecDelete `cmd:"" help:"Delete a scheduled recording"`
+}
+
+// SonyCmdRecStatus is the kong CLI struct for the `sony rec status` command.
+type SonyCmdRecStatus struct{}
+
+// SonyCmdRecList is the kong CLI struct for the `sony rec list` command.
+type SonyCmdRecList struct {
+	JSON bool `help:"Print as JSON"`
+}
+
+// SonyCmdRecAdd is the kong CLI struct for the `sony rec add` command.
+type SonyCmdRecAdd struct {
+	Channel  string        `arg:"" help:"Channel number, title or URI to record"`
+	Start    string        `arg:"" help:"Start time, as \"YYYY-MM-DD HH:MM\" in local time or RFC 3339"`
+	Duration time.Duration `arg:"" help:"How long to record for, e.g. 1h30m"`
+	Title    string        `help:"Title of the recording"`
+}
+
+// SonyCmdRecDelete is the kong CLI struct for the `sony rec delete` command.
+type SonyCmdRecDelete struct {
+	URI string `arg:"" help:"URI of the scheduled recording, as listed by rec list"`
+}
+
+// SonyCmdWOLMode is the kong CLI struct for the `sony wolmode` command.
+type SonyCmdWOLMode struct {
+	State string `arg:"" optional:"" default:"" enum:",on,off" help:"Get/set whether the TV wakes on LAN"`
+}
+
+// SonyCmdBrowse is the kong CLI struct for the `sony browse` command.
+type SonyCmdBrowse struct {
+	URL string `arg:"" name:"url" help:"URL to open on the TV"`
+}
+
+// SonyCmdScene is the kong CLI struct for the `sony scene` command.
+type SonyCmdScene struct {
+	Scene string `arg:"" optional:"" help:"Get/set scene setting (e.g. auto, game, graphics; available scenes vary by model)"`
+}
+
+// SonyCmdBatch is the kong CLI struct for the `sony batch` command.
+type SonyCmdBatch struct {
+	StopOnError bool `help:"Stop at the first command that fails"`
+}
+
+// SonyCmdPlaying is the kong CLI struct for the `sony now-playing`
+// command.
+type SonyCmdPlaying struct {
+	JSON bool `help:"Print as JSON"`
+}
+
+// SonyCmdWait is the kong CLI struct for the `sony wait` command.
+type SonyCmdWait struct {
+	Power    string        `enum:",active,standby" default:"" help:"Wait for the TV to be active (on) or in standby (off)"`
+	Input    string        `help:"Wait for the TV to show this input, by label or URI"`
+	Timeout  time.Duration `default:"30s" help:"How long to wait before failing"`
+	Interval time.Duration `default:"1s" help:"How often to check the state of the TV"`
+
+	inputURI string // --input resolved to a URI
+}
+
+// SonyCmdWatch is the kong CLI struct for the `sony watch` command.
+type SonyCmdWatch struct {
+	JSON     bool          `help:"Print each change as a line of JSON"`
+	Interval time.Duration `default:"5s" help:"How often to poll the TV for changes"`
+}
+
+// SonyCmdAPI is the kong CLI struct for the `sony api` command.
+type SonyCmdAPI struct {
+	JSON     bool     `help:"Print as JSON"`
+	Services []string `arg:"" optional:"" help:"Services to list (default all)"`
+}
+
+// SonyCmdRaw is the kong CLI struct for the `sony raw` command.
+type SonyCmdRaw struct {
+	Service string `arg:"" help:"Service of the method to call, e.g. system"`
+	Method  string `arg:"" help:"Method to call, e.g. getPowerStatus"`
+	Version string `name:"api-version" default:"1.0" help:"Version of the method to call"`
+	Params  string `placeholder:"JSON" help:"Parameters of the method as a JSON value, e.g. '{\"status\":true}'"`
+}
+
+// SonyCmdWOL is the kong CLI struct for the `sony wol` command.
+type SonyCmdWOL struct{}
+
+// SonyCmdToggle is the kong CLI struct for the `sony toggle` command.
+type SonyCmdToggle struct {
+	screenFlags
+	Input string `short:"i" help:"Specify host input, do not autodetect"`
+}
+
+// AfterApply creates a new [ScreenBackend] from the flags in the
+// [screenFlags] struct.
+func (sf *screenFlags) AfterApply() error {
+	s, err := sf.newScreen()
+	if err != nil {
+		return err
+	}
+	sf.screen = s
+	return nil
+}
+
+// newScreen creates the screen backend selected by --screen-backend.
+func (sf *screenFlags) newScreen() (ScreenBackend, error) {
+	return sf.newScreenFor(sf.monitor())
+}
+
+// monitor returns the monitor to manage given by --manufacturer,
+// --product-code, --edid-serial and --output.
+func (sf *screenFlags) monitor() monitorSpec {
+	return monitorSpec{
+		manufacturer: sf.Manufacturer,
+		productCode:  sf.ProductCode,
+		serial:       sf.EDIDSerial,
+		output:       sf.Output,
+	}
+}
+
+// newScreenFor creates the screen backend selected by --screen-backend for
+// the given monitor. The TV input and hostname of the monitor are not used.
+// With x11, the displays given with --display are watched together.
+func (sf *screenFlags) newScreenFor(m monitorSpec) (ScreenBackend, error) {
+	backend := sf.backend()
+	if (backend == "mutter" || backend == "wayland") && sf.IdleTimeout <= 0 {
+		return nil, fmt.Errorf("%w: --idle-timeout must be positive", ErrUsage)
+	}
+	switch backend {
+	case "stdin":
+		return newIdleHookScreen(os.Stdin), nil
+	case "fake":
+		return newFakeEventScreen(sf.FakeEvents)
+	case "dbus":
+		return NewScreenSaverDBusScreen()
+	case "mutter":
+		return NewMutterDBusScreen(sf.IdleTimeout)
+	case "logind":
+		return NewLogindDBusScreen(logindTriggers{lock: true, idle: true, lid: sf.triggers()["lid"]})
+	case "wayland":
+		s, err := NewWaylandScreen(sf.WaylandDisplay, sf.IdleTimeout, m.manufacturer, m.productCode)
+		if err != nil {
+			return nil, err
+		}
+		s.MatchSerial(m.serial)
+		s.MatchOutput(m.output)
+		return s, nil
+	case "macos":
+		if m.output != "" {
+			return nil, fmt.Errorf("%w: monitors cannot be matched by output on macOS", ErrUsage)
+		}
+		return NewMacScreen(m.manufacturer, m.productCode, m.serial)
+	case "windows":
+		if m.output != "" {
+			return nil, fmt.Errorf("%w: monitors cannot be matched by output on Windows", ErrUsage)
+		}
+		return NewWindowsScreen(m.manufacturer, m.productCode, m.serial)
+	}
+	displays := sf.displays()
+	screens := make([]ScreenBackend, 0, len(displays))
+	for _, display := range displays {
+		display := display
+		s, err := sf.newX11Screen(m, display)
+		if err != nil {
+			for _, s := range screens {
+				s.Close()
+			}
+			if len(displays) > 1 {
+				err = fmt.Errorf("display %s: %w", display, err)
+			}
+			return nil, err
+		}
+		if sf.XReconnect {
+			s = newReconnectingScreen(s, func() (ScreenBackend, error) { return sf.newX11Screen(m, display) })
+		}
+		screens = append(screens, s)
+	}
+	if len(screens) == 1 {
+		return screens[0], nil
+	}
+	return newMultiScreen(screens), nil
+}
+
+// newX11Screen connects to the X server of the given display and creates the
+// X screen for the given monitor.
+func (sf *screenFlags) newX11Screen(m monitorSpec, display string) (ScreenBackend, error) {
+	c, err := sf.dialDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewScreen(c, m.manufacturer, m.productCode)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	switch {
+	case m.output != "":
+		err = s.MatchOutput(m.output)
+	case m.serial != "":
+		err = s.MatchSerial(m.serial)
+	}
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	s.DebounceHotplug(sf.HotplugSettle)
+	s.PresenceGrace(sf.PresenceGrace)
+	switch sf.BlankWith {
+	case "dpms":
+		if err := s.BlankWithDPMS(); err != nil {
+			s.Close()
+			return nil, err
+		}
+	case "output":
+		s.BlankOutput()
+	}
+	if sf.WakeOnInput {
+		if err := s.WakeOnInput(); err != nil {
+			s.Close()
+			return nil, fmt.Errorf("could not watch for input: %w", err)
+		}
+	}
+	if err := sf.useTriggers(s); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// backend returns the screen backend selected by --screen-backend, choosing
+// one for auto.
+func (sf *screenFlags) backend() string {
+	backend := sf.Backend
+	if backend == "auto" {
+		switch {
+		case runtime.GOOS == "darwin":
+			backend = "macos"
+		case runtime.GOOS == "windows":
+			backend = "windows"
+		case sf.WaylandDisplay != "" && strings.Contains(os.Getenv("XDG_CURRENT_DESKTOP"), "KDE"):
+			// Locking is not idleness on Plasma Wayland.
+			backend = "dbus"
+		case sf.WaylandDisplay != "":
+			backend = "wayland"
+		default:
+			// The dbus backend cannot tell whether the monitor is
+			// present, so X11 Plasma, whose screen locker bypasses
+			// the X screen saver, needs --trigger=lock instead.
+			if strings.Contains(os.Getenv("XDG_CURRENT_DESKTOP"), "KDE") && !sf.triggers()["lock"] {
+				diag.Event("KDE Plasma on X11: use --trigger=screensaver,lock to follow its screen locker")
+			}
+			backend = "x11"
+		}
+	}
+	return backend
+}
+
+// useTriggers sets up the X screen to follow the triggers given with
+// --trigger. The screensaver trigger follows the X idle time instead of the
+// screen saver with --x-idle-timeout or if the X server has no screen saver.
+func (sf *screenFlags) useTriggers(s *Screen) error {
+	triggers := sf.triggers()
+	if triggers["dpms"] && sf.XIdleTimeout > 0 {
+		return fmt.Errorf("%w: --x-idle-timeout cannot be used with --trigger=dpms", ErrUsage)
+	}
+	hybrid := triggers["screensaver"] && triggers["dpms"]
+	if hybrid && !s.HasScreenSaver() {
+		warnf("X server has no SCREENSAVER extension; following DPMS only")
+		hybrid = false
+	}
+	switch {
+	case hybrid:
+		if err := s.UseDPMSWithScreenSaver(sf.DPMSInterval); err != nil {
+			return err
+		}
+	case triggers["dpms"]:
+		if err := s.UseDPMS(sf.DPMSInterval); err != nil {
+			return err
+		}
+	}
+	if triggers["screensaver"] && !triggers["dpms"] && (sf.XIdleTimeout > 0 || !s.HasScreenSaver()) {
+		if !s.HasScreenSaver() {
+			warnf("X server has no SCREENSAVER extension; polling the idle time instead")
+		}
+		timeout := sf.XIdleTimeout
+		if timeout <= 0 {
+			timeout = defaultXIdleTimeout
+		}
+		if err := s.UseIdleTime(sf.IdleInterval, timeout); err != nil {
+			return fmt.Errorf("could not poll idle time: %w", err)
+		}
+	}
+	if triggers["lock"] || triggers["lid"] {
+		lock, err := NewLogindDBusScreen(logindTriggers{lock: triggers["lock"], lid: triggers["lid"]})
+		if err != nil {
+			return fmt.Errorf("could not watch session lock: %w", err)
+		}
+		s.UseLock(lock)
+	}
+	return nil
+}
+
+// triggers returns the set of triggers given with --trigger.
+func (sf *screenFlags) triggers() map[string]bool {
+	triggers := map[string]bool{}
+	for _, t := range sf.Trigger {
+		triggers[t] = true
+	}
+	return triggers
+}
+
+// Run (offscreen run) runs offscreen to turn the connected TV on and off
+// in line with X screen saver events. Each monitor given with --monitor is
+// watched and its TV controlled alongside the main one, until one of them
+// fails or offscreen is shut down.
+func (cmd *RunCmd) Run(ctx context.Context) error {
+	if cmd.RetargetFile != "" {
+		cmd.screen = newRetargetableScreen(cmd.screen)
+	}
+	defer cmd.screen.Close()
+	if cmd.RespectInhibitors {
+		inhibitors, err := newScreenSaverInhibitors()
+		if err != nil {
+			warnf("not respecting screen saver inhibitors: %v", err)
+		} else {
+			defer inhibitors.Close()
+			cmd.ssInhibitors = inhibitors
+		}
+	}
+	if cmd.FollowSession && runtime.GOOS == "linux" {
+		// Many hosts have no logind, so this is only a diagnostic.
+		session, err := newSessionActivity()
+		if err != nil {
+			diag.Event("not following the logind session: %v", err)
+		} else {
+			defer session.Close()
+			cmd.session = session
+		}
+	}
+	if cmd.Hostname == "" && cmd.Serial == "" {
+		if err := cmd.discoverHostname(ctx); err != nil {
+			return err
+		}
+	}
+	if len(cmd.Monitor) == 0 {
+		return cmd.runMonitor(ctx, cmd.screen, &cmd.braviaAPI, cmd.Input)
+	}
+	if b := cmd.backend(); b != "x11" && b != "wayland" && b != "macos" && b != "windows" {
+		return fmt.Errorf("%w: --monitor needs --screen-backend=x11, wayland, macos or windows, not %s", ErrUsage, b)
+	}
+	monitors := make([]monitorSpec, 0, len(cmd.Monitor))
+	for _, spec := range cmd.Monitor {
+		m, err := parseMonitorSpec(spec)
+		if err != nil {
+			return err
+		}
+		monitors = append(monitors, m)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, len(monitors)+1)
+	run := func(name string, screen ScreenBackend, b *braviaAPI, input string) {
+		err := cmd.runMonitor(ctx, screen, b, input)
+		if err != nil {
+			err = fmt.Errorf("monitor %s: %w", name, err)
+		}
+		// Stop the other monitors too.
+		cancel()
+		errs <- err
+	}
+	running := 1
+	go run(cmd.monitor().String(), cmd.screen, &cmd.braviaAPI, cmd.Input)
+	var err error
+	for _, m := range monitors {
+		screen, serr := cmd.newScreenFor(m)
+		if serr != nil {
+			err = fmt.Errorf("monitor %s: %w", m, serr)
+			cancel()
+			break
+		}
+		defer screen.Close()
+		// The TV of the monitor is controlled like the main TV, but with
+		// its own client, MAC address and label overrides.
+		b := cmd.braviaAPI
+		b.Hostname, b.MAC, b.Serial, b.c = m.hostname, "", "", nil
+		running++
+		go run(m.String(), screen, &b, m.input)
+	}
+	for ; running > 0; running-- {
+		if rerr := <-errs; err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// runMonitor turns the TV controlled by b on and off in line with screen
+// until the screen is closed or ctx is cancelled. input is the TV input
+// (label or URI) the monitor is connected to.
+func (cmd *RunCmd) runMonitor(ctx context.Context, screen ScreenBackend, b *braviaAPI, input string) error {
+	c := b.tv()
+	rc, isREST := c.(*bravia.RESTClient)
+	if !isREST && (cmd.OffMode != "standby" || cmd.Scene != "" || cmd.Sound != "" || len(cmd.PictureSchedule) > 0) {
+		return fmt.Errorf("%w: --off-mode=pictureOff, --scene, --sound and --picture-schedule need the REST API (--protocol=rest without --serial)", ErrUsage)
+	}
+	ourInput, err := getInputURI(ctx, c, input)
+	if err != nil {
+		return fmt.Errorf("could not get input URI for %s: %w", input, err)
+	}
+	if isREST {
+		discoverMAC(ctx, rc, b.Hostname)
+		checkWOLMode(ctx, rc)
+	}
+
+	picture, err := parsePictureSchedule(cmd.PictureSchedule)
+	if err != nil {
+		return err
+	}
+	sound, err := parseSoundSettings(cmd.Sound)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tc := &tvController{
+		ctx:         ctx,
+		client:      c,
+		ourInput:    ourInput,
+		screen:      screen,
+		onInputLost: cmd.OnInputLost,
+		offMode:     cmd.OffMode,
+		picture:     picture,
+		sound:       sound,
+		scene:       cmd.Scene,
+	}
+	if cmd.AudioSink != "" {
+		tc.audio = &audioSwitcher{offSink: cmd.AudioSink}
+	}
+	if cmd.InhibitSuspend {
+		tc.inhibitor = &suspendInhibitor{}
+	}
+	tc.ssInhibitors = cmd.ssInhibitors
+	tc.session = cmd.session
+	cmd.session.notifyActive(tc.sessionActive)
+	defer tc.Close()
+	if cmd.ReconcileInterval > 0 {
+		go tc.reconcileLoop(cmd.ReconcileInterval)
+	}
+	if cmd.Notifications && isREST {
+		go tc.notifyLoop()
+	}
+	if r, ok := screen.(*retargetableScreen); ok {
+		go cmd.retargetLoop(ctx, r, tc)
+	}
+	// Stop watching the screen when shutting down, such as on SIGINT.
+	return watchContext(ctx, screen, tc)
+}
+
+// retargetLoop switches the monitor and TV input managed by tc to those read
+// from --retarget-file whenever offscreen receives SIGHUP, until ctx is done.
+// Failing to do so is only a warning, leaving the current ones managed.
+func (cmd *RunCmd) retargetLoop(ctx context.Context, screen *retargetableScreen, tc *tvController) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+		if err := cmd.retarget(ctx, screen, tc); err != nil {
+			warnf("could not retarget: %v", err)
+		}
+	}
+}
+
+// retarget switches the monitor and TV input managed by tc to those read
+// from --retarget-file.
+func (cmd *RunCmd) retarget(ctx context.Context, screen *retargetableScreen, tc *tvController) error {
+	b, err := os.ReadFile(cmd.RetargetFile)
+	if err != nil {
+		return err
+	}
+	m, err := parseRetarget(string(b))
+	if err != nil {
+		return err
+	}
+	input, err := getInputURI(ctx, tc.client, m.input)
+	if err != nil {
+		return fmt.Errorf("could not get input URI for %s: %w", m.input, err)
+	}
+	var s ScreenBackend
+	if m.manufacturer != "" || m.output != "" {
+		if b := cmd.backend(); b != "x11" && b != "wayland" && b != "macos" && b != "windows" {
+			return fmt.Errorf("%w: switching the monitor needs --screen-backend=x11, wayland, macos or windows, not %s", ErrUsage, b)
+		}
+		if s, err = cmd.newScreenFor(m); err != nil {
+			return fmt.Errorf("monitor %s: %w", m, err)
+		}
+	}
+	// Switch the input first so the new screen's state is acted on
+	// with it.
+	err = tc.retarget(input)
+	if s != nil {
+		diag.Event("managing monitor %s", m)
+		screen.retarget(s)
+	}
+	return err
+}
+
+// Run (list) lists all the outputs of the X server with whether a monitor
+// is connected and its name, manufacturer ID, product code and serial number
+// from its EDID, either as a table or as JSON with --json. This is to be able
+// to set the values of `--manufacturer` and `--product-code` (or `--output`)
+// for when the defaults are not correct (as the defaults are for a
+// particular model that offscreen was built for).
+func (cmd *ListCmd) Run() error {
+	c, err := cmd.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if err := randr.Init(c); err != nil {
+		return fmt.Errorf("could not initialise RANDR extension: %w", err)
+	}
+	outputs, err := ListOutputs(c)
+	if err != nil {
+		return err
+	}
+	if cmd.JSON {
+		return printJSON(outputs)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OUTPUT\tCONNECTION\tNAME\tMANUFACTURER\tPRODUCT CODE\tSERIAL")
+	for _, o := range outputs {
+		code := ""
+		if o.Manufacturer != "" {
+			code = strconv.Itoa(int(o.ProductCode))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", o.Output, o.Connection, o.Name, o.Manufacturer, code, o.Serial)
+	}
+	return tw.Flush()
+}
+
+// Run (wake) deactivates the screen saver and forces the monitor on with
+// DPMS, the mirror of blanking the screen with `sony toggle`, so toggling
+// workflows can be scripted in both directions.
+func (cmd *WakeCmd) Run() error {
+	defer cmd.screen.Close()
+	if err := cmd.screen.Unblank(); err != nil {
+		return fmt.Errorf("could not unblank screen: %w", err)
+	}
+	return nil
+}
+
+// Run (edid dump) prints the raw EDID of the monitor connected to an output
+// in hex, as xrandr --verbose does, or writes it in binary to a file with
+// --file. Either can be fed to edid-decode or attached to bug reports.
+func (cmd *EDIDCmdDump) Run() error {
+	c, err := cmd.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if err := randr.Init(c); err != nil {
+		return fmt.Errorf("could not initialise RANDR extension: %w", err)
+	}
+	data, err := EDIDByOutputName(c, cmd.Output)
+	if err != nil {
+		return err
+	}
+	if cmd.File != "" {
+		return os.WriteFile(cmd.File, data, 0o644) //nolint:gosec // EDID is not secret
+	}
+	for len(data) > 0 {
+		n := 16
+		if len(data) < n {
+			n = len(data)
+		}
+		fmt.Println(hex.EncodeToString(data[:n]))
+		data = data[n:]
+	}
+	return nil
+}
+
+// Run (sony power) gets or sets the power state of a Sony Bravia TV. If no
+// argument is provided, the current power state is printed. If the argument is
+// present and is "on", the TV is turned on. If it is "off" the TV is turned
+// off. With --verbose, all the power status details are printed, to help
+// debug why a TV does not wake. They are only available with the REST API.
+func (sc *SonyCmdPower) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.tv()
+	if sc.State == "" && sc.Verbose {
+		rc, ok := c.(*bravia.RESTClient)
+		if !ok {
+			return fmt.Errorf("%w: --verbose needs the REST API (--protocol=rest without --serial)", ErrUsage)
+		}
+		return printPowerDetails(ctx, rc)
+	}
+	if sc.State == "" {
+		state, err := c.PowerStatus(ctx)
+		if err != nil {
+			return fmt.Errorf("power status: %w", err)
+		}
+		fmt.Println(state)
+		return nil
+	}
+	status := false
+	if sc.State == "on" {
+		status = true
+	}
+	return c.SetPowerStatus(ctx, status)
+}
+
+// printPowerDetails prints all the fields of the power status of the TV,
+// with its Wake-on-LAN and power saving modes as they affect whether and how
+// it wakes. The modes are printed as "unknown" if they cannot be retrieved.
+func printPowerDetails(ctx context.Context, c *bravia.RESTClient) error {
+	details, err := c.PowerStatusDetails(ctx)
+	if err != nil {
+		return fmt.Errorf("power status: %w", err)
+	}
+	keys := make([]string, 0, len(details))
+	for k := range details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s:\t%v\n", k, details[k])
+	}
+	wol := "unknown"
+	if enabled, err := c.WOLMode(ctx); err == nil {
+		wol = strconv.FormatBool(enabled)
+	}
+	saving, err := c.PowerSavingMode(ctx)
+	if err != nil {
+		saving = "unknown"
+	}
+	fmt.Fprintf(tw, "wolMode:\t%s\n", wol)
+	fmt.Fprintf(tw, "powerSavingMode:\t%s\n", saving)
+	return tw.Flush()
+}
+
+// Run (sony volume) gets or sets the volume of a Sony Bravia TV. With no
+// arguments or "get", the volume of the target output is printed, followed by
+// "(muted)" if it is muted. "set N" sets the volume to N, and "up"/"down"
+// change the volume by the given amount, or by 1 if no amount is given.
+// "mute" and "unmute" mute and unmute the TV.
+func (sc *SonyCmdVolume) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.tv()
+	if sc.Action == "mute" || sc.Action == "unmute" {
+		if sc.Level != "" {
+			return fmt.Errorf("%w: cannot use a volume level with %s", ErrUsage, sc.Action)
+		}
+		if err := c.SetAudioMute(ctx, sc.Action == "mute"); err != nil {
+			return fmt.Errorf("set mute: %w", err)
+		}
+		return nil
+	}
+	if sc.Action == "get" {
+		if sc.Level != "" {
+			return fmt.Errorf("%w: cannot use a volume level with get", ErrUsage)
+		}
+		infos, err := c.VolumeInformation(ctx)
+		if err != nil {
+			return fmt.Errorf("volume information: %w", err)
+		}
+		for _, info := range infos {
+			if info.Target != sc.Target {
+				continue
+			}
+			muted := ""
+			if info.Mute {
+				muted = " (muted)"
+			}
+			fmt.Printf("%d%s\n", info.Volume, muted)
+			return nil
+		}
+		return fmt.Errorf("tv set does not have audio output: %s", sc.Target)
+	}
+
+	level := sc.Level
+	if level == "" && sc.Action != "set" {
+		level = "1"
+	}
+	n, err := strconv.Atoi(level)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%w: volume level must be a non-negative number: %q", ErrUsage, sc.Level)
+	}
+	switch sc.Action {
+	case "up":
+		level = "+" + level
+	case "down":
+		level = "-" + level
+	}
+	if err := c.SetAudioVolume(ctx, sc.Target, level); err != nil {
+		return fmt.Errorf("set volume: %w", err)
+	}
+	return nil
+}
+
+// Run (sony key) sends a remote control key to a Sony Bravia TV using
+// IRCC-IP, for navigation the REST API cannot do. The key is given by name
+// (e.g. Home, Return, Up, Confirm, Netflix), ignoring case, or as a raw IRCC
+// code. With --list, the known key names are printed.
+func (sc *SonyCmdKey) Run(ctx context.Context, cli *CLI) error {
+	if sc.List {
+		if sc.Name != "" {
+			return fmt.Errorf("%w: cannot use --list with a key name", ErrUsage)
+		}
+		codes := cli.TV.client().IRCCCodes(ctx)
+		names := make([]string, 0, len(codes))
+		for name := range codes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Println(strings.Join(names, "\n"))
+		return nil
+	}
+	if sc.Name == "" {
+		return fmt.Errorf("%w: key name required", ErrUsage)
+	}
+	c := cli.TV.client()
+	code, ok := bravia.IRCCCode(c.IRCCCodes(ctx), sc.Name)
+	if !ok {
+		return fmt.Errorf("%w: unknown key %q (see --list)", ErrUsage, sc.Name)
+	}
+	if err := c.SendIRCC(ctx, code); err != nil {
+		return fmt.Errorf("send key %s: %w", sc.Name, err)
+	}
+	return nil
+}
+
+// Run (sony keys) sends a sequence of remote control keys to a Sony Bravia
+// TV, waiting --delay between each, to automate multi-step navigation such as
+// "Home Down Down Confirm". Keys are given as arguments or in a macro file
+// with --file, or both (file keys first). A macro file contains keys
+// separated by whitespace, with comments from "#" to the end of a line. A
+// duration such as "2s" in place of a key adds an extra pause. All keys are
+// checked before any are sent.
+func (sc *SonyCmdKeys) Run(ctx context.Context, cli *CLI) error {
+	tokens := []string{}
+	if sc.File != "" {
+		b, err := os.ReadFile(sc.File)
+		if err != nil {
+			return fmt.Errorf("could not read macro file: %w", err)
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			line, _, _ = strings.Cut(line, "#")
+			tokens = append(tokens, strings.Fields(line)...)
+		}
+	}
+	tokens = append(tokens, sc.Keys...)
+	if len(tokens) == 0 {
+		return fmt.Errorf("%w: no keys given", ErrUsage)
+	}
+
+	// A step is either a key code to send or a pause.
+	type step struct {
+		name  string
+		code  string
+		pause time.Duration
+	}
+	c := cli.TV.client()
+	codes := c.IRCCCodes(ctx)
+	steps := make([]step, 0, len(tokens))
+	for _, token := range tokens {
+		if d, err := time.ParseDuration(token); err == nil {
+			steps = append(steps, step{name: token, pause: d})
+			continue
+		}
+		code, ok := bravia.IRCCCode(codes, token)
+		if !ok {
+			return fmt.Errorf("%w: unknown key %q (see `tv key --list`)", ErrUsage, token)
+		}
+		steps = append(steps, step{name: token, code: code})
+	}
+
+	sent := false
+	for _, s := range steps {
+		if s.code == "" {
+			if err := sleep(ctx, s.pause); err != nil {
+				return err
+			}
+			continue
+		}
+		if sent {
+			if err := sleep(ctx, sc.Delay); err != nil {
+				return err
+			}
+		}
+		if err := c.SendIRCC(ctx, s.code); err != nil {
+			return fmt.Errorf("send key %s: %w", s.name, err)
+		}
+		sent = true
+	}
+	return nil
+}
+
+// Run (sony app) manages the applications on a Sony Bravia TV. "list" (the
+// default) lists the installed apps with their URIs, "launch <name>" starts
+// the app with that title and "kill" terminates all running apps. The name
+// is matched against app titles ignoring case, first exactly then as a
+// unique part of a title, or can be an app URI.
+func (sc *SonyCmdApp) Run(ctx context.Context, cli *CLI) error {
+	if (sc.Action == "launch") != (sc.Name != "") {
+		return fmt.Errorf("%w: an app name is needed for launch and only for launch", ErrUsage)
+	}
+	c := cli.TV.client()
+	if sc.Action == "kill" {
+		if err := c.TerminateApps(ctx); err != nil {
+			return fmt.Errorf("terminate apps: %w", err)
+		}
+		return nil
+	}
+
+	apps, err := c.Apps(ctx)
+	if err != nil {
+		return fmt.Errorf("get apps: %w", err)
+	}
+	if sc.Action == "list" {
+		sort.Slice(apps, func(i, j int) bool { return apps[i].Title < apps[j].Title })
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TITLE\tURI")
+		for _, app := range apps {
+			fmt.Fprintf(tw, "%s\t%s\n", app.Title, app.URI)
+		}
+		return tw.Flush()
+	}
+
+	uri, err := findApp(apps, sc.Name)
+	if err != nil {
+		return err
+	}
+	if err := c.SetActiveApp(ctx, uri); err != nil {
+		return fmt.Errorf("launch %s: %w", sc.Name, err)
+	}
+	return nil
+}
+
+// findApp returns the URI of the app whose title is name, ignoring case. If
+// there is no such app, name may be part of the title of exactly one app, or
+// it may be the URI of an app.
+func findApp(apps []bravia.App, name string) (string, error) {
+	lname := strings.ToLower(name)
+	var matches []bravia.App
+	for _, app := range apps {
+		switch {
+		case app.URI == name || strings.EqualFold(app.Title, name):
+			return app.URI, nil
+		case strings.Contains(strings.ToLower(app.Title), lname):
+			matches = append(matches, app)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no app matching %q", name)
+	case 1:
+		return matches[0].URI, nil
+	}
+	titles := make([]string, len(matches))
+	for i, app := range matches {
+		titles[i] = app.Title
+	}
+	return "", fmt.Errorf("%q matches more than one app: %s", name, strings.Join(titles, ", "))
+}
+
+// Run (sony info) prints the system information of a Sony Bravia TV: its
+// model, serial number, MAC address, firmware generation and region, either
+// as a table or as JSON with --json.
+func (sc *SonyCmdInfo) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	info, err := c.SystemInformation(ctx)
+	if err != nil {
+		return fmt.Errorf("system information: %w", err)
+	}
+	if info.MACAddr != "" {
+		if err := cacheMAC(cli.TV.Hostname, info.MACAddr); err != nil {
+			warnf("could not cache MAC address of TV: %v", err)
+		}
+	}
+	if sc.JSON {
+		return printJSON(info)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Product:\t%s\n", info.Product)
+	fmt.Fprintf(tw, "Name:\t%s\n", info.Name)
+	fmt.Fprintf(tw, "Model:\t%s\n", info.Model)
+	fmt.Fprintf(tw, "Serial:\t%s\n", info.Serial)
+	fmt.Fprintf(tw, "MAC address:\t%s\n", info.MACAddr)
+	fmt.Fprintf(tw, "Generation:\t%s\n", info.Generation)
+	fmt.Fprintf(tw, "Region:\t%s\n", info.Region)
+	fmt.Fprintf(tw, "Area:\t%s\n", info.Area)
+	fmt.Fprintf(tw, "Language:\t%s\n", info.Language)
+	return tw.Flush()
+}
+
+// Run (sony powersave) gets or sets the power saving mode of a Sony Bravia
+// TV. If no argument is provided, the current mode is printed. Otherwise the
+// mode is set to the argument: "off", "low", "high" or "pictureOff".
+func (sc *SonyCmdPowerSave) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	if sc.Mode == "" {
+		mode, err := c.PowerSavingMode(ctx)
+		if err != nil {
+			return fmt.Errorf("power saving mode: %w", err)
+		}
+		fmt.Println(mode)
+		return nil
+	}
+	return c.SetPowerSavingMode(ctx, sc.Mode)
+}
+
+// Run (sony wol) sends a Wake-on-LAN magic packet to a Sony Bravia TV. The
+// MAC address of the TV is taken from --mac, or from the cache of MAC
+// addresses discovered from the TV. If it is not known, it is discovered
+// from the TV if it is reachable and cached for next time.
+func (sc *SonyCmdWOL) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	discoverMAC(ctx, c, cli.TV.Hostname)
+	if c.MAC == "" {
+		return fmt.Errorf("MAC address of TV is not known; set --mac or run this once while the TV is on")
+	}
+	return bravia.SendWOL(c.MAC)
+}
+
+// Run (sony audio-out) gets or sets the audio output terminal of a Sony
+// Bravia TV, such as its speakers or an external audio system. If no
+// argument is provided, the current output is printed.
+func (sc *SonyCmdAudioOut) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	if sc.Terminal == "" {
+		terminal, err := c.AudioOutput(ctx)
+		if err != nil {
+			return fmt.Errorf("audio output: %w", err)
+		}
+		fmt.Println(terminal)
+		return nil
+	}
+	if err := c.SetAudioOutput(ctx, sc.Terminal); err != nil {
+		// Say which outputs are available if we can, as they vary
+		// by model and with what is connected to the TV.
+		if settings, serr := c.SoundSettings(ctx, "outputTerminal"); serr == nil && len(settings) > 0 {
+			if values := settings[0].CandidateValues(); values != "" {
+				return fmt.Errorf("set audio output: %w (available: %s)", err, values)
+			}
+		}
+		return fmt.Errorf("set audio output: %w", err)
+	}
+	return nil
+}
+
+// Run (sony sound) gets or sets the sound settings of a Sony Bravia TV, such
+// as its sound mode, night mode and voice zoom. If no argument is provided,
+// the current settings and the values they can be set to are printed,
+// either as a table or as JSON with --json.
+func (sc *SonyCmdSound) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	if sc.Settings != "" {
+		settings, err := parseSoundSettings(sc.Settings)
+		if err != nil {
+			return err
+		}
+		if err := c.SetSoundSettings(ctx, settings); err != nil {
+			return fmt.Errorf("set sound settings: %w", err)
+		}
+		return nil
+	}
+	settings, err := c.SoundSettings(ctx, sc.Target)
+	if err != nil {
+		return fmt.Errorf("sound settings: %w", err)
+	}
+	if sc.JSON {
+		return printJSON(settings)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TARGET\tVALUE\tCANDIDATES\n")
+	for _, s := range settings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Target, s.CurrentValue, s.CandidateValues())
+	}
+	return tw.Flush()
+}
+
+// Run (sony picture) gets or sets the picture quality settings of a Sony
+// Bravia TV, such as its brightness, picture mode and light sensor. If no
+// argument is provided, the current settings and the values they can be set
+// to are printed, either as a table or as JSON with --json. An argument of
+// "off" blanks the panel while audio and apps keep running, and "on" turns
+// it back on, restoring the power saving mode from before it was blanked.
+func (sc *SonyCmdPicture) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	if sc.Settings == "on" || sc.Settings == "off" {
+		if err := setPicture(ctx, c, cli.TV.Hostname, sc.Settings == "on"); err != nil {
+			return fmt.Errorf("turn picture %s: %w", sc.Settings, err)
+		}
+		return nil
+	}
+	if sc.Settings != "" {
+		settings, err := parsePictureSettings(sc.Settings)
+		if err != nil {
+			return err
+		}
+		if err := c.SetPictureQualitySettings(ctx, settings); err != nil {
+			return fmt.Errorf("set picture settings: %w", err)
+		}
+		return nil
+	}
+	settings, err := c.PictureQualitySettings(ctx, sc.Target)
+	if err != nil {
+		return fmt.Errorf("picture settings: %w", err)
+	}
+	if sc.JSON {
+		return printJSON(settings)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TARGET\tVALUE\tCANDIDATES\n")
+	for _, s := range settings {
+		if !s.IsAvailable {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Target, s.CurrentValue, s.CandidateValues())
+	}
+	return tw.Flush()
+}
 
-	screen *Screen
+// Run (sony channel list) lists the broadcast channels of the tuners of a
+// Sony Bravia TV, either as a table or as JSON with --json.
+func (sc *SonyCmdChannelList) Run(ctx context.Context, cli *CLI) error {
+	channels, err := cli.TV.client().TunerChannels(ctx, sc.Source...)
+	if err != nil {
+		return err
+	}
+	if sc.JSON {
+		return printJSON(channels)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "NUMBER\tTITLE\tTYPE\tURI\n")
+	for _, ch := range channels {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", ch.DispNum, ch.Title, ch.ProgramMediaType, ch.URI)
+	}
+	return tw.Flush()
 }
 
-// braviaAPI is a kong CLI struct to be embedded in command structs that
-// talk to a Sony Bravia TV set. It contains the parameters to communicate
-// with a TV using the Bravia REST IP control protocol.
-type braviaAPI struct {
-	Hostname string `env:"OFFSCREEN_HOSTNAME" help:"Hostname of Sony Bravia TV"`
-	PSK      string `env:"OFFSCREEN_PSK" help:"Pre-shared key"`
+// Run (sony channel up/down) changes a Sony Bravia TV to the next or previous
+// channel of the tuner it is showing, wrapping around at either end of the
+// channel list.
+func (sc *SonyCmdChannelStep) Run(ctx context.Context, kctx *kong.Context, cli *CLI) error {
+	delta := 1
+	if kctx.Selected().Name == "down" {
+		delta = -1
+	}
+	c := cli.TV.client()
+	uri, err := c.SelectedInput(ctx)
+	if err != nil {
+		return fmt.Errorf("selected input: %w", err)
+	}
+	source := channelSource(uri)
+	if source == "" {
+		return fmt.Errorf("TV is not showing a tuner channel (showing %s)", uri)
+	}
+	channels, err := c.Channels(ctx, source)
+	if err != nil {
+		return fmt.Errorf("channels of %s: %w", source, err)
+	}
+	ch, err := stepChannel(channels, uri, delta)
+	if err != nil {
+		return err
+	}
+	return c.SetInput(ctx, ch.URI)
 }
 
-// BeforeResolve runs before environment variable defaults are applied to
-// the kong structs, allowing us to set build-time values for the Bravia
-// host and PSK before looking in the OFFSCREEN_* env vars.
-// BeforeResolve implements the kong.BeforeResolve interface.
-func (b *braviaAPI) BeforeResolve() error { //nolint:unparam // false positive
-	// Ensure we do not override values set from the environment
-	// at run time (OFFSCREEN_HOSTNAME and OFFSCREEN_PSK).
-	// The kong docs says env settings are not applied until resolve time,
-	// but the code does not actually use a resolver for env values and
-	// instead sets them during the Reset() phase. b.Hostname and/or
-	// b.PSK will be set during Reset() if set from an env var.
-	if b.Hostname == "" {
-		b.Hostname = buildtimeHost
+// Run (sony channel set) changes a Sony Bravia TV to a channel given by its
+// number or title, as listed by `sony channel list`, or by its URI. Channels
+// of all the TV's tuners are searched for the number or title.
+func (sc *SonyCmdChannelSet) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	if channelSource(sc.Channel) != "" {
+		return c.SetInput(ctx, sc.Channel)
 	}
-	if b.PSK == "" {
-		b.PSK = buildtimePSK
+	channels, err := c.TunerChannels(ctx)
+	if err != nil {
+		return err
+	}
+	ch, err := findChannel(channels, sc.Channel)
+	if err != nil {
+		return err
+	}
+	return c.SetInput(ctx, ch.URI)
+}
+
+// Run (sony status) prints the power status, selected input, volume, running
+// app and application indicators (such as textInput, when the TV is waiting
+// for text entry) of a Sony Bravia TV, either as a table or as JSON with
+// --json.
+func (sc *SonyCmdStatus) Run(ctx context.Context, cli *CLI) error {
+	status, err := cli.TV.client().Status(ctx)
+	if err != nil {
+		return err
+	}
+	if sc.JSON {
+		return printJSON(status)
+	}
+	input := status.Input
+	if status.InputLabel != "" {
+		input = fmt.Sprintf("%s (%s)", status.InputLabel, status.Input)
+	}
+	volume := ""
+	if status.Volume != nil {
+		volume = strconv.Itoa(*status.Volume)
+		if *status.Mute {
+			volume += " (muted)"
+		}
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Power:\t%s\n", status.Power)
+	fmt.Fprintf(tw, "Input:\t%s\n", input)
+	fmt.Fprintf(tw, "Volume:\t%s\n", volume)
+	fmt.Fprintf(tw, "App:\t%s\n", status.App)
+	names := make([]string, 0, len(status.AppStatus))
+	for name := range status.AppStatus {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s:\t%s\n", name, status.AppStatus[name])
+	}
+	return tw.Flush()
+}
+
+// Run (sony reboot) reboots a Sony Bravia TV, such as when Android TV has
+// hung.
+func (sc *SonyCmdReboot) Run(ctx context.Context, cli *CLI) error {
+	if err := cli.TV.client().Reboot(ctx); err != nil {
+		return fmt.Errorf("reboot: %w", err)
 	}
 	return nil
 }
 
-// RunCmd is the kong CLI struct for the `run` command.
-type RunCmd struct {
-	braviaAPI
-	screenFlags
+// Run (sony cec) changes the HDMI-CEC settings of a Sony Bravia TV, such as
+// power sync, which can fight with offscreen turning the TV on and off. The
+// TV does not report these settings, so at least one must be given.
+func (sc *SonyCmdCEC) Run(ctx context.Context, cli *CLI) error {
+	if sc.Control == "" && sc.PowerOffSync == "" && sc.PowerOnSync == "" && sc.MHLAutoInput == "" {
+		return fmt.Errorf("%w: no CEC settings given", ErrUsage)
+	}
+	onOff := func(s string) *bool {
+		if s == "" {
+			return nil
+		}
+		b := s == "on"
+		return &b
+	}
+	c := cli.TV.client()
+	if sc.Control != "" {
+		if err := c.SetCECControl(ctx, sc.Control == "on"); err != nil {
+			return fmt.Errorf("set CEC control: %w", err)
+		}
+	}
+	if sc.PowerOffSync != "" || sc.PowerOnSync != "" {
+		if err := c.SetCECPowerSync(ctx, onOff(sc.PowerOffSync), onOff(sc.PowerOnSync)); err != nil {
+			return fmt.Errorf("set CEC power sync: %w", err)
+		}
+	}
+	if sc.MHLAutoInput != "" {
+		if err := c.SetMHLAutoInput(ctx, sc.MHLAutoInput == "on"); err != nil {
+			return fmt.Errorf("set MHL auto input: %w", err)
+		}
+	}
+	return nil
+}
 
-	Input string `short:"i" help:"The TV input (label or URI) we are connected to"`
+// Run (sony pair) pairs offscreen with a Sony Bravia TV using a PIN
+// displayed on the TV, for TVs that do not have a Pre-Shared Key set. The
+// auth cookie from pairing is saved and used for later commands when no PSK
+// is given.
+func (sc *SonyCmdPair) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	c.AuthCookie = ""
+	cookie, err := c.Register(ctx, sc.PIN)
+	if errors.Is(err, bravia.ErrPINRequired) {
+		var pin string
+		fmt.Print("Enter the PIN displayed on the TV: ")
+		if _, err := fmt.Scanln(&pin); err != nil {
+			return fmt.Errorf("could not read PIN: %w", err)
+		}
+		cookie, err = c.Register(ctx, pin)
+	}
+	if err != nil {
+		return fmt.Errorf("pair: %w", err)
+	}
+	if err := cookieCache.set(cli.TV.Hostname, cookie); err != nil {
+		return fmt.Errorf("could not save auth cookie: %w", err)
+	}
+	fmt.Println("Paired with", cli.TV.Hostname)
+	return nil
 }
 
-// ListCmd is the kond CLI struct for the `list` command.
-type ListCmd struct {
-	Display string `env:"DISPLAY" help:"X11 display to connect to"`
+// Run (sony net) prints the network settings of each network interface of a
+// Sony Bravia TV, either as a table or as JSON with --json. The MAC address
+// of the connected interface is cached for Wake-on-LAN.
+func (sc *SonyCmdNet) Run(ctx context.Context, cli *CLI) error {
+	netifs, err := cli.TV.client().NetworkSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("network settings: %w", err)
+	}
+	if mac := connectedMAC(netifs); mac != "" {
+		if err := cacheMAC(cli.TV.Hostname, mac); err != nil {
+			warnf("could not cache MAC address of TV: %v", err)
+		}
+	}
+	if sc.JSON {
+		return printJSON(netifs)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "INTERFACE\tIP\tNETMASK\tGATEWAY\tMAC\n")
+	for _, n := range netifs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", n.Netif, n.IPAddrV4, n.Netmask, n.Gateway, n.HWAddr)
+	}
+	return tw.Flush()
 }
 
-// SonyCmd is the kong CLI struct for the `sony` command.
-type SonyCmd struct {
-	Power  SonyCmdPower  `cmd:""`
-	Input  SonyCmdInput  `cmd:""`
-	Toggle SonyCmdToggle `cmd:""`
+// Run (sony rec status) prints whether a Sony Bravia TV is recording.
+func (sc *SonyCmdRecStatus) Run(ctx context.Context, cli *CLI) error {
+	status, err := cli.TV.client().RecordingStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("recording status: %w", err)
+	}
+	fmt.Println(status)
+	return nil
+}
 
-	braviaAPI
+// Run (sony rec list) lists the scheduled recordings of a Sony Bravia TV,
+// either as a table or as JSON with --json.
+func (sc *SonyCmdRecList) Run(ctx context.Context, cli *CLI) error {
+	recordings, err := cli.TV.client().Recordings(ctx)
+	if err != nil {
+		return fmt.Errorf("scheduled recordings: %w", err)
+	}
+	if sc.JSON {
+		return printJSON(recordings)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "START\tDURATION\tCHANNEL\tTITLE\tSTATUS\tURI\n")
+	for _, r := range recordings {
+		d := time.Duration(r.DurationSec) * time.Second
+		fmt.Fprintf(tw, "%s\t%v\t%s\t%s\t%s\t%s\n", r.StartDateTime, d, r.ChannelName, r.Title, r.RecordingStatus, r.URI)
+	}
+	return tw.Flush()
 }
 
-// SonyCmdPower is the kong CLI struct for the `sony power` command.
-type SonyCmdPower struct {
-	State string `arg:"" optional:"" default:"" enum:",on,off" help:"Get/set power state"`
+// Run (sony rec add) schedules a recording on a Sony Bravia TV of a channel
+// given by its number, title or URI as for `sony channel set`.
+func (sc *SonyCmdRecAdd) Run(ctx context.Context, cli *CLI) error {
+	start, err := time.ParseInLocation("2006-01-02 15:04", sc.Start, time.Local)
+	if err != nil {
+		if start, err = time.Parse(time.RFC3339, sc.Start); err != nil {
+			return fmt.Errorf("%w: bad start time %q", ErrUsage, sc.Start)
+		}
+	}
+	c := cli.TV.client()
+	uri := sc.Channel
+	if channelSource(uri) == "" {
+		channels, err := c.TunerChannels(ctx)
+		if err != nil {
+			return err
+		}
+		ch, err := findChannel(channels, sc.Channel)
+		if err != nil {
+			return err
+		}
+		uri = ch.URI
+	}
+	if err := c.AddRecording(ctx, uri, sc.Title, start, sc.Duration); err != nil {
+		return fmt.Errorf("add recording: %w", err)
+	}
+	return nil
 }
 
-// SonyCmdInput is the kong CLI struct for the `sony input` command.
-type SonyCmdInput struct {
-	List  bool
-	Label string `arg:"" optional:"" default:"" help:"Get/set input"`
+// Run (sony rec delete) deletes a scheduled recording on a Sony Bravia TV.
+func (sc *SonyCmdRecDelete) Run(ctx context.Context, cli *CLI) error {
+	if err := cli.TV.client().DeleteRecording(ctx, sc.URI); err != nil {
+		return fmt.Errorf("delete recording: %w", err)
+	}
+	return nil
 }
 
-// SonyCmdToggle is the kong CLI struct for the `sony toggle` command.
-type SonyCmdToggle struct {
-	screenFlags
-	Input string `short:"i" help:"Specify host input, do not autodetect"`
+// Run (sony wolmode) gets or sets whether a Sony Bravia TV can be woken
+// with Wake-on-LAN. If no argument is provided, "on" or "off" is printed.
+func (sc *SonyCmdWOLMode) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	if sc.State == "" {
+		enabled, err := c.WOLMode(ctx)
+		if err != nil {
+			return fmt.Errorf("wake-on-lan mode: %w", err)
+		}
+		state := "off"
+		if enabled {
+			state = "on"
+		}
+		fmt.Println(state)
+		return nil
+	}
+	return c.SetWOLMode(ctx, sc.State == "on")
 }
 
-// AfterApply creates a new [Screen] from the flags in the [screenFlags] struct.
-func (sf *screenFlags) AfterApply() error {
-	s, err := NewScreen(sf.Display, sf.Manufacturer, sf.ProductCode)
+// Run (sony browse) opens a URL in the web browser of a Sony Bravia TV.
+func (sc *SonyCmdBrowse) Run(ctx context.Context, cli *CLI) error {
+	if err := cli.TV.client().OpenURL(ctx, sc.URL); err != nil {
+		return fmt.Errorf("open URL: %w", err)
+	}
+	return nil
+}
+
+// Run (sony scene) gets or sets the scene setting of a Sony Bravia TV. If
+// no argument is provided, the current scene is printed.
+func (sc *SonyCmdScene) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	if sc.Scene == "" {
+		scene, err := c.SceneSetting(ctx)
+		if err != nil {
+			return fmt.Errorf("scene setting: %w", err)
+		}
+		fmt.Println(scene)
+		return nil
+	}
+	return c.SetSceneSetting(ctx, sc.Scene)
+}
+
+// Run (sony batch) reads `sony` commands from stdin and runs them in order
+// with the same TV client, e.g. "power on", "input HDMI 2" and "key Home".
+// Commands are either one per line, with blank lines and lines starting with
+// # ignored, or a JSON array with each command as a string or as an array of
+// arguments. TV flags given with a command, such as --verify or --hostname,
+// apply to that command only, which then uses a client of its own. If a
+// command fails, the error is printed and the remaining commands are run
+// unless --stop-on-error is given. An error is returned if any command
+// failed.
+func (sc *SonyCmdBatch) Run(ctx context.Context, cli *CLI) error {
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("could not read commands: %w", err)
+	}
+	cmds, err := parseBatch(b)
 	if err != nil {
 		return err
 	}
-	sf.screen = s
+	cli.TV.client() // create the client to be shared by all commands
+	failed := 0
+	for _, args := range cmds {
+		if err := runBatchCommand(ctx, cli, args); err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "offscreen: tv %s: %v\n", strings.Join(args, " "), withHint(err))
+			if sc.StopOnError {
+				break
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d commands failed", failed, len(cmds))
+	}
 	return nil
 }
 
-// Run (offscreen run) runs offscreen to turn the connected TV on and off
-// in line with X screen saver events.
-func (cmd *RunCmd) Run() (err error) {
-	defer cmd.screen.Close()
+// parseBatch parses the commands for `sony batch` into the arguments of
+// each command.
+func parseBatch(b []byte) ([][]string, error) {
+	var cmds [][]string
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '[' {
+		var list []json.RawMessage
+		if err := json.Unmarshal(trimmed, &list); err != nil {
+			return nil, fmt.Errorf("%w: bad JSON command list: %v", ErrUsage, err)
+		}
+		for _, raw := range list {
+			var line string
+			var args []string
+			if err := json.Unmarshal(raw, &line); err == nil {
+				args = strings.Fields(line)
+			} else if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("%w: command %s is not a string or array of strings", ErrUsage, raw)
+			}
+			if len(args) > 0 {
+				cmds = append(cmds, args)
+			}
+		}
+		return cmds, nil
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cmds = append(cmds, strings.Fields(line))
+	}
+	return cmds, nil
+}
 
-	c := NewRESTClient(cmd.Hostname, cmd.PSK)
-	ourInput, err := getInputURI(c, cmd.Input)
+// runBatchCommand parses args as a `sony` command and runs it with the TV
+// flags and client of cli. TV flags given in args override those of cli,
+// with a new client made for the command if any are given.
+func runBatchCommand(ctx context.Context, cli *CLI, args []string) error {
+	if args[0] == "batch" {
+		return fmt.Errorf("%w: batch commands cannot be nested", ErrUsage)
+	}
+	var sub CLI
+	parser, err := kong.New(&sub, kongOptions()...)
+	if err != nil {
+		return err
+	}
+	kctx, err := parser.Parse(append([]string{"tv"}, args...))
 	if err != nil {
-		return fmt.Errorf("could not get input URI for %s: %w", cmd.Input, err)
+		return fmt.Errorf("%w: %v", ErrUsage, err)
 	}
+	sub.TV.braviaAPI = mergeBraviaAPI(cli.TV.braviaAPI, &sub.TV.braviaAPI, kctx)
+	kctx.BindTo(ctx, (*context.Context)(nil))
+	return kctx.Run(&sub)
+}
 
-	watcher := ScreenWatcherFunc(func(ssOn bool) error {
-		return ssChange(c, ourInput, ssOn)
-	})
-	return cmd.screen.Watch(watcher)
+// mergeBraviaAPI returns the TV flags of base with those given on the
+// command line parsed by kctx into line overridden. The client of base is
+// only kept if no flags were overridden, as it is made from the flags.
+func mergeBraviaAPI(base braviaAPI, line *braviaAPI, kctx *kong.Context) braviaAPI {
+	lineV := reflect.ValueOf(line).Elem()
+	given := map[uintptr]bool{}
+	for _, p := range kctx.Path {
+		if p.Flag != nil && p.Flag.Target.CanAddr() {
+			given[p.Flag.Target.Addr().Pointer()] = true
+		}
+	}
+	merged := base
+	mergedV := reflect.ValueOf(&merged).Elem()
+	for i := 0; i < lineV.NumField(); i++ {
+		if lineV.Type().Field(i).IsExported() && given[lineV.Field(i).Addr().Pointer()] {
+			mergedV.Field(i).Set(lineV.Field(i))
+			merged.c = nil
+		}
+	}
+	return merged
 }
 
-// ssChange handles a screen saver change event, turning the TV on or
-// off and possibly selecting our input on the TV.
-func ssChange(c *RESTClient, ourInput string, ssOn bool) error {
-	status, err := c.PowerStatus()
+// Run (sony now-playing) prints what a Sony Bravia TV is showing: the
+// input, or the channel and program for tuner content, either as a table or
+// as JSON with --json.
+func (sc *SonyCmdPlaying) Run(ctx context.Context, cli *CLI) error {
+	info, err := cli.TV.client().PlayingContent(ctx)
 	if err != nil {
-		return fmt.Errorf("could not get power status: %w", err)
+		return fmt.Errorf("playing content: %w", err)
 	}
-
-	// If the TV is off and the screen saver turns on, nothing to do
-	// because the TV is already off.
-	if status == "standby" && ssOn {
-		return nil
+	if info == nil {
+		info = &bravia.PlayingContentInfo{}
+	}
+	if sc.JSON {
+		return printJSON(info)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Source:\t%s\n", info.Source)
+	fmt.Fprintf(tw, "URI:\t%s\n", info.URI)
+	fmt.Fprintf(tw, "Title:\t%s\n", info.Title)
+	if info.DispNum != "" {
+		fmt.Fprintf(tw, "Channel:\t%s\n", info.DispNum)
 	}
+	if info.ProgramTitle != "" {
+		fmt.Fprintf(tw, "Program:\t%s\n", info.ProgramTitle)
+		fmt.Fprintf(tw, "Type:\t%s\n", info.ProgramMediaType)
+		fmt.Fprintf(tw, "Start:\t%s\n", info.StartDateTime)
+		fmt.Fprintf(tw, "Duration:\t%v\n", time.Duration(info.DurationSec)*time.Second)
+	}
+	return tw.Flush()
+}
 
-	// If the TV is off and the screen saver turns off, turn on the TV.
-	// We may later change the input, but we can't do that now because we
-	// cannot get the current input until the TV is on.
-	if status == "standby" && !ssOn {
-		if err := c.SetPowerStatus(true); err != nil {
-			return fmt.Errorf("could not set power status: %w", err)
+// Run (sony wait) waits until a Sony Bravia TV is in the power state given
+// by --power and showing the input given by --input, so scripts can sequence
+// actions after waking the TV. Waiting for an input implies waiting for the
+// TV to be on. The TV is polled every --interval, and errors while polling
+// are ignored as the TV may be unreachable while it wakes. If the TV does
+// not reach the state within --timeout, an error is returned.
+func (sc *SonyCmdWait) Run(ctx context.Context, cli *CLI) error {
+	if sc.Power == "" && sc.Input == "" {
+		return fmt.Errorf("%w: nothing to wait for; use --power or --input", ErrUsage)
+	}
+	if sc.Power == "standby" && sc.Input != "" {
+		return fmt.Errorf("%w: cannot wait for an input with --power standby", ErrUsage)
+	}
+	ctx, cancel := context.WithTimeout(ctx, sc.Timeout)
+	defer cancel()
+	c := cli.TV.tv()
+	var lastErr error
+	for {
+		done, err := sc.reached(ctx, c)
+		if done {
+			return nil
+		}
+		if err != nil {
+			diag.Event("wait: %v", err)
+			lastErr = err
+		}
+		if err := sleep(ctx, sc.Interval); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return sc.timedOut(lastErr)
+			}
+			return err
 		}
 	}
+}
 
-	// Get the selected input. We cannot do this before turning on the
-	// TV otherwise the Bravia REST API returns an error.
-	input, err := c.SelectedInput()
+// reached returns true if the TV is in the state waited for. The input
+// waited for is resolved to a URI the first time the TV's input labels can
+// be retrieved.
+func (sc *SonyCmdWait) reached(ctx context.Context, c bravia.TV) (bool, error) {
+	power, err := c.PowerStatus(ctx)
 	if err != nil {
-		return fmt.Errorf("could not get selected input: %w", err)
+		return false, err
 	}
-
-	// If we turned on the TV and the currently selected input is not us,
-	// select our input.
-	if status == "standby" && !ssOn && input != ourInput {
-		if err := c.SetInput(ourInput); err != nil {
-			return fmt.Errorf("could not set input: %w", err)
+	if sc.Input == "" {
+		return power == sc.Power, nil
+	}
+	if power != "active" {
+		return false, nil
+	}
+	if sc.inputURI == "" {
+		uri, err := getInputURI(ctx, c, sc.Input)
+		if err != nil {
+			return false, err
 		}
-		return nil
+		sc.inputURI = uri
+	}
+	input, err := c.SelectedInput(ctx)
+	return err == nil && input == sc.inputURI, err
+}
+
+func (sc *SonyCmdWait) timedOut(lastErr error) error {
+	var state []string
+	if sc.Power != "" {
+		state = append(state, "power "+sc.Power)
+	}
+	if sc.Input != "" {
+		state = append(state, "input "+sc.Input)
+	}
+	err := fmt.Errorf("TV did not reach %s within %v", strings.Join(state, " and "), sc.Timeout)
+	if lastErr != nil {
+		err = fmt.Errorf("%w (last error: %v)", err, lastErr)
 	}
+	return err
+}
 
-	// If the TV is on and the screen saver turns on, we turn off
-	// the TV but only if our input is the current input. Otherwise
-	// we leave it alone - the TV is showing the screen of another
-	// machine so we should not blank the screen.
-	if status == "active" && ssOn && input == ourInput {
-		if err := c.SetPowerStatus(false); err != nil {
-			return fmt.Errorf("could not set power status: %w", err)
+// Run (sony watch) prints a line for each change of the power status,
+// input, volume and mute state of a Sony Bravia TV until interrupted, as
+// text or as JSON with --json, starting with the current state. The TV is
+// polled every --interval and, if it is controlled with the REST API and
+// supports notifications, whenever it notifies a change.
+func (sc *SonyCmdWatch) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.tv()
+	changed := make(chan struct{}, 1)
+	if rc, ok := c.(*bravia.RESTClient); ok {
+		go func() {
+			err := rc.Subscribe(ctx, func(bravia.Notification) {
+				select {
+				case changed <- struct{}{}:
+				default: // a poll is already pending
+				}
+			})
+			if err != nil {
+				diag.Event("watch: not using notifications: %v", err)
+			}
+		}()
+	}
+	ticker := time.NewTicker(sc.Interval)
+	defer ticker.Stop()
+	state := map[string]string{}
+	for {
+		if err := sc.poll(ctx, c, state, os.Stdout); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-changed:
 		}
 	}
+}
 
+// watchFields are the parts of the state of the TV printed by `sony watch`,
+// in the order they are printed.
+var watchFields = []string{"power", "input", "volume", "mute"}
+
+// poll gets the state of the TV and prints the parts of it that differ from
+// state to w, updating state. The input, volume and mute state are only
+// known while the TV is on. Errors getting the state are recorded in the
+// diagnostics as the TV may be unreachable for a while; only errors writing
+// to w are returned.
+func (sc *SonyCmdWatch) poll(ctx context.Context, c bravia.TV, state map[string]string, w io.Writer) error {
+	current := map[string]string{}
+	power, err := c.PowerStatus(ctx)
+	if err != nil {
+		diag.Event("watch: power status: %v", err)
+		return nil
+	}
+	current["power"] = power
+	if power == "active" {
+		if input, err := c.SelectedInput(ctx); err == nil {
+			current["input"] = input
+			if labels, err := c.InputLabels(ctx); err == nil && labels[input] != "" && labels[input] != input {
+				current["input"] = fmt.Sprintf("%s (%s)", labels[input], input)
+			}
+		}
+		if infos, err := c.VolumeInformation(ctx); err == nil {
+			for _, info := range infos {
+				if info.Target == "speaker" || len(infos) == 1 {
+					current["volume"] = strconv.Itoa(info.Volume)
+					current["mute"] = strconv.FormatBool(info.Mute)
+				}
+			}
+		}
+	}
+	now := time.Now().Format(time.RFC3339)
+	for _, field := range watchFields {
+		value, ok := current[field]
+		if !ok || value == state[field] {
+			continue
+		}
+		state[field] = value
+		var err error
+		if sc.JSON {
+			err = json.NewEncoder(w).Encode(map[string]string{"time": now, "field": field, "value": value})
+		} else {
+			_, err = fmt.Fprintln(w, now, field, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// Forget what is unknown while the TV is off so it is printed again
+	// once the TV is back on.
+	for field := range state {
+		if _, ok := current[field]; !ok {
+			delete(state, field)
+		}
+	}
 	return nil
 }
 
-// Run (list) lists the manufacturer ID and product code of all monitors
-// connected to the host. This is to be able to set the values of
-// `--manufacturer` and `--product-code` for when the defaults are not correct
-// (as the defaults are for a particular model that offscreen was built for).
-func (cmd *ListCmd) Run() error {
-	c, err := xgb.NewConnDisplay(cmd.Display)
+// Run (sony api) lists the services, methods and versions of the REST IP
+// control protocol supported by a Sony Bravia TV, either as a table or as
+// JSON with --json. If services are given, only those are listed.
+func (sc *SonyCmdAPI) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.client()
+	infos, err := c.SupportedAPIInfo(ctx, sc.Services...)
 	if err != nil {
-		return fmt.Errorf("could not open display %s: %w", cmd.Display, err)
+		return fmt.Errorf("supported API info: %w", err)
 	}
-	if err := randr.Init(c); err != nil {
-		return fmt.Errorf("could not initialise RANDR extension: %w", err)
+	if sc.JSON {
+		return printJSON(infos)
 	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Service < infos[j].Service })
 	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	defer tw.Flush() //nolint:errcheck // nothing to do, not a big deal
-	fmt.Fprintln(tw, "DISPLAY\tManufacturer ID\tProduct Code")
-	return RangeEDID(c, 0, func(output randr.Output, e *edid.Edid) (bool, error) {
-		oi, err := randr.GetOutputInfo(c, output, 0).Reply()
-		if err != nil {
-			return false, fmt.Errorf("could not get info for output: %w", err)
+	fmt.Fprintf(tw, "SERVICE\tMETHOD\tVERSIONS\n")
+	for _, info := range infos {
+		apis := info.APIs
+		sort.Slice(apis, func(i, j int) bool { return apis[i].Name < apis[j].Name })
+		for _, api := range apis {
+			versions := make([]string, 0, len(api.Versions))
+			for _, v := range api.Versions {
+				versions = append(versions, v.Version)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", info.Service, api.Name, strings.Join(versions, ", "))
 		}
-		fmt.Fprintf(tw, "%s\t%s\t%d\n", string(oi.Name), e.ManufacturerId, e.ProductCode)
-		return true, nil
-	})
+	}
+	return tw.Flush()
 }
 
-// Run (sony power) gets or sets the power state of a Sony Bravia TV. If no
-// argument is provided, the current power state is printed. If the argument is
-// present and is "on", the TV is turned on. If it is "off" the TV is turned
-// off.
-func (sc *SonyCmdPower) Run(cli *CLI) error {
-	c := NewRESTClient(cli.TV.Hostname, cli.TV.PSK)
-	if sc.State == "" {
-		state, err := c.PowerStatus()
-		if err != nil {
-			return fmt.Errorf("power status: %w", err)
+// Run (sony raw) sends a request for an arbitrary method of the REST IP
+// control protocol to a Sony Bravia TV and prints the result as JSON. The
+// method is called with exactly the given version; it is not negotiated.
+func (sc *SonyCmdRaw) Run(ctx context.Context, cli *CLI) error {
+	var params any
+	if sc.Params != "" {
+		if !json.Valid([]byte(sc.Params)) {
+			return fmt.Errorf("%w: --params is not valid JSON", ErrUsage)
 		}
-		fmt.Println(state)
-		return nil
+		params = json.RawMessage(sc.Params)
 	}
-	status := false
-	if sc.State == "on" {
-		status = true
+	c := cli.TV.client()
+	result, err := c.Call(ctx, sc.Service, sc.Method, sc.Version, params)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", sc.Service, sc.Method, err)
 	}
-	return c.SetPowerStatus(status)
+	if result == nil {
+		return nil
+	}
+	return printJSON(result)
+}
+
+// printJSON prints v to stdout as indented JSON.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
 }
 
 // Run (sony input) gets or sets the currently displayed input of a Sony Bravia
 // TV set. If no argument is provided and the flag --list is not specified, the
 // currently selected input is printed with the label of the input as
 // configured on the TV, or with an input URI if no label is set. If --list is
-// specified, all the available input URIs with their labels (if any) are
-// listed. If an argument is provided and matches the label of one of the
-// inputs, the TV is set to that input. Otherwise the argument is assumed to be
-// a URI and sets the input to that URI.
-func (sc *SonyCmdInput) Run(cli *CLI) error {
+// specified, all the available input URIs with their labels (if any), titles
+// and whether something is connected to them are listed, as JSON with
+// --json. If an argument is provided and matches the label of one of the
+// inputs, the TV is set to that input. Otherwise the argument must be a content
+// URI, which may be of an external input, screen mirroring (extInput:widi), a
+// CEC device (extInput:cec) or a tuner (tv:dvbt), and the input is set to
+// that URI.
+func (sc *SonyCmdInput) Run(ctx context.Context, cli *CLI) error {
 	if sc.Label != "" && sc.List {
 		return fmt.Errorf("%w: cannot use --list with a label", ErrUsage)
 	}
+	if sc.JSON && !sc.List {
+		return fmt.Errorf("%w: --json can only be used with --list", ErrUsage)
+	}
 
-	c := NewRESTClient(cli.TV.Hostname, cli.TV.PSK)
-	labels, err := c.Inputs()
+	c := cli.TV.tv()
+	inputs, err := c.Inputs(ctx)
 	if err != nil {
 		return fmt.Errorf("getting labels: %w", err)
 	}
+	labels := bravia.InputLabelMap(inputs)
 
 	switch {
 	// List all inputs
+	case sc.Label == "" && sc.List && sc.JSON:
+		return printJSON(inputs)
 	case sc.Label == "" && sc.List:
 		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(tw, "URI\tLABEL")
+		fmt.Fprintln(tw, "URI\tLABEL\tTITLE\tCONNECTED")
 
-		// Get the URIs from the map and sort them
-		uris := make([]string, 0, len(labels))
-		for k := range labels {
-			if strings.HasPrefix(k, "extInput:") {
-				uris = append(uris, k)
+		sort.Slice(inputs, func(i, j int) bool { return inputs[i].URI < inputs[j].URI })
+		for _, in := range inputs {
+			connected := "no"
+			if in.Connection {
+				connected = "yes"
 			}
-		}
-		sort.Strings(uris)
-
-		for _, uri := range uris {
-			fmt.Fprintf(tw, "%s\t%s\n", uri, labels[uri])
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", in.URI, in.Label, in.Title, connected)
 		}
 		tw.Flush() //nolint:errcheck,gosec
 
 	// Show selected input
 	case sc.Label == "" && !sc.List:
-		uri, err := c.SelectedInput()
+		uri, err := c.SelectedInput(ctx)
 		if err != nil {
 			return fmt.Errorf("selected input: %w", err)
 		}
@@ -285,13 +2155,16 @@ func (sc *SonyCmdInput) Run(cli *CLI) error {
 		}
 		fmt.Println(label)
 
-	// Select input by label
+	// Select input by label or URI
 	case sc.Label != "":
 		uri := labels[sc.Label]
 		if uri == "" {
+			if !isContentURI(sc.Label) {
+				return fmt.Errorf("tv set does not have labelled input: %s", sc.Label)
+			}
 			uri = sc.Label
 		}
-		if err := c.SetInput(uri); err != nil {
+		if err := c.SetInput(ctx, uri); err != nil {
 			return fmt.Errorf("set input: %w", err)
 		}
 	}
@@ -316,21 +2189,24 @@ func (sc *SonyCmdInput) Run(cli *CLI) error {
 // was pressed if the screen is not active for that machine. Otherwise it turns
 // off the screen as an alternative to locking it when locking is not desired
 // but there is no need to leave the screen on.
-func (sc *SonyCmdToggle) Run(cli *CLI) error {
-	c := NewRESTClient(cli.TV.Hostname, cli.TV.PSK)
-	ourInput, err := getInputURI(c, sc.Input)
+func (sc *SonyCmdToggle) Run(ctx context.Context, cli *CLI) error {
+	c := cli.TV.tv()
+	ourInput, err := getInputURI(ctx, c, sc.Input)
 	if err != nil {
 		return fmt.Errorf("getting labels: %w", err)
 	}
+	if rc, ok := c.(*bravia.RESTClient); ok {
+		discoverMAC(ctx, rc, cli.TV.Hostname)
+	}
 
-	status, err := c.PowerStatus()
+	status, err := c.SettledPowerStatus(ctx)
 	if err != nil {
 		return fmt.Errorf("could not get power status: %w", err)
 	}
 	if status == "active" { //nolint:nestif // come on, it's not that "complex"!
 		// turn off the screen if we are the current input, otherwise
 		// switch to us.
-		input, err := c.SelectedInput()
+		input, err := c.SelectedInput(ctx)
 		if err != nil {
 			return fmt.Errorf("could not get selected input: %w", err)
 		}
@@ -340,29 +2216,50 @@ func (sc *SonyCmdToggle) Run(cli *CLI) error {
 			}
 			return nil
 		}
-		if err := c.SetInput(ourInput); err != nil {
+		if err := c.SetInput(ctx, ourInput); err != nil {
 			return fmt.Errorf("could not select input %s: %w", ourInput, err)
 		}
 		return nil
 	}
 
 	// Screen is off. turn it on and select our input
-	if err := c.SetPowerStatus(true); err != nil {
+	if err := c.SetPowerStatus(ctx, true); err != nil {
 		return fmt.Errorf("could not turn on screen: %w", err)
 	}
-	if err := c.SetInput(ourInput); err != nil {
+	if err := c.SetInput(ctx, ourInput); err != nil {
 		return fmt.Errorf("could not select input %s: %w", ourInput, err)
 	}
 	return nil
 }
 
-func getInputURI(c *RESTClient, label string) (string, error) {
+// contentSchemes are the URI schemes of content that the TV can be switched
+// to with [bravia.RESTClient.SetInput]: external inputs (including screen mirroring
+// as extInput:widi and CEC devices as extInput:cec), and the tuners.
+var contentSchemes = []string{"extInput", "tv", "radio"}
+
+// isContentURI returns true if s is a content URI, such as
+// "extInput:hdmi?port=1", "extInput:widi?port=1" or "tv:dvbt", rather than
+// the label of an input.
+func isContentURI(s string) bool {
+	scheme, _, ok := strings.Cut(s, ":")
+	if !ok {
+		return false
+	}
+	for _, cs := range contentSchemes {
+		if scheme == cs {
+			return true
+		}
+	}
+	return false
+}
+
+func getInputURI(ctx context.Context, c bravia.TV, label string) (string, error) {
 	// If the label is already a URI, just return that.
-	if strings.HasPrefix(label, "extInput:") {
+	if isContentURI(label) {
 		return label, nil
 	}
 
-	labels, err := c.Inputs()
+	labels, err := c.InputLabels(ctx)
 	if err != nil {
 		return "", fmt.Errorf("could not get available inputs: %w", err)
 	}
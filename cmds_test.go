@@ -1,8 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"foxygo.at/offscreen/pkg/bravia"
 	"github.com/alecthomas/kong"
 	"github.com/matryer/is"
 )
@@ -52,3 +62,178 @@ func TestBuildtimeVars(t *testing.T) {
 		})
 	}
 }
+
+func TestIsContentURI(t *testing.T) {
+	is := is.New(t)
+	for _, s := range []string{"extInput:hdmi?port=1", "extInput:widi?port=1", "extInput:cec?type=player&port=3", "tv:dvbt", "tv:dvbc?trip=1.2.3"} {
+		is.True(isContentURI(s)) // expected content URI
+	}
+	for _, s := range []string{"desktop", "", "HDMI 1", "http://example.com"} {
+		is.True(!isContentURI(s)) // expected input label
+	}
+}
+
+func TestParseBatch(t *testing.T) {
+	is := is.New(t)
+
+	want := [][]string{{"power", "on"}, {"input", "HDMI 2"}, {"key", "Home"}}
+	cmds, err := parseBatch([]byte(`["power on", ["input", "HDMI 2"], "key Home"]`))
+	is.NoErr(err) // failed to parse JSON commands
+	is.Equal(want, cmds)
+
+	cmds, err = parseBatch([]byte("# scene\npower on\n\n  volume set 20\n"))
+	is.NoErr(err) // failed to parse line commands
+	is.Equal([][]string{{"power", "on"}, {"volume", "set", "20"}}, cmds)
+
+	_, err = parseBatch([]byte(`["power on", 3]`))
+	is.True(errors.Is(err, ErrUsage)) // expected usage error for bad command
+}
+
+func TestBatchSharesClient(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	sim := newBraviaSim(t.Logf)
+	hostname, stop, err := sim.Start()
+	is.NoErr(err)
+	t.Cleanup(stop)
+
+	var methods []string
+	c := bravia.NewRESTClient(hostname, "")
+	c.Use(func(next http.RoundTripper) http.RoundTripper {
+		return bravia.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			var r struct{ Method string }
+			_ = json.Unmarshal(body, &r)
+			methods = append(methods, r.Method)
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			return next.RoundTrip(req)
+		})
+	})
+	var cli CLI
+	cli.TV.c = c
+
+	is.NoErr(runBatchCommand(ctx, &cli, []string{"power", "on"}))               // power on failed
+	is.NoErr(runBatchCommand(ctx, &cli, []string{"scene", "game"}))             // scene failed
+	is.True(errors.Is(runBatchCommand(ctx, &cli, []string{"bogus"}), ErrUsage)) // expected usage error
+	is.Equal([]string{"getSupportedApiInfo", "setPowerStatus", "setSceneSetting"}, methods)
+	is.Equal("active", sim.power)
+	is.Equal("game", sim.scene)
+}
+
+func TestBatchLineFlags(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	sim := newBraviaSim(t.Logf)
+	hostname, stop, err := sim.Start()
+	is.NoErr(err)
+	t.Cleanup(stop)
+
+	var cli CLI
+	cli.TV.Hostname = "bravia.invalid"
+	cli.TV.Verify = 2
+	cli.TV.c = bravia.NewRESTClient(cli.TV.Hostname, "")
+
+	var sub CLI
+	parser, err := kong.New(&sub, kongOptions()...)
+	is.NoErr(err)
+	kctx, err := parser.Parse([]string{"tv", "--hostname", hostname, "scene", "game"})
+	is.NoErr(err)
+	merged := mergeBraviaAPI(cli.TV.braviaAPI, &sub.TV.braviaAPI, kctx)
+	is.Equal(hostname, merged.Hostname) // hostname of line not used
+	is.Equal(2, merged.Verify)          // verify of batch not kept
+	is.True(merged.c == nil)            // client of batch kept for another TV
+
+	kctx, err = parser.Parse([]string{"tv", "scene", "game"})
+	is.NoErr(err)
+	merged = mergeBraviaAPI(cli.TV.braviaAPI, &sub.TV.braviaAPI, kctx)
+	is.True(merged.c == cli.TV.c) // client of batch not shared
+
+	is.NoErr(runBatchCommand(ctx, &cli, []string{"scene", "game", "--hostname", hostname})) // scene failed
+	is.Equal("game", sim.scene)
+}
+
+func TestPowerVerboseNeedsREST(t *testing.T) {
+	is := is.New(t)
+	var cli CLI
+	cli.TV.Hostname = "bravia.invalid"
+	cli.TV.Protocol = "simpleip"
+	cmd := SonyCmdPower{Verbose: true}
+	is.True(errors.Is(cmd.Run(context.Background(), &cli), ErrUsage)) // expected usage error for --verbose with simpleip
+}
+
+func TestInputJSONNeedsList(t *testing.T) {
+	is := is.New(t)
+	var cli CLI
+	cmd := SonyCmdInput{JSON: true}
+	is.True(errors.Is(cmd.Run(context.Background(), &cli), ErrUsage)) // expected usage error for --json without --list
+}
+
+func TestWait(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	sim := newBraviaSim(t.Logf, "other", "us")
+	hostname, stop, err := sim.Start()
+	is.NoErr(err)
+	t.Cleanup(stop)
+	var cli CLI
+	cli.TV.Hostname = hostname
+
+	wait := SonyCmdWait{Power: "standby", Timeout: time.Second, Interval: 10 * time.Millisecond}
+	is.NoErr(wait.Run(ctx, &cli)) // TV already in standby
+
+	wait = SonyCmdWait{Input: "us", Timeout: 50 * time.Millisecond, Interval: 10 * time.Millisecond}
+	err = wait.Run(ctx, &cli)
+	is.True(err != nil && strings.Contains(err.Error(), "did not reach input us")) // expected timeout
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		sim.mu.Lock()
+		sim.setPower(true)
+		sim.mu.Unlock()
+		sim.SelectInput("extInput:hdmi?port=2")
+	}()
+	wait = SonyCmdWait{Power: "active", Input: "us", Timeout: time.Second, Interval: 10 * time.Millisecond}
+	is.NoErr(wait.Run(ctx, &cli)) // TV did not reach state
+}
+
+func TestWatchPoll(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	sim := newBraviaSim(t.Logf, "other", "us")
+	hostname, stop, err := sim.Start()
+	is.NoErr(err)
+	t.Cleanup(stop)
+	c := bravia.NewRESTClient(hostname, "")
+
+	var sc SonyCmdWatch
+	var out strings.Builder
+	state := map[string]string{}
+	is.NoErr(sc.poll(ctx, c, state, &out))
+	is.True(strings.HasSuffix(out.String(), " power standby\n")) // initial state not printed
+
+	out.Reset()
+	is.NoErr(sc.poll(ctx, c, state, &out))
+	is.Equal("", out.String()) // nothing changed
+
+	is.NoErr(c.SetPowerStatus(ctx, true))
+	sim.SelectInput("extInput:hdmi?port=2")
+	is.NoErr(sc.poll(ctx, c, state, &out))
+	is.True(strings.Contains(out.String(), " power active\n"))                    // power change not printed
+	is.True(strings.Contains(out.String(), " input us (extInput:hdmi?port=2)\n")) // input change not printed
+}
+
+func TestAutoBackendKDE(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("auto picks the native backend")
+	}
+	is := is.New(t)
+	t.Setenv("XDG_CURRENT_DESKTOP", "KDE")
+	sf := screenFlags{Backend: "auto"}
+	is.Equal(sf.backend(), "x11") // X11 Plasma needs RANDR presence detection
+	sf.WaylandDisplay = "wayland-0"
+	is.Equal(sf.backend(), "dbus")
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"foxygo.at/offscreen/pkg/bravia"
+)
+
+// tvController turns the TV on and off in response to screen saver changes
+// for the `run` command. It implements [ScreenWatcher].
+type tvController struct {
+	mu sync.Mutex
+
+	// ctx is the context of requests to the TV. It is done when the
+	// controller is shutting down, cancelling requests in flight.
+	ctx context.Context //nolint:containedctx // SSChange is called by the screen without a context
+
+	client   bravia.TV
+	ourInput string
+	screen   ScreenBackend
+
+	// onInputLost is what to do to our session when the TV switches away
+	// from our input while we are in use: "none", "blank" or "lock".
+	onInputLost string
+
+	// lastInput is the input the TV was last seen showing, or the empty
+	// string if the TV was off or we were not in use.
+	lastInput string
+
+	// offMode is how the TV is turned off: "standby" turns the TV off
+	// and "pictureOff" just turns off its picture, which is much quicker
+	// to wake from.
+	offMode string
+
+	// savedPowerSaving is the power saving mode of the TV before we turned
+	// its picture off in pictureOff mode, to be restored when turning the
+	// picture back on.
+	savedPowerSaving string
+
+	// audio switches the host's audio away from the TV while it is off.
+	// It is nil if audio switching is not enabled.
+	audio *audioSwitcher
+
+	// inhibitor stops the host suspending while the TV shows our input.
+	// It is nil if suspend inhibiting is not enabled.
+	inhibitor *suspendInhibitor
+
+	// ssInhibitors are the applications inhibiting the screen saver,
+	// while which the TV is not turned off. It is nil if screen saver
+	// inhibitors are not respected.
+	ssInhibitors *screenSaverInhibitors
+
+	// session is whether our session is the active one of its seat. The
+	// TV is left alone while it is not. It is nil if the session is not
+	// followed.
+	session *sessionActivity
+
+	// picture is the schedule of picture settings to apply whenever we
+	// select our input.
+	picture pictureSchedule
+
+	// sound is the sound settings to apply whenever we select our input.
+	sound []bravia.SoundSetting
+
+	// scene is the scene setting to select whenever we select our input,
+	// or the empty string to leave it alone.
+	scene string
+}
+
+// SSChange handles a screen saver change event, turning the TV on or
+// off and possibly selecting our input on the TV. SSChange implements the
+// [ScreenWatcher] interface.
+func (tc *tvController) SSChange(ssOn bool) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if !tc.session.active() {
+		diag.Event("session is not active; ignoring screen saver on=%v", ssOn)
+		return nil
+	}
+	defer tc.updateInhibitor()
+	tc.lastInput = ""
+	c, ourInput := tc.client, tc.ourInput
+
+	status, err := c.SettledPowerStatus(tc.ctx)
+	if err != nil {
+		return fmt.Errorf("could not get power status: %w", err)
+	}
+
+	// If the TV is off and the screen saver turns on, nothing to do
+	// because the TV is already off.
+	if status == "standby" && ssOn {
+		diag.Event("TV is already off")
+		return nil
+	}
+
+	// If the TV is off and the screen saver turns off, turn on the TV
+	// and select our input.
+	if status == "standby" && !ssOn {
+		return tc.turnOn()
+	}
+
+	// Get the selected input. We cannot do this while the TV is off
+	// otherwise the Bravia REST API returns an error.
+	input, err := c.SelectedInput(tc.ctx)
+	if err != nil {
+		return fmt.Errorf("could not get selected input: %w", err)
+	}
+	if !ssOn {
+		tc.lastInput = input
+	}
+
+	// If the TV is on and the screen saver turns on, we turn off
+	// the TV but only if our input is the current input. Otherwise
+	// we leave it alone - the TV is showing the screen of another
+	// machine so we should not blank the screen.
+	if status == "active" && ssOn && input == ourInput {
+		if apps := tc.ssInhibitors.inhibitedBy(); len(apps) > 0 {
+			diag.Event("not turning TV off: screen saver inhibited by %s", strings.Join(apps, ", "))
+			return nil
+		}
+		if err := tc.turnOff(); err != nil {
+			return err
+		}
+		tc.audio.tvOff()
+		return nil
+	}
+
+	// In pictureOff mode, the TV stays on with its picture off when we
+	// turn it "off", so turn the picture back on and select our input.
+	if status == "active" && !ssOn && tc.offMode == "pictureOff" {
+		mode, err := tc.rest().PowerSavingMode(tc.ctx)
+		if err != nil {
+			return fmt.Errorf("could not get power saving mode: %w", err)
+		}
+		if mode == "pictureOff" {
+			return tc.pictureOn(input)
+		}
+	}
+
+	if input != ourInput {
+		diag.Event("TV is showing %s, not our input; leaving it alone", input)
+	}
+	return nil
+}
+
+// sessionActive brings the TV in line with the screen saver when our session
+// becomes active again, as screen saver changes were ignored while it was
+// not.
+func (tc *tvController) sessionActive() {
+	if err := tc.SSChange(tc.screen.IsScreenSaverOn()); err != nil {
+		warnf("%v", err)
+	}
+}
+
+// retarget makes input, a TV input URI, our input, such as after the cable
+// was moved to another port of the TV. If the TV was last seen showing our
+// old input, it is switched to the new one.
+func (tc *tvController) retarget(input string) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	defer tc.updateInhibitor()
+	old := tc.ourInput
+	tc.ourInput = input
+	diag.Event("managing input %s (was %s)", input, old)
+	if input == old || tc.lastInput != old {
+		return nil
+	}
+	diag.Event("selecting input %s (was %s)", input, old)
+	if err := tc.client.SetInput(tc.ctx, input); err != nil {
+		return fmt.Errorf("could not set input: %w", err)
+	}
+	tc.lastInput = input
+	tc.applySettings()
+	return nil
+}
+
+// rest returns the client of the TV if it is controlled with the REST API,
+// or nil if it is controlled with a protocol that supports only power,
+// input and volume, in which case pictureOff mode, the scene, picture and
+// sound settings and notifications are not available.
+func (tc *tvController) rest() *bravia.RESTClient {
+	c, _ := tc.client.(*bravia.RESTClient)
+	return c
+}
+
+// turnOn turns on the TV and selects our input as per
+// [tvController.selectOurInput]. The input cannot be got until the TV is on.
+func (tc *tvController) turnOn() error {
+	diag.Event("turning TV on")
+	if err := tc.client.SetPowerStatus(tc.ctx, true); err != nil {
+		return fmt.Errorf("could not set power status: %w", err)
+	}
+	tc.audio.tvOn()
+	input, err := tc.client.SelectedInput(tc.ctx)
+	if err != nil {
+		return fmt.Errorf("could not get selected input: %w", err)
+	}
+	tc.lastInput = input
+	return tc.selectOurInput(input)
+}
+
+// turnOff turns off the TV, or just its picture in pictureOff mode.
+func (tc *tvController) turnOff() error {
+	if tc.offMode != "pictureOff" {
+		diag.Event("turning TV off")
+		if err := tc.client.SetPowerStatus(tc.ctx, false); err != nil {
+			return fmt.Errorf("could not set power status: %w", err)
+		}
+		return nil
+	}
+	mode, err := tc.rest().PowerSavingMode(tc.ctx)
+	if err != nil {
+		return fmt.Errorf("could not get power saving mode: %w", err)
+	}
+	if mode != "pictureOff" {
+		tc.savedPowerSaving = mode
+	}
+	diag.Event("turning picture off")
+	if err := tc.rest().SetPowerSavingMode(tc.ctx, "pictureOff"); err != nil {
+		return fmt.Errorf("could not set power saving mode: %w", err)
+	}
+	return nil
+}
+
+// pictureOn turns the picture back on after [tvController.turnOff] turned
+// it off in pictureOff mode, restoring the previous power saving mode, and
+// selects our input if the TV is showing another input.
+func (tc *tvController) pictureOn(input string) error {
+	mode := tc.savedPowerSaving
+	if mode == "" {
+		mode = "off"
+	}
+	diag.Event("turning picture on (power saving mode %s)", mode)
+	if err := tc.rest().SetPowerSavingMode(tc.ctx, mode); err != nil {
+		return fmt.Errorf("could not set power saving mode: %w", err)
+	}
+	tc.audio.tvOn()
+	return tc.selectOurInput(input)
+}
+
+// selectOurInput selects our input if the TV, just turned on, is showing
+// another input, and applies the picture, sound and scene settings as we
+// have the TV.
+func (tc *tvController) selectOurInput(input string) error {
+	if input != tc.ourInput {
+		diag.Event("selecting input %s (was %s)", tc.ourInput, input)
+		if err := tc.client.SetInput(tc.ctx, tc.ourInput); err != nil {
+			return fmt.Errorf("could not set input: %w", err)
+		}
+	}
+	tc.lastInput = tc.ourInput
+	tc.applySettings()
+	return nil
+}
+
+// Close releases anything held by the controller on behalf of the host.
+func (tc *tvController) Close() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.inhibitor.set(false)
+}
+
+// updateInhibitor holds the suspend inhibitor while the TV was last seen
+// showing our input and releases it otherwise.
+func (tc *tvController) updateInhibitor() {
+	tc.inhibitor.set(tc.lastInput == tc.ourInput)
+}
+
+// applySettings applies the picture settings scheduled for the current time
+// of day, the sound settings and the scene setting. Failing to do so is only
+// a warning as the TV is still usable.
+func (tc *tvController) applySettings() {
+	if tc.rest() == nil {
+		return
+	}
+	if tc.scene != "" {
+		diag.Event("selecting scene %s", tc.scene)
+		if err := tc.rest().SetSceneSetting(tc.ctx, tc.scene); err != nil {
+			warnf("could not select scene: %v", err)
+		}
+	}
+	if settings := tc.picture.at(time.Now()); len(settings) > 0 {
+		diag.Event("applying picture settings %v", settings)
+		if err := tc.rest().SetPictureQualitySettings(tc.ctx, settings); err != nil {
+			warnf("could not apply picture settings: %v", err)
+		}
+	}
+	if len(tc.sound) > 0 {
+		diag.Event("applying sound settings %v", tc.sound)
+		if err := tc.rest().SetSoundSettings(tc.ctx, tc.sound); err != nil {
+			warnf("could not apply sound settings: %v", err)
+		}
+	}
+}
+
+// Reconcile polls the TV to catch changes made by other hosts or with the
+// remote control since the last screen saver change. If the TV has switched
+// away from our input while our session is in use (the screen saver is off),
+// our session is blanked or locked as per onInputLost, so an unlocked desktop
+// is not left running invisibly behind another host's picture.
+func (tc *tvController) Reconcile() error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	defer tc.updateInhibitor()
+
+	if !tc.screen.IsPresent() || tc.screen.IsScreenSaverOn() || !tc.session.active() {
+		tc.lastInput = ""
+		return nil
+	}
+	status, err := tc.client.PowerStatus(tc.ctx)
+	if err != nil {
+		return fmt.Errorf("could not get power status: %w", err)
+	}
+	if !bravia.IsSettledPowerStatus(status) {
+		// Try again next time rather than holding up screen saver
+		// changes while the TV settles.
+		return nil
+	}
+	if status != "active" {
+		tc.lastInput = ""
+		return nil
+	}
+	input, err := tc.client.SelectedInput(tc.ctx)
+	if err != nil {
+		return fmt.Errorf("could not get selected input: %w", err)
+	}
+	prev := tc.lastInput
+	tc.lastInput = input
+	if prev == tc.ourInput && input != tc.ourInput {
+		diag.Event("TV switched from our input to %s", input)
+		return tc.inputLost()
+	}
+	return nil
+}
+
+// inputLost blanks or locks our session as per onInputLost.
+func (tc *tvController) inputLost() error {
+	switch tc.onInputLost {
+	case "blank":
+		diag.Event("blanking screen as our input was taken away")
+		if err := tc.screen.Blank(); err != nil {
+			return fmt.Errorf("could not blank screen: %w", err)
+		}
+	case "lock":
+		diag.Event("locking session as our input was taken away")
+		if out, err := exec.Command("loginctl", "lock-session").CombinedOutput(); err != nil {
+			return fmt.Errorf("could not lock session: %w: %s", err, out)
+		}
+	}
+	return nil
+}
+
+// notifyRetryInterval is how long to wait before resubscribing to
+// notifications after the connection to the TV is lost.
+const notifyRetryInterval = 30 * time.Second
+
+// notifiedMethods are the notifications from the TV handled by
+// [tvController.notified], and so subscribed to by notifyLoop.
+var notifiedMethods = []string{"notifyPowerStatus", "notifyPlayingContentInfo", "notifyExternalInputStatus"}
+
+// notifyLoop subscribes to change notifications from the TV, reconciling
+// whenever the TV's power or input changes so changes made by other hosts or
+// with the remote control are noticed immediately, until tc.ctx is done. If
+// the connection to the TV is lost, it resubscribes after
+// notifyRetryInterval. If the TV does not support notifications, it gives up.
+func (tc *tvController) notifyLoop() {
+	for {
+		err := tc.rest().Subscribe(tc.ctx, tc.notified, notifiedMethods...)
+		if err == nil {
+			return
+		}
+		var herr bravia.HTTPStatusError
+		if errors.As(err, &herr) || bravia.IsUnsupported(err) {
+			diag.Event("TV does not support notifications: %v", err)
+			return
+		}
+		diag.Event("%v; resubscribing in %v", err, notifyRetryInterval)
+		if sleep(tc.ctx, notifyRetryInterval) != nil {
+			return
+		}
+	}
+}
+
+// notified handles a notification from the TV.
+func (tc *tvController) notified(n bravia.Notification) {
+	switch n.Method {
+	case "notifyPowerStatus", "notifyPlayingContentInfo":
+		diag.Event("TV notified %s %s", n.Method, n.Params)
+		if err := tc.Reconcile(); err != nil {
+			warnf("reconcile: %v", err)
+		}
+	case "notifyExternalInputStatus":
+		diag.Event("TV notified %s %s", n.Method, n.Params)
+		tc.rest().InvalidateInputs()
+	}
+}
+
+// reconcileLoop calls [tvController.Reconcile] every interval until tc.ctx is
+// done. Errors are reported as warnings as the TV may just be unreachable
+// for a while.
+func (tc *tvController) reconcileLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tc.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := tc.Reconcile(); err != nil {
+				warnf("reconcile: %v", err)
+			}
+		}
+	}
+}
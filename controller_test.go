@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"foxygo.at/offscreen/pkg/bravia"
+	"github.com/matryer/is"
+)
+
+// newTestController returns a tvController wired to a simulated TV and a
+// fake screen that is being watched. The TV starts in standby on HDMI 1 and
+// our input is HDMI 2.
+func newTestController(t *testing.T) (*tvController, *braviaSim, *FakeScreen) {
+	t.Helper()
+	sim := newBraviaSim(t.Logf, "other", "us")
+	hostname, stop, err := sim.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stop)
+
+	screen := NewFakeScreen(true /* ssOn */, true /* present */)
+	tc := &tvController{
+		ctx:      context.Background(),
+		client:   bravia.NewRESTClient(hostname, ""),
+		ourInput: "extInput:hdmi?port=2",
+		screen:   screen,
+		offMode:  "standby",
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := screen.Watch(tc); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Cleanup(func() {
+		screen.Close()
+		<-done
+	})
+	return tc, sim, screen
+}
+
+func TestControllerStandby(t *testing.T) {
+	is := is.New(t)
+	_, sim, screen := newTestController(t)
+
+	is.NoErr(screen.Send(fakeSSOff))
+	is.Equal("active", sim.power)               // TV not turned on
+	is.Equal("extInput:hdmi?port=2", sim.input) // our input not selected
+
+	is.NoErr(screen.Send(fakeSSOn))
+	is.Equal("standby", sim.power) // TV not turned off
+}
+
+func TestControllerLeavesOtherInputAlone(t *testing.T) {
+	is := is.New(t)
+	_, sim, screen := newTestController(t)
+
+	is.NoErr(screen.Send(fakeSSOff))
+	sim.SelectInput("extInput:hdmi?port=1")
+	is.NoErr(screen.Send(fakeSSOn))
+	is.Equal("active", sim.power) // TV showing another host should not be turned off
+}
+
+func TestControllerPictureOff(t *testing.T) {
+	is := is.New(t)
+	tc, sim, screen := newTestController(t)
+	tc.offMode = "pictureOff"
+	sim.saving = "low"
+
+	is.NoErr(screen.Send(fakeSSOff))
+	is.NoErr(screen.Send(fakeSSOn))
+	is.Equal("active", sim.power)      // TV should stay on in pictureOff mode
+	is.Equal("pictureOff", sim.saving) // picture not turned off
+
+	sim.SelectInput("extInput:hdmi?port=1")
+	is.NoErr(screen.Send(fakeSSOff))
+	is.Equal("low", sim.saving)                 // power saving mode not restored
+	is.Equal("extInput:hdmi?port=2", sim.input) // our input not selected
+}
+
+func TestControllerSelectsScene(t *testing.T) {
+	is := is.New(t)
+	tc, sim, screen := newTestController(t)
+	tc.scene = "game"
+
+	is.NoErr(screen.Send(fakeSSOff))
+	is.Equal("extInput:hdmi?port=2", sim.input) // our input not selected
+	is.Equal("game", sim.scene)                 // scene not selected with our input
+}
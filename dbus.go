@@ -0,0 +1,194 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// dbusCallTimeout is how long to wait for the reply to a method call, as
+// libdbus does.
+const dbusCallTimeout = 25 * time.Second
+
+// propertiesChanged is the name of the signal of a change to the properties
+// of an object.
+const propertiesChanged = "org.freedesktop.DBus.Properties.PropertiesChanged"
+
+// errDBusClosed is returned by the watchers of a D-Bus connection that was
+// closed other than by Close.
+var errDBusClosed = errors.New("D-Bus connection closed")
+
+// isDBusError returns true if err is a D-Bus error reply with the given
+// name, e.g. "org.freedesktop.DBus.Error.ServiceUnknown".
+func isDBusError(err error, name string) bool {
+	var derr dbus.Error
+	return errors.As(err, &derr) && derr.Name == name
+}
+
+// dbusConn is a private connection to a D-Bus message bus, with the method
+// calls offscreen makes and the signals or monitored messages it receives.
+type dbusConn struct {
+	conn *dbus.Conn
+	name string // our unique name on the bus
+
+	// signals receives the signals delivered to us. It is closed when the
+	// connection is closed. Signals are queued without bound by godbus, so
+	// a slow reader does not hold up method replies.
+	signals chan *dbus.Signal
+
+	// messages receives all the messages seen once the connection is a
+	// monitor (see [dbusConn.becomeMonitor]). It is closed when the
+	// connection is closed. Messages are dropped while it is full.
+	messages chan *dbus.Message
+}
+
+// dialSessionBus connects to the D-Bus session bus of the user.
+func dialSessionBus() (*dbusConn, error) {
+	addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	if addr == "" {
+		dir := os.Getenv("XDG_RUNTIME_DIR")
+		if dir == "" {
+			return nil, errors.New("could not find D-Bus session bus: neither $DBUS_SESSION_BUS_ADDRESS nor $XDG_RUNTIME_DIR is set")
+		}
+		addr = "unix:path=" + dir + "/bus"
+	}
+	return dialDBus(addr)
+}
+
+// dialSystemBus connects to the D-Bus system bus.
+func dialSystemBus() (*dbusConn, error) {
+	addr := os.Getenv("DBUS_SYSTEM_BUS_ADDRESS")
+	if addr == "" {
+		addr = "unix:path=/var/run/dbus/system_bus_socket"
+	}
+	return dialDBus(addr)
+}
+
+// dialDBus connects to the message bus at the given D-Bus server address,
+// authenticates as the current user and registers with the bus.
+func dialDBus(address string) (*dbusConn, error) {
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to D-Bus %s: %w", address, err)
+	}
+	c := &dbusConn{
+		conn:     conn,
+		signals:  make(chan *dbus.Signal, 64),
+		messages: make(chan *dbus.Message, 64),
+	}
+	if names := conn.Names(); len(names) > 0 {
+		c.name = names[0]
+	}
+	conn.Signal(c.signals)
+	return c, nil
+}
+
+// Close closes the connection to the bus, closing the signals and messages
+// channels.
+func (c *dbusConn) Close() {
+	c.conn.Close() //nolint:errcheck // nothing to do about it
+}
+
+// addMatch asks the bus to send us the messages matching the given match
+// rule, e.g. "type='signal',interface='org.freedesktop.ScreenSaver'".
+func (c *dbusConn) addMatch(rule string) error {
+	_, err := c.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", rule)
+	if err != nil {
+		return fmt.Errorf("could not add D-Bus match %s: %w", rule, err)
+	}
+	return nil
+}
+
+// becomeMonitor turns the connection into a monitor that sees the messages
+// between other connections matching the given match rules, which are sent
+// to [dbusConn.messages]. No more methods can be called once the connection
+// is a monitor.
+func (c *dbusConn) becomeMonitor(rules ...string) error {
+	// The messages of a monitor are only seen by eavesdropping, which takes
+	// the reply to BecomeMonitor too, so the call is made by hand and its
+	// reply picked out of the eavesdropped messages.
+	c.conn.Eavesdrop(c.messages)
+	args := []any{rules, uint32(0)}
+	msg := &dbus.Message{
+		Type: dbus.TypeMethodCall,
+		Headers: map[dbus.HeaderField]dbus.Variant{
+			dbus.FieldDestination: dbus.MakeVariant("org.freedesktop.DBus"),
+			dbus.FieldPath:        dbus.MakeVariant(dbus.ObjectPath("/org/freedesktop/DBus")),
+			dbus.FieldInterface:   dbus.MakeVariant("org.freedesktop.DBus.Monitoring"),
+			dbus.FieldMember:      dbus.MakeVariant("BecomeMonitor"),
+			dbus.FieldSignature:   dbus.MakeVariant(dbus.SignatureOf(args...)),
+		},
+		Body: args,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbusCallTimeout)
+	defer cancel()
+	call := c.conn.SendWithContext(ctx, msg, nil)
+	if call.Err != nil {
+		return fmt.Errorf("could not monitor D-Bus: %w", call.Err)
+	}
+	for {
+		select {
+		case reply, ok := <-c.messages:
+			if !ok {
+				return fmt.Errorf("could not monitor D-Bus: %w", errDBusClosed)
+			}
+			if serial, _ := reply.Headers[dbus.FieldReplySerial].Value().(uint32); serial != msg.Serial() {
+				continue
+			}
+			if reply.Type == dbus.TypeError {
+				return fmt.Errorf("could not monitor D-Bus: %w", dbus.Error{Name: dbusHeader(reply, dbus.FieldErrorName), Body: reply.Body})
+			}
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("could not monitor D-Bus: no reply within %v", dbusCallTimeout)
+		}
+	}
+}
+
+// call calls a method and returns the body of the reply. A D-Bus error reply
+// is returned as a [dbus.Error].
+func (c *dbusConn) call(dest, path, iface, member string, args ...any) ([]any, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbusCallTimeout)
+	defer cancel()
+	call := c.conn.Object(dest, dbus.ObjectPath(path)).CallWithContext(ctx, iface+"."+member, 0, args...)
+	if errors.Is(call.Err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%s.%s: no reply within %v", iface, member, dbusCallTimeout)
+	}
+	return call.Body, call.Err
+}
+
+// getProperty returns the value of a property of an object.
+func (c *dbusConn) getProperty(dest, path, iface, property string) (any, error) {
+	body, err := c.call(dest, path, "org.freedesktop.DBus.Properties", "Get", iface, property)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := dbusArg[dbus.Variant](body, 0)
+	if !ok {
+		return nil, fmt.Errorf("no value for property %s.%s", iface, property)
+	}
+	return v.Value(), nil
+}
+
+// dbusArg returns the argument at index i of a message body as a T, or false
+// if there is no such argument or it is not a T.
+func dbusArg[T any](body []any, i int) (T, bool) {
+	var zero T
+	if i >= len(body) {
+		return zero, false
+	}
+	v, ok := body[i].(T)
+	return v, ok
+}
+
+// dbusHeader returns the string value of a header field of a message, or ""
+// if the message does not have it.
+func dbusHeader(msg *dbus.Message, field dbus.HeaderField) string {
+	v, _ := msg.Headers[field].Value().(string)
+	return v
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/matryer/is"
+)
+
+// fakeBus is a D-Bus message bus with a single client, which answers method
+// calls with a handler and can send signals to the client.
+type fakeBus struct {
+	t       *testing.T
+	address string
+	handler func(call *fakeCall) ([]any, error)
+
+	mu    sync.Mutex
+	conn  net.Conn
+	calls []string // interface.member of the calls received
+}
+
+// fakeCall is a method call received by a [fakeBus].
+type fakeCall struct {
+	path, iface, member string
+	body                []any
+}
+
+// newFakeBus starts a fake bus that answers calls with handler, which may
+// be nil to answer all calls (other than those to the bus itself) with no
+// values. Hello, AddMatch and BecomeMonitor are answered by the bus.
+func newFakeBus(t *testing.T, handler func(call *fakeCall) ([]any, error)) *fakeBus {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "bus")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	fb := &fakeBus{t: t, address: "unix:path=" + socket, handler: handler}
+	connected := make(chan struct{})
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		fb.mu.Lock()
+		fb.conn = c
+		fb.mu.Unlock()
+		close(connected)
+		fb.serve(c)
+	}()
+	t.Cleanup(func() {
+		select {
+		case <-connected:
+			fb.conn.Close()
+		default:
+		}
+	})
+	return fb
+}
+
+// auth answers the authentication of the client, accepting the EXTERNAL
+// mechanism without passing unix file descriptors.
+func (fb *fakeBus) auth(r *bufio.Reader, c net.Conn) error {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimLeft(strings.TrimSpace(line), "\x00")
+		var reply string
+		switch {
+		case line == "AUTH":
+			reply = "REJECTED EXTERNAL"
+		case strings.HasPrefix(line, "AUTH EXTERNAL"):
+			reply = "OK 0123456789abcdef0123456789abcdef"
+		case line == "BEGIN":
+			return nil
+		default:
+			reply = "ERROR"
+		}
+		if _, err := c.Write([]byte(reply + "\r\n")); err != nil {
+			return err
+		}
+	}
+}
+
+func (fb *fakeBus) serve(c net.Conn) {
+	r := bufio.NewReader(c)
+	if err := fb.auth(r, c); err != nil {
+		return
+	}
+	for {
+		msg, err := dbus.DecodeMessage(r)
+		if err != nil {
+			return
+		}
+		call := &fakeCall{
+			path:   string(msg.Headers[dbus.FieldPath].Value().(dbus.ObjectPath)),
+			iface:  dbusHeader(msg, dbus.FieldInterface),
+			member: dbusHeader(msg, dbus.FieldMember),
+			body:   msg.Body,
+		}
+		fb.mu.Lock()
+		fb.calls = append(fb.calls, call.iface+"."+call.member)
+		fb.mu.Unlock()
+		var body []any
+		switch {
+		case call.iface == "org.freedesktop.DBus" && call.member == "Hello":
+			body = []any{":1.42"}
+		case strings.HasPrefix(call.iface, "org.freedesktop.DBus") && call.iface != "org.freedesktop.DBus.Properties":
+		case fb.handler != nil:
+			body, err = fb.handler(call)
+		}
+		headers := map[dbus.HeaderField]dbus.Variant{
+			dbus.FieldReplySerial: dbus.MakeVariant(msg.Serial()),
+			dbus.FieldDestination: dbus.MakeVariant(":1.42"),
+		}
+		reply := &dbus.Message{Type: dbus.TypeMethodReply, Headers: headers, Body: body}
+		var derr dbus.Error
+		if err != nil && errors.As(err, &derr) {
+			headers[dbus.FieldErrorName] = dbus.MakeVariant(derr.Name)
+			reply = &dbus.Message{Type: dbus.TypeError, Headers: headers, Body: derr.Body}
+		} else if err != nil {
+			fb.t.Error(err)
+		}
+		fb.send(reply)
+	}
+}
+
+// send sends a message to the client.
+func (fb *fakeBus) send(msg *dbus.Message) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	if len(msg.Body) > 0 {
+		msg.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(msg.Body...))
+	}
+	if err := msg.EncodeTo(fb.conn, binary.LittleEndian); err != nil {
+		fb.t.Error(err)
+	}
+}
+
+// signal sends a signal to the client.
+func (fb *fakeBus) signal(path, iface, member string, args ...any) {
+	fb.send(&dbus.Message{
+		Type: dbus.TypeSignal,
+		Headers: map[dbus.HeaderField]dbus.Variant{
+			dbus.FieldPath:      dbus.MakeVariant(dbus.ObjectPath(path)),
+			dbus.FieldInterface: dbus.MakeVariant(iface),
+			dbus.FieldMember:    dbus.MakeVariant(member),
+		},
+		Body: args,
+	})
+}
+
+// called returns the interface.member of the calls received so far.
+func (fb *fakeBus) called() []string {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	return append([]string(nil), fb.calls...)
+}
+
+func TestDBusCall(t *testing.T) {
+	is := is.New(t)
+	fb := newFakeBus(t, func(call *fakeCall) ([]any, error) {
+		if call.member == "Fail" {
+			return nil, dbus.Error{Name: "org.example.Error.Failed", Body: []any{"nope"}}
+		}
+		return []any{strings.ToUpper(call.body[0].(string))}, nil
+	})
+	c, err := dialDBus(fb.address)
+	is.NoErr(err)
+	defer c.Close()
+	is.Equal(c.name, ":1.42")
+
+	body, err := c.call("org.example", "/", "org.example", "Upper", "hello")
+	is.NoErr(err)
+	is.Equal(body, []any{"HELLO"})
+
+	_, err = c.call("org.example", "/", "org.example", "Fail")
+	is.True(isDBusError(err, "org.example.Error.Failed"))
+
+	fb.signal("/", "org.example", "Changed", true)
+	sig := <-c.signals
+	is.Equal(sig.Name, "org.example.Changed")
+	is.Equal(sig.Body, []any{true})
+}
+
+func TestDBusSignalsDoNotBlockCalls(t *testing.T) {
+	is := is.New(t)
+	fb := newFakeBus(t, func(call *fakeCall) ([]any, error) {
+		return []any{true}, nil
+	})
+	c, err := dialDBus(fb.address)
+	is.NoErr(err)
+	defer c.Close()
+
+	// Nobody reads the signals, which must not hold up the reply.
+	for i := 0; i < 2*cap(c.signals); i++ {
+		fb.signal("/", "org.example", "Changed", true)
+	}
+	body, err := c.call("org.example", "/", "org.example", "Get")
+	is.NoErr(err)
+	is.Equal(body, []any{true})
+}
@@ -0,0 +1,353 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// DBusScreen is a [ScreenBackend] driven by D-Bus signals from the desktop
+// environment, for desktops whose screen lockers do not drive the X screen
+// saver. D-Bus does not tell us about monitors, so the monitor is taken to be
+// always present.
+type DBusScreen struct {
+	conn *dbusConn
+
+	// signal interprets a signal, returning the screen saver state it
+	// signals and true, or false if it is not a screen saver signal.
+	signal func(sig *dbus.Signal) (ssOn bool, ok bool)
+
+	// blank turns the screen saver on and unblank turns it off. Either is
+	// nil if it cannot be done.
+	blank   func() error
+	unblank func() error
+
+	ssOn   atomic.Bool
+	closed atomic.Bool
+}
+
+// NewScreenSaverDBusScreen returns a new DBusScreen that follows the
+// ActiveChanged signal of the [org.freedesktop.ScreenSaver] interface on the
+// session bus, as implemented by the screen savers of XFCE, Cinnamon and
+// others, and of the org.kde.screensaver interface of KDE Plasma, whose
+// screen locker bypasses the X screen saver.
+//
+// [org.freedesktop.ScreenSaver]: https://specifications.freedesktop.org/idle-inhibit-spec/latest/
+func NewScreenSaverDBusScreen() (*DBusScreen, error) {
+	const (
+		service  = "org.freedesktop.ScreenSaver"
+		iface    = "org.freedesktop.ScreenSaver"
+		kdeIface = "org.kde.screensaver"
+	)
+	c, err := dialSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	// KDE Plasma implements the interface at /ScreenSaver as well as the
+	// standard path. Find out which to call.
+	path := "/org/freedesktop/ScreenSaver"
+	body, err := c.call(service, path, iface, "GetActive")
+	if isDBusError(err, "org.freedesktop.DBus.Error.UnknownObject") || isDBusError(err, "org.freedesktop.DBus.Error.UnknownMethod") {
+		path = "/ScreenSaver"
+		body, err = c.call(service, path, iface, "GetActive")
+	}
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("could not get screen saver state from %s: %w", service, err)
+	}
+	for _, i := range []string{iface, kdeIface} {
+		if err := c.addMatch("type='signal',interface='" + i + "',member='ActiveChanged'"); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	s := &DBusScreen{conn: c}
+	s.signal = func(sig *dbus.Signal) (bool, bool) {
+		if sig.Name != iface+".ActiveChanged" && sig.Name != kdeIface+".ActiveChanged" {
+			return false, false
+		}
+		return dbusArg[bool](sig.Body, 0)
+	}
+	s.blank = func() error {
+		_, err := c.call(service, path, iface, "SetActive", true)
+		return err
+	}
+	s.unblank = func() error {
+		_, err := c.call(service, path, iface, "SetActive", false)
+		return err
+	}
+	active, _ := dbusArg[bool](body, 0)
+	s.ssOn.Store(active)
+	return s, nil
+}
+
+// NewMutterDBusScreen returns a new DBusScreen that follows the
+// org.gnome.Mutter.IdleMonitor of the GNOME session, for GNOME where the X
+// screen saver reports nothing useful. The screen saver is taken to be on once
+// the session has been idle for idleTimeout, and off on the next user
+// activity. Blanking activates the GNOME screen saver.
+func NewMutterDBusScreen(idleTimeout time.Duration) (*DBusScreen, error) {
+	const (
+		service = "org.gnome.Mutter.IdleMonitor"
+		path    = "/org/gnome/Mutter/IdleMonitor/Core"
+		iface   = "org.gnome.Mutter.IdleMonitor"
+	)
+	c, err := dialSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	fail := func(format string, err error) (*DBusScreen, error) {
+		c.Close()
+		return nil, fmt.Errorf(format, err)
+	}
+	if err := c.addMatch("type='signal',interface='" + iface + "',member='WatchFired'"); err != nil {
+		return fail("%w", err)
+	}
+
+	// The idle watch fires each time the session has been idle for the
+	// timeout. A user active watch fires once, on the next user activity,
+	// so is added each time the session becomes idle.
+	timeout := uint64(idleTimeout.Milliseconds())
+	body, err := c.call(service, path, iface, "AddIdleWatch", timeout)
+	if err != nil {
+		return fail("could not add Mutter idle watch: %w", err)
+	}
+	idleWatch, _ := dbusArg[uint32](body, 0)
+	var activeWatch uint32
+	addActiveWatch := func() error {
+		body, err := c.call(service, path, iface, "AddUserActiveWatch")
+		if err != nil {
+			return fmt.Errorf("could not add Mutter user active watch: %w", err)
+		}
+		activeWatch, _ = dbusArg[uint32](body, 0)
+		return nil
+	}
+
+	s := &DBusScreen{conn: c}
+	s.signal = func(sig *dbus.Signal) (bool, bool) {
+		id, ok := dbusArg[uint32](sig.Body, 0)
+		if sig.Name != iface+".WatchFired" || !ok {
+			return false, false
+		}
+		switch id {
+		case idleWatch:
+			if err := addActiveWatch(); err != nil {
+				warnf("%v", err)
+			}
+			return true, true
+		case activeWatch:
+			return false, true
+		}
+		return false, false
+	}
+	s.blank = func() error {
+		_, err := c.call("org.gnome.ScreenSaver", "/org/gnome/ScreenSaver", "org.gnome.ScreenSaver", "SetActive", true)
+		return err
+	}
+	s.unblank = func() error {
+		_, err := c.call("org.gnome.ScreenSaver", "/org/gnome/ScreenSaver", "org.gnome.ScreenSaver", "SetActive", false)
+		return err
+	}
+
+	body, err = c.call(service, path, iface, "GetIdletime")
+	if err != nil {
+		return fail("could not get Mutter idle time: %w", err)
+	}
+	if idle, _ := dbusArg[uint64](body, 0); idle >= timeout {
+		if err := addActiveWatch(); err != nil {
+			return fail("%w", err)
+		}
+		s.ssOn.Store(true)
+	}
+	return s, nil
+}
+
+// logindTriggers are the states followed by [NewLogindDBusScreen] that count
+// as the screen saver being on.
+type logindTriggers struct {
+	lock bool // the session being locked
+	idle bool // the session being idle
+	lid  bool // the laptop lid being closed
+}
+
+// NewLogindDBusScreen returns a new DBusScreen that follows systemd logind on
+// the system bus: the screen saver is on while any of the given triggers
+// holds, i.e. while the session of offscreen is locked (the Lock and Unlock
+// signals), idle (the IdleHint property) or the laptop lid is closed (the
+// LidClosed property of the manager). This needs no display server so also
+// works on consoles and Wayland sessions. The session is $XDG_SESSION_ID, or
+// the session logind picks for the user if not set. Blanking locks the
+// session.
+func NewLogindDBusScreen(triggers logindTriggers) (*DBusScreen, error) {
+	const (
+		service      = "org.freedesktop.login1"
+		managerPath  = "/org/freedesktop/login1"
+		managerIface = "org.freedesktop.login1.Manager"
+		sessionIface = "org.freedesktop.login1.Session"
+	)
+	c, err := dialSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	fail := func(format string, err error) (*DBusScreen, error) {
+		c.Close()
+		return nil, fmt.Errorf(format, err)
+	}
+	path, err := logindSession(c)
+	if err != nil {
+		return fail("%w", err)
+	}
+	rules := []string{
+		"type='signal',sender='" + service + "',path='" + path + "',interface='" + sessionIface + "'",
+		"type='signal',sender='" + service + "',path='" + path + "',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged'",
+	}
+	if triggers.lid {
+		rules = append(rules, "type='signal',sender='"+service+"',path='"+managerPath+"',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged'")
+	}
+	for _, rule := range rules {
+		if err := c.addMatch(rule); err != nil {
+			return fail("%w", err)
+		}
+	}
+
+	var locked, isIdle, lidClosed bool
+	type property struct {
+		path, iface, name string
+		v                 *bool
+	}
+	props := []property{
+		{path, sessionIface, "LockedHint", &locked},
+		{path, sessionIface, "IdleHint", &isIdle},
+	}
+	if triggers.lid {
+		props = append(props, property{managerPath, managerIface, "LidClosed", &lidClosed})
+	}
+	for _, prop := range props {
+		v, err := c.getProperty(service, prop.path, prop.iface, prop.name)
+		if err != nil {
+			return fail("could not get logind state: %w", err)
+		}
+		*prop.v, _ = v.(bool)
+	}
+	isOn := func() bool {
+		return (triggers.lock && locked) || (triggers.idle && isIdle) || (triggers.lid && lidClosed)
+	}
+
+	s := &DBusScreen{conn: c}
+	s.signal = func(sig *dbus.Signal) (bool, bool) {
+		switch {
+		case string(sig.Path) == path && sig.Name == sessionIface+".Lock":
+			locked = true
+		case string(sig.Path) == path && sig.Name == sessionIface+".Unlock":
+			locked = false
+		case string(sig.Path) == path && sig.Name == propertiesChanged:
+			changedIface, _ := dbusArg[string](sig.Body, 0)
+			changed, _ := dbusArg[map[string]dbus.Variant](sig.Body, 1)
+			if changedIface != sessionIface {
+				return false, false
+			}
+			if v, ok := changed["LockedHint"].Value().(bool); ok {
+				locked = v
+			}
+			if v, ok := changed["IdleHint"].Value().(bool); ok {
+				isIdle = v
+			}
+		case string(sig.Path) == managerPath && sig.Name == propertiesChanged:
+			changedIface, _ := dbusArg[string](sig.Body, 0)
+			changed, _ := dbusArg[map[string]dbus.Variant](sig.Body, 1)
+			v, ok := changed["LidClosed"].Value().(bool)
+			if changedIface != managerIface || !ok {
+				return false, false
+			}
+			lidClosed = v
+		default:
+			return false, false
+		}
+		return isOn(), true
+	}
+	s.blank = func() error {
+		_, err := c.call(service, path, sessionIface, "Lock")
+		return err
+	}
+	s.ssOn.Store(isOn())
+	return s, nil
+}
+
+// logindSession returns the object path of the logind session of offscreen
+// on the system bus c. The session is $XDG_SESSION_ID, or the session logind
+// picks for the user if not set.
+func logindSession(c *dbusConn) (string, error) {
+	id := os.Getenv("XDG_SESSION_ID")
+	if id == "" {
+		id = "auto"
+	}
+	body, err := c.call("org.freedesktop.login1", "/org/freedesktop/login1", "org.freedesktop.login1.Manager", "GetSession", id)
+	if err != nil {
+		return "", fmt.Errorf("could not get logind session: %w", err)
+	}
+	path, _ := dbusArg[dbus.ObjectPath](body, 0)
+	return string(path), nil
+}
+
+// Close closes the connection to the bus. This will cause [DBusScreen.Watch]
+// to return.
+func (s *DBusScreen) Close() {
+	s.closed.Store(true)
+	s.conn.Close()
+}
+
+// IsScreenSaverOn returns the current state of the screen saver.
+func (s *DBusScreen) IsScreenSaverOn() bool {
+	return s.ssOn.Load()
+}
+
+// IsPresent returns true as the presence of the monitor is not known.
+func (s *DBusScreen) IsPresent() bool {
+	return true
+}
+
+// Blank turns the screen saver on.
+func (s *DBusScreen) Blank() error {
+	if s.blank == nil {
+		return errors.New("cannot blank the screen with this screen backend")
+	}
+	return s.blank()
+}
+
+// Unblank turns the screen saver off. A locked session stays locked, so this
+// cannot be done with the logind backend.
+func (s *DBusScreen) Unblank() error {
+	if s.unblank == nil {
+		return errors.New("cannot unblank the screen with this screen backend")
+	}
+	return s.unblank()
+}
+
+// Watch loops while the connection to the bus is open (see
+// [DBusScreen.Close]) calling the given watcher when the state of the screen
+// saver changes.
+func (s *DBusScreen) Watch(watcher ScreenWatcher) error {
+	for sig := range s.conn.signals {
+		isOn, ok := s.signal(sig)
+		if !ok {
+			continue
+		}
+		wasOn := s.ssOn.Swap(isOn)
+		diag.Event("screen saver on=%v (was %v) from %s", isOn, wasOn, sig.Name)
+		if isOn != wasOn {
+			if err := watcher.SSChange(isOn); err != nil {
+				return err
+			}
+		}
+	}
+	if s.closed.Load() {
+		return nil
+	}
+	return errDBusClosed
+}
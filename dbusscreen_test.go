@@ -0,0 +1,206 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/matryer/is"
+)
+
+func TestScreenSaverDBusScreen(t *testing.T) {
+	is := is.New(t)
+	fb := newFakeBus(t, func(call *fakeCall) ([]any, error) {
+		if call.member == "GetActive" {
+			return []any{false}, nil
+		}
+		return nil, nil
+	})
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", fb.address)
+
+	s, err := NewScreenSaverDBusScreen()
+	is.NoErr(err)
+	is.True(!s.IsScreenSaverOn())
+
+	changes := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	const iface = "org.freedesktop.ScreenSaver"
+	fb.signal("/org/freedesktop/ScreenSaver", "org.example.Other", "ActiveChanged", true) // ignored
+	fb.signal("/org/freedesktop/ScreenSaver", iface, "ActiveChanged", true)
+	is.Equal(true, <-changes)
+	fb.signal("/ScreenSaver", iface, "ActiveChanged", false)
+	is.Equal(false, <-changes)
+
+	is.NoErr(s.Blank())
+	is.Equal(fb.called()[len(fb.called())-1], iface+".SetActive")
+	is.NoErr(s.Unblank())
+	is.Equal(fb.called()[len(fb.called())-1], iface+".SetActive")
+
+	s.Close()
+	is.NoErr(<-done)
+}
+
+func TestMutterDBusScreen(t *testing.T) {
+	is := is.New(t)
+	fb := newFakeBus(t, func(call *fakeCall) ([]any, error) {
+		switch call.member {
+		case "AddIdleWatch":
+			is.Equal(call.body, []any{uint64(60000)}) // idle timeout in ms
+			return []any{uint32(1)}, nil
+		case "AddUserActiveWatch":
+			return []any{uint32(2)}, nil
+		case "GetIdletime":
+			return []any{uint64(5000)}, nil
+		}
+		return nil, nil
+	})
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", fb.address)
+
+	s, err := NewMutterDBusScreen(time.Minute)
+	is.NoErr(err)
+	is.True(!s.IsScreenSaverOn()) // only idle for 5s
+
+	changes := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	const path, iface = "/org/gnome/Mutter/IdleMonitor/Core", "org.gnome.Mutter.IdleMonitor"
+	fb.signal(path, iface, "WatchFired", uint32(1))
+	is.Equal(true, <-changes)
+	fb.signal(path, iface, "WatchFired", uint32(2))
+	is.Equal(false, <-changes)
+
+	s.Close()
+	is.NoErr(<-done)
+}
+
+func TestScreenSaverDBusScreenKDE(t *testing.T) {
+	is := is.New(t)
+	fb := newFakeBus(t, func(call *fakeCall) ([]any, error) {
+		if call.path != "/ScreenSaver" {
+			return nil, dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownObject", Body: []any{"No such object path"}}
+		}
+		if call.member == "GetActive" {
+			return []any{true}, nil
+		}
+		return nil, nil
+	})
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", fb.address)
+
+	s, err := NewScreenSaverDBusScreen()
+	is.NoErr(err)
+	is.True(s.IsScreenSaverOn()) // locked at startup
+
+	changes := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	fb.signal("/ScreenSaver", "org.kde.screensaver", "ActiveChanged", false)
+	is.Equal(false, <-changes)
+	is.NoErr(s.Blank()) // SetActive on /ScreenSaver
+
+	s.Close()
+	is.NoErr(<-done)
+}
+
+func TestLogindDBusScreen(t *testing.T) {
+	is := is.New(t)
+	const path, iface = "/org/freedesktop/login1/session/_32", "org.freedesktop.login1.Session"
+	fb := newFakeBus(t, func(call *fakeCall) ([]any, error) {
+		switch call.member {
+		case "GetSession":
+			is.Equal(call.body, []any{"2"})
+			return []any{dbus.ObjectPath(path)}, nil
+		case "Get":
+			return []any{dbus.MakeVariant(false)}, nil
+		}
+		return nil, nil
+	})
+	t.Setenv("DBUS_SYSTEM_BUS_ADDRESS", fb.address)
+	t.Setenv("XDG_SESSION_ID", "2")
+
+	s, err := NewLogindDBusScreen(logindTriggers{lock: true, idle: true})
+	is.NoErr(err)
+	is.True(!s.IsScreenSaverOn())
+
+	changes := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	fb.signal(path, iface, "Lock")
+	is.Equal(true, <-changes)
+	fb.signal(path, "org.freedesktop.DBus.Properties", "PropertiesChanged",
+		iface, map[string]dbus.Variant{"IdleHint": dbus.MakeVariant(true)}, []string{})
+	fb.signal(path, iface, "Unlock") // still idle
+	fb.signal(path, "org.freedesktop.DBus.Properties", "PropertiesChanged",
+		iface, map[string]dbus.Variant{"IdleHint": dbus.MakeVariant(false)}, []string{})
+	is.Equal(false, <-changes)
+	is.True(s.Unblank() != nil) // would unlock the session
+
+	s.Close()
+	is.NoErr(<-done)
+}
+
+func TestLogindDBusScreenLid(t *testing.T) {
+	is := is.New(t)
+	const path, iface = "/org/freedesktop/login1", "org.freedesktop.login1.Manager"
+	fb := newFakeBus(t, func(call *fakeCall) ([]any, error) {
+		switch call.member {
+		case "GetSession":
+			return []any{dbus.ObjectPath("/org/freedesktop/login1/session/_32")}, nil
+		case "Get":
+			if call.body[1] == "LidClosed" {
+				return []any{dbus.MakeVariant(true)}, nil
+			}
+			return []any{dbus.MakeVariant(false)}, nil
+		}
+		return nil, nil
+	})
+	t.Setenv("DBUS_SYSTEM_BUS_ADDRESS", fb.address)
+
+	s, err := NewLogindDBusScreen(logindTriggers{lid: true})
+	is.NoErr(err)
+	is.True(s.IsScreenSaverOn()) // lid closed at start
+
+	changes := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	fb.signal(path, "org.freedesktop.DBus.Properties", "PropertiesChanged",
+		iface, map[string]dbus.Variant{"LidClosed": dbus.MakeVariant(false)}, []string{})
+	is.Equal(false, <-changes)
+	fb.signal("/org/freedesktop/login1/session/_32", "org.freedesktop.login1.Session", "Lock") // lock not followed
+	fb.signal(path, "org.freedesktop.DBus.Properties", "PropertiesChanged",
+		iface, map[string]dbus.Variant{"LidClosed": dbus.MakeVariant(true)}, []string{})
+	is.Equal(true, <-changes)
+
+	s.Close()
+	is.NoErr(<-done)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"foxygo.at/offscreen/pkg/bravia"
+)
+
+// DemoCmd is the kong CLI struct for the `demo` command.
+type DemoCmd struct{}
+
+// demoStep is a step in the scripted day played through by the `demo`
+// command. Each step happens at a time of day, is described for the
+// timeline and then acts on the fake screen and simulated TV.
+type demoStep struct {
+	at   string
+	desc string
+	act  func(d *demo) error
+}
+
+// demo holds the parts wired together by the `demo` command.
+type demo struct {
+	out    io.Writer
+	screen *FakeScreen
+	sim    *braviaSim
+	tc     *tvController
+}
+
+// demoScript is the scripted day played through by the `demo` command.
+var demoScript = []demoStep{
+	{"08:00", "monitor plugged in, user is at the desk", func(d *demo) error {
+		return d.screen.Send(fakePresent)
+	}},
+	{"12:00", "user goes to lunch, screen saver turns on", func(d *demo) error {
+		return d.screen.Send(fakeSSOn)
+	}},
+	{"13:00", "user is back, screen saver turns off", func(d *demo) error {
+		return d.screen.Send(fakeSSOff)
+	}},
+	{"15:00", "a laptop on HDMI 1 takes over the TV", func(d *demo) error {
+		d.sim.SelectInput("extInput:hdmi?port=1")
+		return nil
+	}},
+	{"15:01", "offscreen polls the TV and notices our input was taken", func(d *demo) error {
+		err := d.tc.Reconcile()
+		d.screen.Sync() // wait for the resulting blank to be processed
+		return err
+	}},
+	{"17:00", "user wakes this host while the laptop is still shown", func(d *demo) error {
+		return d.screen.Send(fakeSSOff)
+	}},
+	{"18:00", "monitor unplugged, screen saver turns on", func(d *demo) error {
+		if err := d.screen.Send(fakeAbsent); err != nil {
+			return err
+		}
+		return d.screen.Send(fakeSSOn)
+	}},
+	{"19:00", "monitor plugged back in while the screen saver is on", func(d *demo) error {
+		return d.screen.Send(fakePresent)
+	}},
+	{"20:00", "TV drops off the network, user wakes this host", func(d *demo) error {
+		d.sim.SetOffline(true)
+		return d.screen.Send(fakeSSOff)
+	}},
+	{"21:00", "TV is back on the network, screen saver turns on", func(d *demo) error {
+		d.sim.SetOffline(false)
+		return d.screen.Send(fakeSSOn)
+	}},
+}
+
+// Run (demo) plays through a scripted day with a fake screen and a
+// simulated Bravia TV, printing a timeline of events and the actions taken
+// by the same logic used by `offscreen run`. It needs neither an X server
+// nor a TV, so is useful for seeing how offscreen behaves, debugging its
+// decisions and reproducing bug reports deterministically.
+func (cmd *DemoCmd) Run(ctx context.Context) error {
+	return runDemo(ctx, os.Stdout)
+}
+
+// runDemo plays through the demo script writing the timeline to out.
+func runDemo(ctx context.Context, out io.Writer) error {
+	d := &demo{out: out}
+	logf := func(format string, args ...any) {
+		fmt.Fprintf(d.out, "        %s\n", fmt.Sprintf(format, args...))
+	}
+	d.sim = newBraviaSim(logf, "laptop", "demo")
+	hostname, stop, err := d.sim.Start()
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	diag.Tee(func(event string) { logf("offscreen: %s", event) })
+	defer diag.Tee(nil)
+
+	c := bravia.NewRESTClient(hostname, "")
+	ourInput, err := getInputURI(ctx, c, "demo")
+	if err != nil {
+		return fmt.Errorf("could not get input URI for demo: %w", err)
+	}
+	d.screen = NewFakeScreen(false /* ssOn */, false /* present */)
+	d.tc = &tvController{
+		ctx:         ctx,
+		client:      c,
+		ourInput:    ourInput,
+		screen:      d.screen,
+		onInputLost: "blank",
+	}
+
+	// Errors are shown in the timeline rather than stopping the demo.
+	// `offscreen run` would exit with the error.
+	watcher := ScreenWatcherFunc(func(ssOn bool) error {
+		if err := d.tc.SSChange(ssOn); err != nil {
+			logf("error: %v (offscreen run would exit here)", withHint(err))
+		}
+		return nil
+	})
+	watchErr := make(chan error, 1)
+	go func() { watchErr <- d.screen.Watch(watcher) }()
+
+	fmt.Fprintf(d.out, "Our host is %q on %s. The TV is in standby.\n\n", "demo", ourInput)
+	for _, step := range demoScript {
+		fmt.Fprintf(d.out, "%s  %s\n", step.at, step.desc)
+		if err := step.act(d); err != nil {
+			logf("error: %v", withHint(err))
+		}
+	}
+
+	d.screen.Close()
+	return <-watchErr
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestDemo(t *testing.T) {
+	is := is.New(t)
+
+	var sb strings.Builder
+	err := runDemo(context.Background(), &sb)
+	is.NoErr(err) // demo failed
+
+	// The timeline should contain these lines in this order.
+	timeline := sb.String()
+	want := []string{
+		"08:00  monitor plugged in",
+		"        TV power → active",
+		"        TV input → extInput:hdmi?port=2",
+		"12:00  user goes to lunch",
+		"        TV power → standby",
+		"13:00  user is back",
+		"        TV power → active",
+		"15:01  offscreen polls the TV",
+		"        offscreen: blanking screen as our input was taken away",
+		"20:00  TV drops off the network",
+		"(offscreen run would exit here)",
+	}
+	for _, w := range want {
+		i := strings.Index(timeline, w)
+		if i < 0 {
+			t.Fatalf("timeline missing %q after previous lines:\n%s", w, sb.String())
+		}
+		timeline = timeline[i+len(w):]
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"foxygo.at/offscreen/pkg/bravia"
+	"github.com/alecthomas/kong"
+)
+
+// maxDiagEntries is the number of events and TV responses kept for the
+// diagnostics bundle. Older entries are discarded.
+const maxDiagEntries = 50
+
+// redacted replaces secrets in the diagnostics bundle.
+const redacted = "<redacted>"
+
+// diag is the process-wide recorder of recent activity that is written out
+// as a diagnostics bundle if offscreen panics or exits with an error.
+var diag = &diagnostics{}
+
+// The TV clients record what they do to diag too.
+func init() { bravia.SetDiagnostics(diag) }
+
+// diagnostics records recent screen events, actions and responses from the
+// TV so that a bug report can contain enough detail to reproduce a problem.
+// Secrets registered with [diagnostics.AddSecret] are redacted from
+// everything that is written out.
+type diagnostics struct {
+	mu        sync.Mutex
+	events    []string
+	responses []string
+	secrets   []string
+
+	// tee, if not nil, is also called with each event as it is recorded.
+	tee func(event string)
+}
+
+// AddSecret registers a string that must never appear in a diagnostics
+// bundle. Empty strings are ignored.
+func (d *diagnostics) AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.secrets = append(d.secrets, secret)
+}
+
+// Tee sets a function to be called with each event as it is recorded, for
+// showing events as they happen. It replaces any previously set function.
+func (d *diagnostics) Tee(fn func(event string)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tee = fn
+}
+
+// Event records a timestamped event in the event history.
+func (d *diagnostics) Event(format string, args ...any) {
+	event := fmt.Sprintf(format, args...)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = appendRing(d.events, timestamp()+" "+event)
+	if d.tee != nil {
+		d.tee(d.redact(event))
+	}
+}
+
+// TVResponse records the body of a response from the TV for the given
+// service and method, or the error if no response was received.
+func (d *diagnostics) TVResponse(service, method string, body []byte, err error) {
+	entry := fmt.Sprintf("%s %s.%s: %s", timestamp(), service, method, string(body))
+	if err != nil {
+		entry = fmt.Sprintf("%s %s.%s: error: %v", timestamp(), service, method, err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.responses = appendRing(d.responses, entry)
+}
+
+// WriteBundle writes a diagnostics bundle to a new file in the temporary
+// directory and returns its path. The bundle contains the cause of the
+// failure, the configuration from kctx, the event history, the recent TV
+// responses and a dump of all goroutines.
+func (d *diagnostics) WriteBundle(kctx *kong.Context, cause string) (string, error) {
+	f, err := os.CreateTemp("", "offscreen-diag-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("could not create diagnostics bundle: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // errors are caught by the Sync below
+
+	d.writeBundle(f, kctx, cause)
+	if err := f.Sync(); err != nil {
+		return "", fmt.Errorf("could not write diagnostics bundle: %w", err)
+	}
+	return f.Name(), nil
+}
+
+func (d *diagnostics) writeBundle(w io.Writer, kctx *kong.Context, cause string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "offscreen %s (%s %s/%s)\n", version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&sb, "time: %s\ncause: %s\n", timestamp(), cause)
+
+	sb.WriteString("\n== config ==\n")
+	if kctx != nil {
+		fmt.Fprintf(&sb, "command: %s\n", kctx.Command())
+		for _, flag := range kctx.Flags() {
+			value := fmt.Sprint(flag.Target.Interface())
+			if flag.Name == "psk" && value != "" {
+				value = redacted
+			}
+			fmt.Fprintf(&sb, "--%s=%s\n", flag.Name, value)
+		}
+	}
+
+	sb.WriteString("\n== events ==\n")
+	for _, e := range d.events {
+		sb.WriteString(e + "\n")
+	}
+
+	sb.WriteString("\n== tv responses ==\n")
+	for _, r := range d.responses {
+		sb.WriteString(r + "\n")
+	}
+
+	sb.WriteString("\n== goroutines ==\n")
+	buf := make([]byte, 1<<20)
+	sb.Write(buf[:runtime.Stack(buf, true)])
+
+	io.WriteString(w, d.redact(sb.String())) //nolint:errcheck,gosec // checked by caller
+}
+
+func (d *diagnostics) redact(s string) string {
+	for _, secret := range d.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// writeDiagnostics writes a diagnostics bundle and tells the user where to
+// find it. Failing to write the bundle is reported but is not fatal as we
+// are already on the way out.
+func writeDiagnostics(kctx *kong.Context, cause string) {
+	path, err := diag.WriteBundle(kctx, cause)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "offscreen: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "offscreen: diagnostics written to %s (please attach it to bug reports)\n", path)
+}
+
+func appendRing(entries []string, entry string) []string {
+	entries = append(entries, entry)
+	if len(entries) > maxDiagEntries {
+		entries = entries[len(entries)-maxDiagEntries:]
+	}
+	return entries
+}
+
+func timestamp() string {
+	return time.Now().Format(time.RFC3339Nano)
+}
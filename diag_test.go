@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestDiagnosticsRedactsSecrets(t *testing.T) {
+	is := is.New(t)
+
+	d := &diagnostics{}
+	d.AddSecret("s3kr1t")
+	d.AddSecret("")
+	d.Event("connecting with psk s3kr1t")
+	d.TVResponse("system", "getPowerStatus", []byte(`{"result":[{"status":"active"}]}`), nil)
+
+	var sb strings.Builder
+	d.writeBundle(&sb, nil, "failed with s3kr1t")
+	bundle := sb.String()
+
+	is.True(!strings.Contains(bundle, "s3kr1t"))                       // secret not redacted
+	is.True(strings.Contains(bundle, "connecting with psk "+redacted)) // event missing
+	is.True(strings.Contains(bundle, "system.getPowerStatus"))         // TV response missing
+	is.True(strings.Contains(bundle, "== goroutines =="))              // goroutine dump missing
+}
+
+func TestDiagnosticsHistoryIsBounded(t *testing.T) {
+	is := is.New(t)
+
+	d := &diagnostics{}
+	for i := 0; i < maxDiagEntries*2; i++ {
+		d.Event("event %d", i)
+	}
+	is.Equal(len(d.events), maxDiagEntries)                                                             // history not bounded
+	is.True(strings.HasSuffix(d.events[maxDiagEntries-1], fmt.Sprintf("event %d", maxDiagEntries*2-1))) // latest event missing
+}
@@ -0,0 +1,112 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"foxygo.at/offscreen/pkg/bravia"
+	"github.com/jezek/xgb/randr"
+)
+
+// discoverWait is how long to wait for TVs on the local network to respond
+// when looking for the TV to control.
+const discoverWait = 3 * time.Second
+
+// tvCache caches the hosts of the TVs found on the local network, keyed by
+// the monitor they were found for, so offscreen can still start when the TV
+// does not respond, such as in standby with remote start disabled.
+var tvCache = hostCache{what: "TV cache", file: "tvs.json", dir: os.UserCacheDir}
+
+// discoverHostname sets --hostname, when it is not given, to the TV of the
+// managed monitor found on the local network with [bravia.Discover]. If no
+// TV responds, the TV found for the monitor before is used.
+func (cmd *RunCmd) discoverHostname(ctx context.Context) error {
+	m := cmd.monitor()
+	name, serial := cmd.monitorIdentity(m)
+	tvs, err := bravia.Discover(ctx, discoverWait)
+	if err != nil {
+		warnf("could not look for TVs: %v", err)
+	}
+	tv, err := pickTV(tvs, name, serial)
+	if err != nil {
+		if host := tvCache.get(m.String()); host != "" && len(tvs) == 0 {
+			diag.Event("no TV found; using %s as found before", host)
+			cmd.Hostname = host
+			return nil
+		}
+		return err
+	}
+	diag.Event("found TV %q (%s) at %s", tv.FriendlyName, tv.ModelName, tv.Host)
+	cmd.Hostname = tv.Host
+	if err := tvCache.set(m.String(), tv.Host); err != nil {
+		warnf("could not cache TV host: %v", err)
+	}
+	return nil
+}
+
+// monitorIdentity returns the name and serial number of the managed monitor
+// from its EDID, to pick its TV among those on the network by. Only the
+// serial number given with --edid-serial is known without X11.
+func (cmd *RunCmd) monitorIdentity(m monitorSpec) (name, serial string) {
+	if cmd.backend() != "x11" {
+		return "", m.serial
+	}
+	c, err := cmd.dial()
+	if err != nil {
+		return "", m.serial
+	}
+	defer c.Close()
+	if randr.Init(c) != nil {
+		return "", m.serial
+	}
+	outputs, err := ListOutputs(c)
+	if err != nil {
+		diag.Event("could not list outputs: %v", err)
+		return "", m.serial
+	}
+	for _, o := range outputs {
+		if m.output != "" && o.Output == m.output ||
+			m.output == "" && o.Manufacturer == m.manufacturer && o.ProductCode == m.productCode && (m.serial == "" || sameSerial(o.Serial, m.serial)) {
+			return o.Name, o.Serial
+		}
+	}
+	return "", m.serial
+}
+
+// pickTV picks the TV of a monitor with the given EDID name and serial number
+// (either of which may be empty) among the TVs found on the network: the one
+// with the same serial number, otherwise the one whose model is named by the
+// EDID, otherwise the only TV found.
+func pickTV(tvs []bravia.DiscoveredTV, name, serial string) (bravia.DiscoveredTV, error) {
+	if len(tvs) == 0 {
+		return bravia.DiscoveredTV{}, errors.New("no TV found on the local network; set --hostname")
+	}
+	matching := func(match func(tv bravia.DiscoveredTV) bool) []bravia.DiscoveredTV {
+		var found []bravia.DiscoveredTV
+		for _, tv := range tvs {
+			if match(tv) {
+				found = append(found, tv)
+			}
+		}
+		return found
+	}
+	if found := matching(func(tv bravia.DiscoveredTV) bool { return sameSerial(tv.SerialNumber, serial) }); len(found) == 1 {
+		return found[0], nil
+	}
+	if found := matching(func(tv bravia.DiscoveredTV) bool { return name != "" && strings.EqualFold(tv.ModelName, name) }); len(found) == 1 {
+		return found[0], nil
+	}
+	if len(tvs) == 1 {
+		return tvs[0], nil
+	}
+	names := make([]string, 0, len(tvs))
+	for _, tv := range tvs {
+		names = append(names, fmt.Sprintf("%s (%s) at %s", tv.FriendlyName, tv.ModelName, tv.Host))
+	}
+	return bravia.DiscoveredTV{}, fmt.Errorf("found several TVs on the local network, %s; set --hostname", strings.Join(names, ", "))
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"foxygo.at/offscreen/pkg/bravia"
+	"github.com/matryer/is"
+)
+
+func TestPickTV(t *testing.T) {
+	is := is.New(t)
+	lounge := bravia.DiscoveredTV{Host: "192.168.1.20", FriendlyName: "Lounge", ModelName: "KD-55X85J", SerialNumber: "7001234"}
+	den := bravia.DiscoveredTV{Host: "192.168.1.21", FriendlyName: "Den", ModelName: "KD-43X80J", SerialNumber: "7005678"}
+
+	tv, err := pickTV([]bravia.DiscoveredTV{lounge}, "SONY TV", "")
+	is.NoErr(err)
+	is.Equal(tv, lounge) // the only TV
+
+	tv, err = pickTV([]bravia.DiscoveredTV{lounge, den}, "SONY TV", "7005678")
+	is.NoErr(err)
+	is.Equal(tv, den) // by serial number
+
+	tv, err = pickTV([]bravia.DiscoveredTV{lounge, den}, "kd-55x85j", "")
+	is.NoErr(err)
+	is.Equal(tv, lounge) // by model
+
+	_, err = pickTV([]bravia.DiscoveredTV{lounge, den}, "SONY TV", "")
+	is.True(err != nil) // cannot tell them apart
+	_, err = pickTV(nil, "SONY TV", "7001234")
+	is.True(err != nil) // none found
+}
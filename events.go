@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jezek/xgb/randr"
+	"github.com/jezek/xgb/screensaver"
+	"github.com/jezek/xgb/xproto"
+)
+
+// EventsCmd is the kong CLI struct for the `events` command.
+type EventsCmd struct {
+	xFlags
+	JSON bool `help:"Print each event as a line of JSON"`
+}
+
+// screenEvent is an event printed by `events`: the screen saver turning on
+// or off, or a monitor being connected or disconnected.
+type screenEvent struct {
+	Time  string `json:"time"`
+	Event string `json:"event"`           // screensaver or monitor
+	State string `json:"state,omitempty"` // on or off, for screensaver events
+	*OutputInfo
+}
+
+// Run (events) prints a line for each screen saver on/off event and each
+// monitor connect/disconnect event of the X server until interrupted, as
+// text or as JSON with --json, starting with the current screen saver state
+// and connected monitors. It is for building other automation on the events
+// offscreen follows, without a TV.
+func (cmd *EventsCmd) Run(ctx context.Context) error {
+	c, err := cmd.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if err := randr.Init(c); err != nil {
+		return fmt.Errorf("could not initialise RANDR extension: %w", err)
+	}
+	root := xproto.Setup(c).DefaultScreen(c).Root
+	edidAtom, err := xproto.InternAtom(c, false /* OnlyIfExists */, 4, "EDID").Reply()
+	if err != nil {
+		return fmt.Errorf("could not intern X11 atom: %w", err)
+	}
+
+	// Stop waiting for events once interrupted.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-done:
+		}
+	}()
+
+	var ssOn bool
+	if err := screensaver.Init(c); err != nil {
+		warnf("X server has no SCREENSAVER extension; printing only monitor events")
+	} else {
+		err := screensaver.SelectInputChecked(c, xproto.Drawable(root), screensaver.EventNotifyMask).Check()
+		if err != nil {
+			return fmt.Errorf("could not watch SCREENSAVER events: %w", err)
+		}
+		info, err := screensaver.QueryInfo(c, xproto.Drawable(root)).Reply()
+		if err != nil {
+			return fmt.Errorf("could not query screen saver: %w", err)
+		}
+		ssOn = info.State == screensaver.StateOn || info.State == screensaver.StateCycle
+		if err := cmd.print(os.Stdout, ssEvent(ssOn)); err != nil {
+			return err
+		}
+	}
+
+	if err := randr.SelectInputChecked(c, root, randr.NotifyMaskOutputChange).Check(); err != nil {
+		return fmt.Errorf("could not watch RANDR events: %w", err)
+	}
+	r, err := randr.GetScreenResourcesCurrent(c, root).Reply()
+	if err != nil {
+		return fmt.Errorf("could not get screens: %w", err)
+	}
+	// connections are the last connection states of the outputs, as RANDR
+	// also sends output change events for mode changes.
+	connections := map[randr.Output]string{}
+	for _, output := range r.Outputs {
+		info, err := outputInfo(c, output, r.ConfigTimestamp, edidAtom.Atom)
+		if err != nil {
+			return err
+		}
+		connections[output] = info.Connection
+		if info.Connection == "connected" {
+			if err := cmd.print(os.Stdout, monitorEvent(info)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		ev, xerr := c.WaitForEvent()
+		if xerr != nil {
+			if fatalXError(xerr) {
+				return fmt.Errorf("could not wait for events: %w", xerr)
+			}
+			diag.Event("events: ignoring X error: %v", xerr)
+			continue
+		}
+		if ev == nil { // X11 connection closed
+			if ctx.Err() != nil {
+				return nil
+			}
+			return ErrXConnLost
+		}
+		var sev screenEvent
+		switch event := ev.(type) {
+		case screensaver.NotifyEvent:
+			isOn := event.State == screensaver.StateOn || event.State == screensaver.StateCycle
+			if isOn == ssOn {
+				continue
+			}
+			ssOn = isOn
+			sev = ssEvent(isOn)
+		case randr.NotifyEvent:
+			if event.SubCode != randr.NotifyOutputChange {
+				continue
+			}
+			oc := event.U.Oc
+			connection := outputConnections[oc.Connection]
+			if connections[oc.Output] == connection {
+				continue
+			}
+			connections[oc.Output] = connection
+			info, err := outputInfo(c, oc.Output, oc.ConfigTimestamp, edidAtom.Atom)
+			if err != nil {
+				diag.Event("events: %v", err)
+			}
+			info.Connection = connection
+			sev = monitorEvent(info)
+		default:
+			continue
+		}
+		if err := cmd.print(os.Stdout, sev); err != nil {
+			return err
+		}
+	}
+}
+
+// ssEvent returns the event for the screen saver turning on or off now.
+func ssEvent(on bool) screenEvent {
+	state := "off"
+	if on {
+		state = "on"
+	}
+	return screenEvent{Time: time.Now().Format(time.RFC3339), Event: "screensaver", State: state}
+}
+
+// monitorEvent returns the event for the monitor of an output being
+// connected or disconnected now.
+func monitorEvent(info OutputInfo) screenEvent {
+	return screenEvent{Time: time.Now().Format(time.RFC3339), Event: "monitor", OutputInfo: &info}
+}
+
+// print prints ev to w as a line of text, or of JSON with --json. Monitor
+// events give the connection state, the output and, if known, the monitor as
+// MANUFACTURER:PRODUCT-CODE[:EDID-SERIAL] as for `run --monitor` and its
+// name.
+func (cmd *EventsCmd) print(w io.Writer, ev screenEvent) error {
+	if cmd.JSON {
+		return json.NewEncoder(w).Encode(ev)
+	}
+	fields := []string{ev.Time, ev.Event}
+	if ev.OutputInfo == nil {
+		fields = append(fields, ev.State)
+	} else {
+		o := ev.OutputInfo
+		fields = append(fields, o.Connection, o.Output)
+		if o.Manufacturer != "" {
+			m := monitorSpec{manufacturer: o.Manufacturer, productCode: o.ProductCode, serial: o.Serial}
+			fields = append(fields, m.String())
+		}
+		if o.Name != "" {
+			fields = append(fields, fmt.Sprintf("%q", o.Name))
+		}
+	}
+	_, err := fmt.Fprintln(w, strings.Join(fields, " "))
+	return err
+}
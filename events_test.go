@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestEventsPrint(t *testing.T) {
+	is := is.New(t)
+	events := []screenEvent{
+		{Time: "2024-01-02T21:00:00Z", Event: "screensaver", State: "on"},
+		{Time: "2024-01-02T21:00:01Z", Event: "monitor", OutputInfo: &OutputInfo{Output: "HDMI-1", Connection: "connected", Name: "SONY TV", Manufacturer: "SNY", ProductCode: 63747, Serial: "7001234"}},
+		{Time: "2024-01-02T21:00:02Z", Event: "monitor", OutputInfo: &OutputInfo{Output: "HDMI-1", Connection: "disconnected"}},
+	}
+
+	var sb strings.Builder
+	cmd := &EventsCmd{}
+	for _, ev := range events {
+		is.NoErr(cmd.print(&sb, ev))
+	}
+	is.Equal(sb.String(), `2024-01-02T21:00:00Z screensaver on
+2024-01-02T21:00:01Z monitor connected HDMI-1 SNY:63747:7001234 "SONY TV"
+2024-01-02T21:00:02Z monitor disconnected HDMI-1
+`)
+
+	sb.Reset()
+	cmd.JSON = true
+	for _, ev := range events {
+		is.NoErr(cmd.print(&sb, ev))
+	}
+	is.Equal(sb.String(), `{"time":"2024-01-02T21:00:00Z","event":"screensaver","state":"on"}
+{"time":"2024-01-02T21:00:01Z","event":"monitor","output":"HDMI-1","connection":"connected","name":"SONY TV","manufacturer":"SNY","productCode":63747,"serial":"7001234"}
+{"time":"2024-01-02T21:00:02Z","event":"monitor","output":"HDMI-1","connection":"disconnected"}
+`)
+}
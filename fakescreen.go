@@ -0,0 +1,232 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Fake screen events that can be sent to a [FakeScreen].
+const (
+	fakeSSOn    = "ss on"
+	fakeSSOff   = "ss off"
+	fakePresent = "present"
+	fakeAbsent  = "absent"
+)
+
+// FakeScreen is a [ScreenBackend] driven by synthetic events instead of an
+// X server, so the TV logic can be exercised without a display or a
+// monitor. Events are sent with [FakeScreen.Send] and are processed by
+// [FakeScreen.Watch] with the same rules as [Screen.Watch].
+type FakeScreen struct {
+	events    chan fakeEvent
+	done      chan struct{}
+	closeOnce sync.Once
+
+	ssOn    atomic.Bool
+	present atomic.Bool
+}
+
+type fakeEvent struct {
+	name string
+	ack  chan struct{} // closed when processed; nil if not waited on
+}
+
+// NewFakeScreen returns a FakeScreen with the given initial screen saver
+// state and monitor presence.
+func NewFakeScreen(ssOn, present bool) *FakeScreen {
+	fs := &FakeScreen{
+		// Buffered so Blank can queue an event without blocking while
+		// the watcher is busy.
+		events: make(chan fakeEvent, 16),
+		done:   make(chan struct{}),
+	}
+	fs.ssOn.Store(ssOn)
+	fs.present.Store(present)
+	return fs
+}
+
+// Send sends an event to the screen and waits for [FakeScreen.Watch] to
+// process it. The event is one of "ss on", "ss off", "present" or "absent".
+// Events queued before it, such as from [FakeScreen.Blank], are processed
+// first.
+func (fs *FakeScreen) Send(event string) error {
+	switch event {
+	case fakeSSOn, fakeSSOff, fakePresent, fakeAbsent:
+	default:
+		return fmt.Errorf("%w: unknown fake screen event %q", ErrUsage, event)
+	}
+	fs.send(event)
+	return nil
+}
+
+// ReadEvents sends the events read from r, one per line, to the screen until
+// r reaches EOF or the screen is closed. Blank lines and lines starting with
+// # are skipped and unknown events are warned about.
+func (fs *FakeScreen) ReadEvents(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := fs.Send(line); err != nil {
+			warnf("ignoring %v (expected ss on, ss off, present or absent)", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// newFakeEventScreen returns a FakeScreen, with the monitor present and the
+// screen saver off, driven by the events read from the file at path, or
+// stdin if path is "-", as with [FakeScreen.ReadEvents]. A FIFO is opened
+// again whenever its writer closes it, so events can be written to it by
+// separate commands; otherwise the screen is closed at EOF.
+func newFakeEventScreen(path string) (*FakeScreen, error) {
+	fs := NewFakeScreen(false /* ssOn */, true /* present */)
+	if path == "-" {
+		go func() {
+			defer fs.Close()
+			if err := fs.ReadEvents(os.Stdin); err != nil {
+				warnf("could not read fake screen events: %v", err)
+			}
+			diag.Event("fake screen events ended")
+		}()
+		return fs, nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open fake screen events: %w", err)
+	}
+	fifo := fi.Mode()&os.ModeNamedPipe != 0
+	go func() {
+		defer fs.Close()
+		for {
+			// Opening a FIFO blocks until there is a writer.
+			f, err := os.Open(path)
+			if err != nil {
+				warnf("could not open fake screen events: %v", err)
+				return
+			}
+			err = fs.ReadEvents(f)
+			f.Close()
+			if err != nil {
+				warnf("could not read fake screen events: %v", err)
+				return
+			}
+			select {
+			case <-fs.done:
+				return
+			default:
+			}
+			if !fifo {
+				diag.Event("fake screen events ended")
+				return
+			}
+		}
+	}()
+	return fs, nil
+}
+
+// Sync waits for all queued events, such as from [FakeScreen.Blank], to be
+// processed by [FakeScreen.Watch].
+func (fs *FakeScreen) Sync() {
+	fs.send("")
+}
+
+func (fs *FakeScreen) send(event string) {
+	ack := make(chan struct{})
+	select {
+	case fs.events <- fakeEvent{name: event, ack: ack}:
+	case <-fs.done:
+		return
+	}
+	select {
+	case <-ack:
+	case <-fs.done:
+	}
+}
+
+// Close stops the fake screen, causing Watch to return. It may be called
+// more than once.
+func (fs *FakeScreen) Close() {
+	fs.closeOnce.Do(func() { close(fs.done) })
+}
+
+// IsScreenSaverOn returns the current state of the fake screen saver.
+func (fs *FakeScreen) IsScreenSaverOn() bool {
+	return fs.ssOn.Load()
+}
+
+// IsPresent returns whether the fake monitor is present.
+func (fs *FakeScreen) IsPresent() bool {
+	return fs.present.Load()
+}
+
+// Blank queues an event to turn the fake screen saver on. Like blanking an
+// X screen, it does not wait for the resulting event to be processed.
+func (fs *FakeScreen) Blank() error {
+	select {
+	case fs.events <- fakeEvent{name: fakeSSOn}:
+		return nil
+	default:
+		return fmt.Errorf("fake screen event queue full")
+	}
+}
+
+// Unblank queues an event to turn the fake screen saver off, without
+// waiting for it to be processed.
+func (fs *FakeScreen) Unblank() error {
+	select {
+	case fs.events <- fakeEvent{name: fakeSSOff}:
+		return nil
+	default:
+		return fmt.Errorf("fake screen event queue full")
+	}
+}
+
+// Watch processes events until the fake screen is closed, calling the
+// watcher when the screen saver changes state while the monitor is present,
+// or with the current screen saver state when the monitor becomes present.
+func (fs *FakeScreen) Watch(watcher ScreenWatcher) error {
+	for {
+		var ev fakeEvent
+		select {
+		case ev = <-fs.events:
+		case <-fs.done:
+			return nil
+		}
+		err := fs.handle(ev.name, watcher)
+		if ev.ack != nil {
+			close(ev.ack)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (fs *FakeScreen) handle(event string, watcher ScreenWatcher) error {
+	switch event {
+	case fakeSSOn, fakeSSOff:
+		isOn := event == fakeSSOn
+		wasOn := fs.ssOn.Swap(isOn)
+		diag.Event("screen saver on=%v (was %v, monitor present=%v)", isOn, wasOn, fs.IsPresent())
+		if isOn != wasOn && fs.IsPresent() {
+			return watcher.SSChange(isOn)
+		}
+	case fakePresent, fakeAbsent:
+		present := event == fakePresent
+		wasPresent := fs.present.Swap(present)
+		diag.Event("monitor present=%v (was %v)", present, wasPresent)
+		if present && !wasPresent {
+			return watcher.SSChange(fs.IsScreenSaverOn())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestFakeEventScreen(t *testing.T) {
+	is := is.New(t)
+	path := filepath.Join(t.TempDir(), "events")
+	is.NoErr(os.WriteFile(path, []byte("# a day at the desk\nss on\n\nss off\nbogus\nabsent\nss on\npresent\n"), 0o600))
+	s, err := newFakeEventScreen(path)
+	is.NoErr(err)
+
+	var changes []bool
+	err = s.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+		changes = append(changes, ssOn)
+		return nil
+	}))
+	is.NoErr(err) // the screen closes at the end of the file
+	is.Equal(changes, []bool{true, false, true})
+
+	_, err = newFakeEventScreen(filepath.Join(t.TempDir(), "missing"))
+	is.True(err != nil)
+}
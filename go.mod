@@ -5,6 +5,8 @@ go 1.19
 require (
 	github.com/alecthomas/kong v0.7.0
 	github.com/anoopengineer/edidparser v0.0.0-20140306172611-ad417053131c
+	github.com/godbus/dbus/v5 v5.1.0
+	github.com/gorilla/websocket v1.5.0
 	github.com/jezek/xgb v1.1.0
 )
 
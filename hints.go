@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"foxygo.at/offscreen/pkg/bravia"
+)
+
+// sonyErrorHints maps the error codes returned in the payload of REST IP
+// control responses to explanations and suggested fixes.
+var sonyErrorHints = map[int]string{
+	bravia.SonyCodeTimeout:              "the TV timed out handling the request; it may still be waking up, try again",
+	bravia.SonyCodeIllegalArgument:      "the TV rejected an argument; check the value is one the TV supports",
+	bravia.SonyCodeIllegalRequest:       "the TV rejected the request as malformed; the method may need a different API version on this model",
+	bravia.SonyCodeIllegalState:         "the TV cannot do that in its current state; it may need to be turned on first",
+	bravia.SonyCodeNoSuchMethod:         "this TV model does not support that method",
+	bravia.SonyCodeUnsupportedVersion:   "this TV model does not support that API version of the method",
+	bravia.SonyCodeUnsupportedOperation: "this TV model does not support that operation",
+	bravia.SonyCodeForbidden:            "the TV refused the request; check the PSK matches TV Settings → Network → Home network → IP control → Pre-Shared Key",
+	bravia.SonyCodeNotFound:             "the TV does not know that service; this TV model may not support it",
+	bravia.SonyCodeNotImplemented:       "this TV model does not implement that method",
+	bravia.SonyCodeDisplayIsTurnedOff:   "the display is turned off; turn the TV on and try again",
+}
+
+// httpStatusHints maps HTTP status codes of failed REST IP control requests
+// to explanations and suggested fixes.
+var httpStatusHints = map[int]string{
+	http.StatusUnauthorized: "the TV requires authentication; set --psk or $OFFSCREEN_PSK to the TV's Pre-Shared Key",
+	http.StatusForbidden:    "PSK mismatch; check TV Settings → Network → Home network → IP control → Pre-Shared Key matches --psk or $OFFSCREEN_PSK",
+	http.StatusNotFound:     "the host does not look like a Bravia with IP control; check --hostname and that IP control is enabled in TV Settings → Network → Home network → IP control",
+}
+
+// hintError decorates an error with a human explanation of what probably
+// went wrong and how to fix it.
+type hintError struct {
+	err  error
+	hint string
+}
+
+// Error returns the message of the wrapped error followed by the hint.
+func (e hintError) Error() string {
+	return e.err.Error() + "\n  hint: " + e.hint
+}
+
+// Unwrap returns the error that e wraps.
+func (e hintError) Unwrap() error {
+	return e.err
+}
+
+// withHint wraps err with a hint describing how to fix it if the cause of
+// err is known. Otherwise err is returned as is.
+func withHint(err error) error {
+	if h := errorHint(err); h != "" {
+		return hintError{err: err, hint: h}
+	}
+	return err
+}
+
+// errorHint returns an explanation and suggested fix for known Sony errors,
+// HTTP errors and network errors, or the empty string if there is none.
+func errorHint(err error) string {
+	var herr hintError
+	var serr bravia.SonyError
+	var hserr bravia.HTTPStatusError
+	var uerr *url.Error
+	var nerr net.Error
+	var dnserr *net.DNSError
+	var certerr x509.UnknownAuthorityError
+	var hosterr x509.HostnameError
+	switch {
+	case err == nil || errors.As(err, &herr):
+		return ""
+	case errors.Is(err, bravia.ErrPairingExpired):
+		return "the TV's pairing has expired; run `offscreen tv pair` again, which needs the PIN the TV displays"
+	case errors.As(err, &serr):
+		return sonyErrorHints[serr.Code]
+	case errors.As(err, &hserr):
+		return httpStatusHints[int(hserr)]
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "the TV refused the connection; check --hostname and that IP control is enabled on the TV"
+	case errors.As(err, &dnserr):
+		return "the TV's hostname could not be resolved; check --hostname or $OFFSCREEN_HOSTNAME"
+	case errors.As(err, &nerr) && nerr.Timeout():
+		return "the TV did not respond; check it is on the network (eco mode may disable network standby) and --hostname is correct"
+	case errors.As(err, &certerr) || errors.As(err, &hosterr):
+		return "the TV's TLS certificate could not be verified; it is usually self-signed, so use --insecure or $OFFSCREEN_INSECURE"
+	case errors.As(err, &uerr) && strings.Contains(uerr.Err.Error(), "no Host"):
+		return "no TV hostname given; set --hostname or $OFFSCREEN_HOSTNAME"
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"foxygo.at/offscreen/pkg/bravia"
+	"github.com/matryer/is"
+)
+
+func TestWithHint(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		wantHint string
+	}{
+		"nil":          {nil, ""},
+		"unknown":      {errors.New("boom"), ""},
+		"http 403":     {fmt.Errorf("power status: %w", bravia.HTTPStatusError(403)), "PSK mismatch"},
+		"sony 40005":   {fmt.Errorf("input: %w", bravia.SonyError{Code: 40005, Message: "Display Is Turned off"}), "the display is turned off"},
+		"unknown sony": {bravia.SonyError{Code: 99999, Message: "?"}, ""},
+		"pairing":      {fmt.Errorf("power status: %w", bravia.ErrPairingExpired), "the TV's pairing has expired"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			err := withHint(tt.err)
+			if tt.wantHint == "" {
+				is.Equal(tt.err, err) // error should not be decorated
+				return
+			}
+			is.True(strings.Contains(err.Error(), "hint: "+tt.wantHint)) // hint missing
+			is.True(errors.Is(err, tt.err))                              // hint should wrap original error
+			is.Equal(err, withHint(err))                                 // hint should not be added twice
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// hostCache is a JSON file of values about TVs keyed by hostname, such as
+// their MAC addresses, kept in a per-user directory.
+type hostCache struct {
+	// what the cache holds, for error messages, e.g. "mac cache".
+	what string
+
+	// file is the name of the cache file in the offscreen subdirectory of
+	// the directory returned by dir.
+	file string
+	dir  func() (string, error)
+}
+
+// path returns the path of the cache file.
+func (hc hostCache) path() (string, error) {
+	dir, err := hc.dir()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", hc.what, err)
+	}
+	return filepath.Join(dir, "offscreen", hc.file), nil
+}
+
+// load returns the cached values keyed by hostname. A missing cache file is
+// not an error.
+func (hc hostCache) load() (map[string]string, error) {
+	path, err := hc.path()
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", hc.what, err)
+	}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, fmt.Errorf("%s %s: %w", hc.what, path, err)
+	}
+	return values, nil
+}
+
+// get returns the cached value for the TV at hostname, or the empty string
+// if it is not known. Errors reading the cache are only warnings.
+func (hc hostCache) get(hostname string) string {
+	values, err := hc.load()
+	if err != nil {
+		warnf("%v", err)
+	}
+	return values[hostname]
+}
+
+// set saves the value for the TV at hostname in the cache. The cache file is
+// only readable by the user as it may hold secrets.
+func (hc hostCache) set(hostname, value string) error {
+	values, err := hc.load()
+	if err != nil {
+		return err
+	}
+	if values[hostname] == value {
+		return nil
+	}
+	values[hostname] = value
+	path, err := hc.path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("%s: %w", hc.what, err)
+	}
+	b, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%s: %w", hc.what, err)
+	}
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		return fmt.Errorf("%s: %w", hc.what, err)
+	}
+	return nil
+}
+
+// cookieCache caches the auth cookies of TVs paired with a PIN, keyed by
+// hostname. Cookies are credentials, so they are kept in the config
+// directory rather than the cache directory which may be cleaned or shared.
+var cookieCache = hostCache{what: "cookie cache", file: "cookies.json", dir: os.UserConfigDir}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/dpms"
+	"github.com/jezek/xgb/screensaver"
+	"github.com/jezek/xgb/xproto"
+)
+
+// IdleCmd is the kong CLI struct for the `idle` command.
+type IdleCmd struct {
+	xFlags
+	JSON bool `help:"Print as JSON"`
+}
+
+// idleReport is the idle state of the X server printed by `idle`. Times are
+// in milliseconds.
+type idleReport struct {
+	IdleMs int64 `json:"idleMs"`
+	// ScreenSaver is the state of the X screen saver: on, off, cycle,
+	// disabled, or unknown if the X server has no SCREENSAVER extension.
+	ScreenSaver string `json:"screenSaver"`
+	// UntilBlankMs is how long until the screen saver turns on, if it is
+	// off.
+	UntilBlankMs *int64 `json:"untilBlankMs,omitempty"`
+	// TimeoutMs is the screen saver timeout (0 if disabled).
+	TimeoutMs int64 `json:"timeoutMs"`
+
+	DPMS *dpmsReport `json:"dpms,omitempty"` // nil without the DPMS extension
+}
+
+// dpmsReport is the DPMS state of the X server printed by `idle`. A zero
+// timeout is disabled.
+type dpmsReport struct {
+	Enabled    bool   `json:"enabled"`
+	PowerLevel string `json:"powerLevel"` // on, standby, suspend or off
+	StandbyMs  int64  `json:"standbyMs"`
+	SuspendMs  int64  `json:"suspendMs"`
+	OffMs      int64  `json:"offMs"`
+}
+
+// Run (idle) prints the time since the last user input, the state of the X
+// screen saver and how long until it turns on, and the DPMS state, as
+// offscreen sees them, so the screen saver configuration can be checked.
+func (cmd *IdleCmd) Run() error {
+	c, err := cmd.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	r, err := queryIdleReport(c)
+	if err != nil {
+		return err
+	}
+	if cmd.JSON {
+		return printJSON(r)
+	}
+	return r.print(os.Stdout)
+}
+
+// queryIdleReport queries the X server for its idle state.
+func queryIdleReport(c *xgb.Conn) (*idleReport, error) {
+	root := xproto.Setup(c).DefaultScreen(c).Root
+	ss, err := xproto.GetScreenSaver(c).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not get screen saver settings: %w", err)
+	}
+	r := &idleReport{TimeoutMs: int64(ss.Timeout) * 1000}
+	if err := screensaver.Init(c); err != nil {
+		// Xwayland has no SCREENSAVER extension, but has the idle time
+		// in the SYNC extension.
+		r.ScreenSaver = "unknown"
+		counter, err := newIdleCounter(c)
+		if err != nil {
+			return nil, err
+		}
+		idle, err := counter.idle()
+		if err != nil {
+			return nil, err
+		}
+		r.IdleMs = idle.Milliseconds()
+	} else {
+		info, err := screensaver.QueryInfo(c, xproto.Drawable(root)).Reply()
+		if err != nil {
+			return nil, fmt.Errorf("could not query screen saver: %w", err)
+		}
+		r.IdleMs = int64(info.MsSinceUserInput)
+		r.ScreenSaver = map[byte]string{
+			screensaver.StateOff:      "off",
+			screensaver.StateOn:       "on",
+			screensaver.StateCycle:    "cycle",
+			screensaver.StateDisabled: "disabled",
+		}[info.State]
+		if info.State == screensaver.StateOff {
+			until := int64(info.MsUntilServer)
+			r.UntilBlankMs = &until
+		}
+	}
+	if dpms.Init(c) == nil {
+		if r.DPMS, err = queryDPMSReport(c); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// queryDPMSReport queries the X server for its DPMS state.
+func queryDPMSReport(c *xgb.Conn) (*dpmsReport, error) {
+	info, err := dpms.Info(c).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not query DPMS: %w", err)
+	}
+	timeouts, err := dpms.GetTimeouts(c).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not get DPMS timeouts: %w", err)
+	}
+	return &dpmsReport{
+		Enabled: info.State,
+		PowerLevel: map[uint16]string{
+			dpms.DPMSModeOn:      "on",
+			dpms.DPMSModeStandby: "standby",
+			dpms.DPMSModeSuspend: "suspend",
+			dpms.DPMSModeOff:     "off",
+		}[info.PowerLevel],
+		StandbyMs: int64(timeouts.StandbyTimeout) * 1000,
+		SuspendMs: int64(timeouts.SuspendTimeout) * 1000,
+		OffMs:     int64(timeouts.OffTimeout) * 1000,
+	}, nil
+}
+
+// print prints the report as a table to w.
+func (r *idleReport) print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "idle time:\t%v\n", msDuration(r.IdleMs))
+	ss := r.ScreenSaver
+	switch {
+	case r.TimeoutMs == 0:
+		ss += " (no timeout)"
+	case r.UntilBlankMs != nil:
+		ss += fmt.Sprintf(" (turns on in %v, timeout %v)", msDuration(*r.UntilBlankMs), msDuration(r.TimeoutMs))
+	default:
+		ss += fmt.Sprintf(" (timeout %v)", msDuration(r.TimeoutMs))
+	}
+	fmt.Fprintf(tw, "screen saver:\t%s\n", ss)
+	if r.DPMS == nil {
+		fmt.Fprintf(tw, "DPMS:\tnot supported\n")
+		return tw.Flush()
+	}
+	d := r.DPMS
+	state := "disabled"
+	if d.Enabled {
+		var timeouts []string
+		for _, t := range []struct {
+			name string
+			ms   int64
+		}{{"standby", d.StandbyMs}, {"suspend", d.SuspendMs}, {"off", d.OffMs}} {
+			if t.ms != 0 {
+				timeouts = append(timeouts, fmt.Sprintf("%s %v", t.name, msDuration(t.ms)))
+			}
+		}
+		state = "enabled, monitor " + d.PowerLevel
+		if len(timeouts) > 0 {
+			state += " (" + strings.Join(timeouts, ", ") + ")"
+		}
+	}
+	fmt.Fprintf(tw, "DPMS:\t%s\n", state)
+	return tw.Flush()
+}
+
+// msDuration returns ms milliseconds as a duration rounded to the second,
+// for printing.
+func msDuration(ms int64) time.Duration {
+	return (time.Duration(ms) * time.Millisecond).Round(time.Second)
+}
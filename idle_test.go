@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestIdleReportPrint(t *testing.T) {
+	is := is.New(t)
+	until := int64(537_400)
+	r := &idleReport{
+		IdleMs:       62_600,
+		ScreenSaver:  "off",
+		UntilBlankMs: &until,
+		TimeoutMs:    600_000,
+		DPMS:         &dpmsReport{Enabled: true, PowerLevel: "on", OffMs: 900_000},
+	}
+	var sb strings.Builder
+	is.NoErr(r.print(&sb))
+	is.Equal(sb.String(), `idle time:     1m3s
+screen saver:  off (turns on in 8m57s, timeout 10m0s)
+DPMS:          enabled, monitor on (off 15m0s)
+`)
+
+	r = &idleReport{IdleMs: 1000, ScreenSaver: "unknown"}
+	sb.Reset()
+	is.NoErr(r.print(&sb))
+	is.Equal(sb.String(), `idle time:     1s
+screen saver:  unknown (no timeout)
+DPMS:          not supported
+`)
+}
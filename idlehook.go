@@ -0,0 +1,61 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// idleHookScreen is a [ScreenBackend] driven by "idle" and "resume" lines
+// read from an idle daemon, for compositors without an X screen saver or
+// ext-idle-notify-v1. The lines are written by the commands that the idle
+// daemon runs on timeout and resume, e.g. with swayidle:
+//
+//	swayidle -w timeout 600 'echo idle' resume 'echo resume' | offscreen run --screen-backend=stdin
+//
+// The idle daemon cannot tell us about monitors, so the monitor is taken to
+// be always present. The lines are processed with the same rules as
+// [Screen.Watch] by the embedded [FakeScreen].
+type idleHookScreen struct {
+	*FakeScreen
+}
+
+// newIdleHookScreen returns an idleHookScreen reading lines from r. The
+// screen is closed, causing Watch to return, when r reaches EOF.
+func newIdleHookScreen(r io.Reader) *idleHookScreen {
+	s := &idleHookScreen{FakeScreen: NewFakeScreen(false /* ssOn */, true /* present */)}
+	go s.read(r)
+	return s
+}
+
+func (s *idleHookScreen) read(r io.Reader) {
+	defer s.Close()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		switch line := strings.TrimSpace(scanner.Text()); line {
+		case "idle":
+			s.send(fakeSSOn)
+		case "resume":
+			s.send(fakeSSOff)
+		case "":
+		default:
+			warnf("ignoring unknown idle event %q (expected idle or resume)", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		warnf("could not read idle events: %v", err)
+	}
+	diag.Event("idle events ended")
+}
+
+// Blank returns an error as the idle daemon cannot be told to go idle.
+func (s *idleHookScreen) Blank() error {
+	return errors.New("cannot blank the screen with --screen-backend=stdin")
+}
+
+// Unblank returns an error as the idle daemon cannot be told to resume.
+func (s *idleHookScreen) Unblank() error {
+	return errors.New("cannot unblank the screen with --screen-backend=stdin")
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestIdleHookScreen(t *testing.T) {
+	is := is.New(t)
+	r, w := io.Pipe()
+	s := newIdleHookScreen(r)
+
+	changes := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	_, err := io.WriteString(w, "idle\n")
+	is.NoErr(err)
+	is.Equal(true, <-changes)
+	_, err = io.WriteString(w, "resume\n")
+	is.NoErr(err)
+	is.Equal(false, <-changes)
+
+	// The screen closes when the idle daemon goes away.
+	is.NoErr(w.Close())
+	is.NoErr(<-done)
+	is.True(s.Blank() != nil) // cannot blank
+}
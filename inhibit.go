@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// suspendInhibitor holds a systemd idle and sleep inhibitor lock while the
+// TV is showing our input, so the host does not auto-suspend while in use
+// but can sleep freely when nobody is watching it.
+//
+// The lock is held by running `systemd-inhibit` with `cat` reading from a
+// pipe to us. Closing the pipe releases the lock, which also happens if
+// offscreen dies without cleaning up.
+//
+// The methods of suspendInhibitor can be called on a nil *suspendInhibitor
+// and do nothing, for when inhibiting is not enabled.
+type suspendInhibitor struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// set takes the inhibitor lock if hold is true and releases it otherwise.
+// Failures are reported as warnings.
+func (si *suspendInhibitor) set(hold bool) {
+	if si == nil || hold == (si.cmd != nil) {
+		return
+	}
+	if !hold {
+		si.release()
+		return
+	}
+	if err := si.hold(); err != nil {
+		warnf("could not inhibit suspend: %v", err)
+	}
+}
+
+func (si *suspendInhibitor) hold() error {
+	cmd := exec.Command("systemd-inhibit",
+		"--what=idle:sleep",
+		"--who=offscreen",
+		"--why=TV is showing this host",
+		"--mode=block",
+		"cat")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("systemd-inhibit: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("systemd-inhibit: %w", err)
+	}
+	si.cmd, si.stdin = cmd, stdin
+	diag.Event("inhibiting suspend")
+	return nil
+}
+
+func (si *suspendInhibitor) release() {
+	si.stdin.Close() //nolint:errcheck,gosec // nothing useful to do
+	if err := si.cmd.Wait(); err != nil {
+		warnf("systemd-inhibit: %v", err)
+	}
+	si.cmd, si.stdin = nil, nil
+	diag.Event("released suspend inhibitor")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// labelsFile is the name of the file in the offscreen config directory that
+// maps friendly input labels to input URIs for each TV, overriding or adding
+// to the labels set on the TV. It is keyed by TV hostname, e.g.
+//
+//	{"tv.local": {"desk": "extInput:hdmi?port=2", "news": "tv:dvbt"}}
+const labelsFile = "labels.json"
+
+// loadLabelOverrides returns the input label overrides for the TV at
+// hostname (or the serial port of a display controlled over RS-232) from
+// the labels file in the user's config directory, mapping labels to URIs. A
+// missing file is not an error.
+func loadLabelOverrides(hostname string) (map[string]string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("input labels: %w", err)
+	}
+	path := filepath.Join(dir, "offscreen", labelsFile)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("input labels: %w", err)
+	}
+	var labels map[string]map[string]string
+	if err := json.Unmarshal(b, &labels); err != nil {
+		return nil, fmt.Errorf("input labels %s: %w", path, err)
+	}
+	return labels[hostname], nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLoadLabelOverrides(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+
+	labels, err := loadLabelOverrides("tv.local")
+	is.NoErr(err)
+	is.Equal(0, len(labels)) // missing labels file should be empty
+
+	cfg, err := os.UserConfigDir()
+	is.NoErr(err)
+	is.NoErr(os.MkdirAll(filepath.Join(cfg, "offscreen"), 0o750))
+	data := `{"tv.local": {"desk": "extInput:hdmi?port=2"}, "other.local": {"desk": "extInput:hdmi?port=1"}}`
+	is.NoErr(os.WriteFile(filepath.Join(cfg, "offscreen", labelsFile), []byte(data), 0o600))
+
+	labels, err = loadLabelOverrides("tv.local")
+	is.NoErr(err)
+	is.Equal(map[string]string{"desk": "extInput:hdmi?port=2"}, labels)
+}
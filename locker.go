@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"foxygo.at/offscreen/pkg/bravia"
+)
+
+// LockerCmd is the kong CLI struct for the `locker` command.
+type LockerCmd struct {
+	braviaAPI
+	screenFlags
+
+	Input   string `short:"i" help:"The TV input (label or URI) we are connected to"`
+	OffMode string `default:"standby" enum:"standby,pictureOff" help:"How to turn the TV off: standby, or pictureOff which blanks the panel and wakes instantly"`
+
+	Locker []string `arg:"" optional:"" passthrough:"" help:"Screen locker to run, which must not fork, e.g. i3lock -n. offscreen exits when it does"`
+}
+
+// Run (locker) is for being run as the locker of xss-lock, so offscreen
+// takes part in its lock and suspend pipeline rather than competing with it:
+//
+//	xss-lock -- offscreen locker -- i3lock -n
+//
+// xss-lock runs the locker when the screen saver activates, the session is
+// locked or the host is about to suspend, so the TV is turned off straight
+// away, after starting the locker if one is given. The sleep delay lock
+// passed by xss-lock in $XSS_SLEEP_LOCK_FD is then released, letting the host
+// suspend. While running, the TV follows the screen saver as with `run`, so
+// it turns back on to show the lock screen. offscreen exits when the locker
+// does, when killed by xss-lock as the session is unlocked, or without a
+// locker when the screen saver turns off.
+func (cmd *LockerCmd) Run(ctx context.Context) error {
+	defer cmd.screen.Close()
+	c := cmd.tv()
+	if _, isREST := c.(*bravia.RESTClient); !isREST && cmd.OffMode != "standby" {
+		return fmt.Errorf("%w: --off-mode=pictureOff needs the REST API (--protocol=rest without --serial)", ErrUsage)
+	}
+	ourInput, err := getInputURI(ctx, c, cmd.Input)
+	if err != nil {
+		return fmt.Errorf("could not get input URI for %s: %w", cmd.Input, err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tc := &tvController{
+		ctx:      ctx,
+		client:   c,
+		ourInput: ourInput,
+		screen:   cmd.screen,
+		offMode:  cmd.OffMode,
+	}
+	defer tc.Close()
+
+	// Lock the screen first, as the TV may take a while.
+	var locker *exec.Cmd
+	if len(cmd.Locker) > 0 {
+		locker = exec.Command(cmd.Locker[0], cmd.Locker[1:]...) //nolint:gosec // run as asked
+		locker.Stdin, locker.Stdout, locker.Stderr = os.Stdin, os.Stdout, os.Stderr
+		locker.Env = withoutEnv(os.Environ(), "XSS_SLEEP_LOCK_FD")
+		// The locker must not hold the sleep lock open, or the host
+		// cannot suspend until it exits.
+		if fd, ok := sleepLockFD(); ok {
+			closeOnExec(fd)
+		}
+		if err := locker.Start(); err != nil {
+			releaseSleepLock()
+			return fmt.Errorf("could not start locker: %w", err)
+		}
+	}
+	if cmd.screen.IsPresent() {
+		if err := tc.SSChange(true); err != nil {
+			warnf("%v", err)
+		}
+	}
+	releaseSleepLock()
+
+	lockerDone := make(chan error, 1)
+	if locker != nil {
+		go func() { lockerDone <- locker.Wait() }()
+	}
+	watchDone := make(chan error, 1)
+	go func() {
+		watchDone <- watchContext(ctx, cmd.screen, ScreenWatcherFunc(func(ssOn bool) error {
+			err := tc.SSChange(ssOn)
+			if !ssOn && locker == nil {
+				cancel() // nothing left to wait for
+			}
+			return err
+		}))
+	}()
+
+	watching := true
+	select {
+	case err = <-lockerDone:
+		diag.Event("locker exited: %v", err)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			err = fmt.Errorf("locker failed: %w", err)
+		}
+	case err = <-watchDone:
+		watching = false
+	case <-ctx.Done():
+		if locker != nil {
+			diag.Event("stopping locker")
+			locker.Process.Signal(syscall.SIGTERM) //nolint:errcheck,gosec // it may have exited
+			<-lockerDone
+		}
+	}
+	cancel()
+	if watching {
+		<-watchDone
+	}
+	// The session is unlocked unless the screen saver is still on.
+	if cmd.screen.IsPresent() && !cmd.screen.IsScreenSaverOn() {
+		tc.ctx = context.Background()
+		if serr := tc.SSChange(false); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// releaseSleepLock closes the sleep delay lock file descriptor that xss-lock
+// passes in $XSS_SLEEP_LOCK_FD when the host is about to suspend, telling
+// logind that the screen is locked and it may suspend.
+func releaseSleepLock() {
+	fd, ok := sleepLockFD()
+	if !ok {
+		return
+	}
+	diag.Event("releasing sleep lock")
+	if err := os.NewFile(uintptr(fd), "sleep lock").Close(); err != nil {
+		warnf("could not release sleep lock: %v", err)
+	}
+	os.Unsetenv("XSS_SLEEP_LOCK_FD") //nolint:errcheck // only for later locks
+}
+
+// sleepLockFD returns the sleep delay lock file descriptor in
+// $XSS_SLEEP_LOCK_FD, or false if there is none.
+func sleepLockFD() (int, bool) {
+	v := os.Getenv("XSS_SLEEP_LOCK_FD")
+	if v == "" {
+		return 0, false
+	}
+	fd, err := strconv.Atoi(v)
+	if err != nil || fd < 3 {
+		warnf("ignoring bad XSS_SLEEP_LOCK_FD %q", v)
+		return 0, false
+	}
+	return fd, true
+}
+
+// withoutEnv returns the environment env without the variable name.
+func withoutEnv(env []string, name string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, name+"=") {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWithoutEnv(t *testing.T) {
+	is := is.New(t)
+	env := []string{"DISPLAY=:0", "XSS_SLEEP_LOCK_FD=3", "XSS_SLEEP_LOCK_FD_X=1"}
+	is.Equal(withoutEnv(env, "XSS_SLEEP_LOCK_FD"), []string{"DISPLAY=:0", "XSS_SLEEP_LOCK_FD_X=1"})
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "syscall"
+
+// closeOnExec has the file descriptor fd closed in the programs we run.
+func closeOnExec(fd int) {
+	syscall.CloseOnExec(fd)
+}
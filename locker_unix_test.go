@@ -0,0 +1,55 @@
+//go:build !windows
+
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestReleaseSleepLock(t *testing.T) {
+	is := is.New(t)
+	r, w, err := os.Pipe()
+	is.NoErr(err)
+	defer r.Close()
+	// Pass a duplicate, as the lock is closed by number and w would close
+	// the number again when finalized, by when it may be reused.
+	fd, err := syscall.Dup(int(w.Fd()))
+	is.NoErr(err)
+	w.Close()
+	t.Setenv("XSS_SLEEP_LOCK_FD", strconv.Itoa(fd))
+
+	releaseSleepLock()
+	b, err := io.ReadAll(r) // EOF once the only writer is closed
+	is.NoErr(err)
+	is.Equal(len(b), 0)
+	is.Equal(os.Getenv("XSS_SLEEP_LOCK_FD"), "")
+}
+
+func TestSleepLockNotInherited(t *testing.T) {
+	is := is.New(t)
+	r, w, err := os.Pipe()
+	is.NoErr(err)
+	defer r.Close()
+	defer w.Close()
+	// Go opens pipes close-on-exec; xss-lock passes the lock without it.
+	fd, err := syscall.Dup(int(w.Fd()))
+	is.NoErr(err)
+	t.Setenv("XSS_SLEEP_LOCK_FD", strconv.Itoa(fd))
+
+	inherited := func() bool {
+		return exec.Command("sh", "-c", "test -e /dev/fd/"+strconv.Itoa(fd)).Run() == nil
+	}
+	is.True(inherited()) // the test is broken otherwise
+	lockFD, ok := sleepLockFD()
+	is.True(ok)
+	closeOnExec(lockFD)
+	is.True(!inherited())
+	releaseSleepLock()
+}
@@ -0,0 +1,5 @@
+package main
+
+// closeOnExec does nothing as handles are not inherited on Windows unless
+// asked for.
+func closeOnExec(fd int) {}
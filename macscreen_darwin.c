@@ -0,0 +1,60 @@
+//go:build darwin && cgo
+
+#include <CoreFoundation/CoreFoundation.h>
+#include <CoreGraphics/CoreGraphics.h>
+#include <IOKit/IOKitLib.h>
+#include <IOKit/IOMessage.h>
+
+#include "_cgo_export.h"
+
+// displayState returns 1 if the display with the given EDID vendor, model
+// and, if matchSerial is set, serial number is asleep, 0 if it is awake or
+// not found, and -1 if the displays could not be listed. present is set to
+// whether the display was found. Online displays include sleeping ones.
+int displayState(uint32_t vendor, uint32_t model, uint32_t serial, int matchSerial, int *present) {
+	CGDirectDisplayID ids[32];
+	uint32_t n = 0;
+	*present = 0;
+	if (CGGetOnlineDisplayList(32, ids, &n) != kCGErrorSuccess) {
+		return -1;
+	}
+	for (uint32_t i = 0; i < n; i++) {
+		if (CGDisplayVendorNumber(ids[i]) != vendor || CGDisplayModelNumber(ids[i]) != model) {
+			continue;
+		}
+		if (matchSerial && CGDisplaySerialNumber(ids[i]) != serial) {
+			continue;
+		}
+		*present = 1;
+		return CGDisplayIsAsleep(ids[i]) ? 1 : 0;
+	}
+	return 0;
+}
+
+static void displayPowerCallback(void *refcon, io_service_t service, natural_t type, void *arg) {
+	if (type == kIOMessageDeviceWillPowerOff || type == kIOMessageDeviceHasPoweredOn) {
+		displayPowerChanged();
+	}
+}
+
+// watchDisplayPower calls displayPowerChanged whenever the display wrangler
+// powers the displays off or on. It runs the run loop of the calling thread
+// so does not return unless the notifications could not be set up, in which
+// case it returns -1.
+int watchDisplayPower(void) {
+	io_service_t wrangler = IOServiceGetMatchingService(kIOMasterPortDefault, IOServiceMatching("IODisplayWrangler"));
+	if (!wrangler) {
+		return -1;
+	}
+	IONotificationPortRef port = IONotificationPortCreate(kIOMasterPortDefault);
+	io_object_t notifier;
+	kern_return_t kr = IOServiceAddInterestNotification(port, wrangler, kIOGeneralInterest, displayPowerCallback, NULL, &notifier);
+	IOObjectRelease(wrangler);
+	if (kr != KERN_SUCCESS) {
+		IONotificationPortDestroy(port);
+		return -1;
+	}
+	CFRunLoopAddSource(CFRunLoopGetCurrent(), IONotificationPortGetRunLoopSource(port), kCFRunLoopDefaultMode);
+	CFRunLoopRun();
+	return 0;
+}
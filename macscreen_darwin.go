@@ -0,0 +1,105 @@
+//go:build darwin && cgo
+
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+/*
+#cgo LDFLAGS: -framework CoreFoundation -framework CoreGraphics -framework IOKit
+#include <stdint.h>
+
+int displayState(uint32_t vendor, uint32_t model, uint32_t serial, int matchSerial, int *present);
+int watchDisplayPower(void);
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// macPollInterval is how often the macOS screen backend polls the displays,
+// to catch monitors being plugged in and display sleep missed by IOKit.
+const macPollInterval = 2 * time.Second
+
+var (
+	// displayPower is signalled when IOKit reports the displays going to
+	// sleep or waking. It is shared by all macOS screens.
+	displayPower          = make(chan struct{}, 1)
+	watchDisplayPowerOnce sync.Once
+)
+
+//export displayPowerChanged
+func displayPowerChanged() {
+	select {
+	case displayPower <- struct{}{}:
+	default:
+	}
+}
+
+// NewMacScreen returns a [ScreenBackend] for macOS that follows the display
+// with the given EDID manufacturer ID, product code and, if not empty,
+// serial number going to sleep and waking, as reported by CoreGraphics. The
+// display power notifications of IOKit's display wrangler have it checked
+// straight away, and it is polled for monitors being plugged in and out.
+// Blanking puts the displays to sleep with `pmset displaysleepnow` and
+// unblanking wakes them with `caffeinate -u`.
+func NewMacScreen(manufacturer string, productCode uint16, serial string) (ScreenBackend, error) {
+	vendor, err := edidManufacturerCode(manufacturer)
+	if err != nil {
+		return nil, err
+	}
+	var serialNum uint64
+	matchSerial := serial != ""
+	if matchSerial {
+		if serialNum, err = strconv.ParseUint(serial, 10, 32); err != nil {
+			return nil, fmt.Errorf("%w: EDID serial %q must be a number on macOS", ErrUsage, serial)
+		}
+	}
+	query := func() (bool, bool, error) {
+		var present C.int
+		asleep := C.displayState(C.uint32_t(vendor), C.uint32_t(productCode), C.uint32_t(serialNum), boolToCInt(matchSerial), &present)
+		if asleep < 0 {
+			return false, false, errors.New("could not list displays")
+		}
+		return asleep == 1, present == 1, nil
+	}
+	watchDisplayPowerOnce.Do(func() {
+		go func() {
+			// The notifications are delivered on the run loop of
+			// the thread that registered for them.
+			runtime.LockOSThread()
+			if C.watchDisplayPower() != 0 {
+				warnf("could not watch display power notifications; polling every %v", macPollInterval)
+			}
+		}()
+	})
+	s, err := newPolledScreen(query, macPollInterval, displayPower)
+	if err != nil {
+		return nil, err
+	}
+	s.blank = func() error {
+		if out, err := exec.Command("pmset", "displaysleepnow").CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return nil
+	}
+	s.unblank = func() error {
+		if out, err := exec.Command("caffeinate", "-u", "-t", "1").CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return nil
+	}
+	return s, nil
+}
+
+func boolToCInt(b bool) C.int {
+	if b {
+		return 1
+	}
+	return 0
+}
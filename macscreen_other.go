@@ -0,0 +1,12 @@
+//go:build !darwin || !cgo
+
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import "errors"
+
+// NewMacScreen returns an error as the macOS screen backend needs macOS and
+// cgo.
+func NewMacScreen(manufacturer string, productCode uint16, serial string) (ScreenBackend, error) {
+	return nil, errors.New("--screen-backend=macos needs offscreen built for macOS with cgo")
+}
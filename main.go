@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
+	"runtime/debug"
+	"syscall"
+	"time"
 
 	"github.com/alecthomas/kong"
 )
@@ -15,11 +21,18 @@ offscreen turns off/on your Sony Bravia when the screen saver turns on/off
 `
 
 type CLI struct {
-	Version kong.VersionFlag `short:"V" help:"Print program version"`
+	Version     kong.VersionFlag `short:"V" help:"Print program version"`
+	Diagnostics bool             `help:"Write a diagnostics bundle to the temporary directory on panics and fatal errors, to attach to bug reports"`
 
-	Run  RunCmd  `cmd:"" default:"1" help:"Run offscreen"`
-	List ListCmd `cmd:"" help:"List connected monitor IDs"`
-	TV   SonyCmd `cmd:"" help:"query/control TV set"`
+	Run    RunCmd    `cmd:"" default:"1" help:"Run offscreen"`
+	List   ListCmd   `cmd:"" help:"List connected monitor IDs"`
+	EDID   EDIDCmd   `cmd:"" name:"edid" help:"Inspect the EDID of connected monitors"`
+	Wake   WakeCmd   `cmd:"" help:"Deactivate the screen saver and turn the monitor back on"`
+	Locker LockerCmd `cmd:"" help:"Turn the TV off as the screen locker of xss-lock, optionally running another locker"`
+	Idle   IdleCmd   `cmd:"" help:"Print the X idle time, screen saver and DPMS state"`
+	Events EventsCmd `cmd:"" help:"Print screen saver and monitor connection events"`
+	TV     SonyCmd   `cmd:"" help:"query/control TV set"`
+	Demo   DemoCmd   `cmd:"" help:"Play through a scripted day with a fake screen and simulated TV"`
 }
 
 func main() {
@@ -27,16 +40,46 @@ func main() {
 	// more than one kernel thread for it, even on large boxes.
 	runtime.GOMAXPROCS(1)
 
+	// Cancel requests to the TV and shut down cleanly on SIGINT or
+	// SIGTERM. A second signal kills offscreen as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	var cli CLI
-	kctx := kong.Parse(&cli,
+	kctx := kong.Parse(&cli, kongOptions()...)
+	kctx.BindTo(ctx, (*context.Context)(nil))
+	defer func() {
+		if !cli.Diagnostics {
+			return // leave the panic alone, with its stack trace
+		}
+		if r := recover(); r != nil {
+			// Re-panicking loses where the panic came from, so print
+			// the stack first.
+			stack := debug.Stack()
+			writeDiagnostics(kctx, fmt.Sprintf("panic: %v\n\n%s", r, stack))
+			fmt.Fprintf(os.Stderr, "panic: %v\n\n%s\n", r, stack)
+			panic(r)
+		}
+	}()
+	err := kctx.Run(&cli)
+	if err != nil && cli.Diagnostics && !errors.Is(err, ErrUsage) && !errors.Is(err, context.Canceled) {
+		writeDiagnostics(kctx, err.Error())
+	}
+	kctx.FatalIfErrorf(withHint(err))
+}
+
+// kongOptions returns the options for parsing the command line into a [CLI].
+func kongOptions() []kong.Option {
+	return []kong.Option{
 		kong.Description(description),
 		kong.Vars{"version": version},
 		kong.PostBuild(func(k *kong.Kong) error {
 			return kong.Visit(k.Model, setInputDefault)
 		}),
-	)
-	err := kctx.Run(&cli)
-	kctx.FatalIfErrorf(err)
+	}
 }
 
 // setInputDefault is a kong.Visitor that sets the default of any flag named
@@ -60,3 +103,29 @@ func setInputDefault(node kong.Visitable, next kong.Next) error {
 	}
 	return next(nil)
 }
+
+// warnf prints a warning to stderr and records it in the diagnostics event
+// history. It is for errors that should not stop offscreen. Any error
+// arguments are decorated with hints on how to fix them, if known.
+func warnf(format string, args ...any) {
+	for i, arg := range args {
+		if err, ok := arg.(error); ok {
+			args[i] = withHint(err)
+		}
+	}
+	msg := fmt.Sprintf(format, args...)
+	diag.Event("warning: %s", msg)
+	fmt.Fprintln(os.Stderr, "offscreen: warning:", msg)
+}
+
+// sleep waits for d, returning early with ctx's error if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
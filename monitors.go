@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// monitorSpec is a monitor, and the TV input it is connected to, that `run`
+// manages in addition to the one given by --manufacturer, --product-code,
+// --input and --hostname.
+type monitorSpec struct {
+	manufacturer string
+	productCode  uint16
+	serial       string // EDID serial number, or empty for any
+	output       string // output name to match instead of the EDID, if not empty
+	input        string
+	hostname     string
+}
+
+// parseMonitorSpec parses a monitor of the form
+// "MANUFACTURER:PRODUCT-CODE[:EDID-SERIAL]=INPUT@HOSTNAME", e.g.
+// "SNY:63747=HDMI 2@bravia", or "OUTPUT=INPUT@HOSTNAME" to match the monitor
+// by the name of its output. The product code may be given in decimal or as
+// hex with a 0x prefix.
+func parseMonitorSpec(spec string) (monitorSpec, error) {
+	bad := func(reason string) (monitorSpec, error) {
+		return monitorSpec{}, fmt.Errorf("%w: bad monitor %q: %s", ErrUsage, spec, reason)
+	}
+	edid, rest, ok := strings.Cut(spec, "=")
+	if !ok {
+		return bad("expected MANUFACTURER:PRODUCT-CODE[:EDID-SERIAL]=INPUT@HOSTNAME")
+	}
+	i := strings.LastIndex(rest, "@")
+	if i < 0 {
+		return bad("expected MANUFACTURER:PRODUCT-CODE[:EDID-SERIAL]=INPUT@HOSTNAME")
+	}
+	m := monitorSpec{input: rest[:i], hostname: rest[i+1:]}
+	if m.input == "" || m.hostname == "" {
+		return bad("expected INPUT@HOSTNAME after =")
+	}
+	if reason := m.parseMatch(edid); reason != "" {
+		return bad(reason)
+	}
+	return m, nil
+}
+
+// parseRetarget parses the monitor and TV input for `run` to manage instead,
+// read from the --retarget-file, of the form
+// "MANUFACTURER:PRODUCT-CODE[:EDID-SERIAL]=INPUT" or "OUTPUT=INPUT" as for
+// [parseMonitorSpec] without the hostname. The monitor may be left out, as
+// in "=INPUT", to switch only the input, in which case the returned spec
+// has neither a manufacturer nor an output.
+func parseRetarget(spec string) (monitorSpec, error) {
+	bad := func(reason string) (monitorSpec, error) {
+		return monitorSpec{}, fmt.Errorf("%w: bad retarget %q: %s", ErrUsage, spec, reason)
+	}
+	edid, input, ok := strings.Cut(strings.TrimSpace(spec), "=")
+	if !ok || input == "" {
+		return bad("expected [MANUFACTURER:PRODUCT-CODE[:EDID-SERIAL]]=INPUT")
+	}
+	m := monitorSpec{input: input}
+	if edid == "" {
+		return m, nil
+	}
+	if reason := m.parseMatch(edid); reason != "" {
+		return bad(reason)
+	}
+	return m, nil
+}
+
+// parseMatch sets how the monitor is matched from the part of a monitor spec
+// before the "=", returning why it is bad if it is.
+func (m *monitorSpec) parseMatch(edid string) string {
+	mfr, product, ok := strings.Cut(edid, ":")
+	if !ok {
+		if edid == "" {
+			return "expected MANUFACTURER:PRODUCT-CODE[:EDID-SERIAL] or OUTPUT before ="
+		}
+		m.output = edid
+		return ""
+	}
+	if mfr == "" {
+		return "expected MANUFACTURER:PRODUCT-CODE[:EDID-SERIAL] before ="
+	}
+	product, m.serial, _ = strings.Cut(product, ":")
+	code, err := strconv.ParseUint(product, 0, 16)
+	if err != nil {
+		return "bad product code: " + err.Error()
+	}
+	m.manufacturer, m.productCode = mfr, uint16(code)
+	return ""
+}
+
+// String returns the monitor's output name, or its manufacturer ID, product
+// code and serial number, to tell monitors apart in messages.
+func (m monitorSpec) String() string {
+	if m.output != "" {
+		return m.output
+	}
+	if m.serial != "" {
+		return fmt.Sprintf("%s:%d:%s", m.manufacturer, m.productCode, m.serial)
+	}
+	return fmt.Sprintf("%s:%d", m.manufacturer, m.productCode)
+}
+
+// edidManufacturerCode returns the manufacturer ID as encoded in an EDID
+// block, three letters A-Z packed five bits each into a big-endian uint16,
+// e.g. 0x4dd9 for "SNY". This is the vendor number reported for displays by
+// macOS.
+func edidManufacturerCode(mfr string) (uint16, error) {
+	if len(mfr) != 3 {
+		return 0, fmt.Errorf("%w: bad manufacturer ID %q: expected three letters", ErrUsage, mfr)
+	}
+	var code uint16
+	for _, c := range []byte(strings.ToUpper(mfr)) {
+		if c < 'A' || c > 'Z' {
+			return 0, fmt.Errorf("%w: bad manufacturer ID %q: expected three letters", ErrUsage, mfr)
+		}
+		code = code<<5 | uint16(c-'A'+1)
+	}
+	return code, nil
+}
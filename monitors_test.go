@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseMonitorSpec(t *testing.T) {
+	is := is.New(t)
+	m, err := parseMonitorSpec("SNY:63747=HDMI 2@bravia2")
+	is.NoErr(err)
+	is.Equal(monitorSpec{manufacturer: "SNY", productCode: 63747, input: "HDMI 2", hostname: "bravia2"}, m)
+
+	m, err = parseMonitorSpec("SNY:63747:7001234=HDMI 2@bravia2")
+	is.NoErr(err)
+	is.Equal(monitorSpec{manufacturer: "SNY", productCode: 63747, serial: "7001234", input: "HDMI 2", hostname: "bravia2"}, m)
+
+	m, err = parseMonitorSpec("HDMI-A-2=HDMI 1@bravia2")
+	is.NoErr(err)
+	is.Equal(monitorSpec{output: "HDMI-A-2", input: "HDMI 1", hostname: "bravia2"}, m)
+
+	m, err = parseMonitorSpec("GSM:0x5b09=extInput:hdmi?port=1@https://tv@example")
+	is.NoErr(err)
+	is.Equal(monitorSpec{manufacturer: "GSM", productCode: 0x5b09, input: "extInput:hdmi?port=1@https://tv", hostname: "example"}, m)
+}
+
+func TestParseMonitorSpecErrors(t *testing.T) {
+	for _, spec := range []string{"SNY:63747", "SNY:63747=HDMI 2", "=HDMI 2@tv", ":1=HDMI 2@tv", "SNY:big=HDMI 2@tv", "SNY:70000=HDMI 2@tv", "SNY:1=@tv", "SNY:1=HDMI 2@"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := parseMonitorSpec(spec)
+			is.New(t).True(errors.Is(err, ErrUsage))
+		})
+	}
+}
+
+func TestEDIDManufacturerCode(t *testing.T) {
+	is := is.New(t)
+	code, err := edidManufacturerCode("SNY")
+	is.NoErr(err)
+	is.Equal(code, uint16(0x4dd9))
+	code, err = edidManufacturerCode("gsm")
+	is.NoErr(err)
+	is.Equal(code, uint16(0x1e6d))
+	for _, mfr := range []string{"", "SN", "SNYY", "S1Y"} {
+		_, err := edidManufacturerCode(mfr)
+		is.True(errors.Is(err, ErrUsage))
+	}
+}
+
+func TestParseRetarget(t *testing.T) {
+	is := is.New(t)
+	m, err := parseRetarget("SNY:63747:7001234=HDMI 2\n")
+	is.NoErr(err)
+	is.Equal(monitorSpec{manufacturer: "SNY", productCode: 63747, serial: "7001234", input: "HDMI 2"}, m)
+
+	m, err = parseRetarget("HDMI-A-2=HDMI 1")
+	is.NoErr(err)
+	is.Equal(monitorSpec{output: "HDMI-A-2", input: "HDMI 1"}, m)
+
+	m, err = parseRetarget("=HDMI 3")
+	is.NoErr(err)
+	is.Equal(monitorSpec{input: "HDMI 3"}, m)
+
+	for _, spec := range []string{"", "SNY:63747", "SNY:63747=", ":1=HDMI 2", "SNY:big=HDMI 2"} {
+		_, err := parseRetarget(spec)
+		is.True(errors.Is(err, ErrUsage))
+	}
+}
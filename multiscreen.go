@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// multiScreen is a [ScreenBackend] that watches several screens at once,
+// such as the X displays of the seats of a multi-seat machine, and passes
+// their changes to a single watcher. The monitor is present if it is present
+// on any of the screens, and the screen saver is on if it is on for all the
+// screens the monitor is present on (or all the screens if it is present on
+// none), with the same rules as [Screen.Watch] for passing changes on.
+type multiScreen struct {
+	screens []ScreenBackend
+
+	mu sync.Mutex // serialises changes
+}
+
+// newMultiScreen returns a multiScreen watching the given screens, which it
+// takes ownership of.
+func newMultiScreen(screens []ScreenBackend) *multiScreen {
+	return &multiScreen{screens: screens}
+}
+
+// Close closes all the screens, causing Watch to return.
+func (ms *multiScreen) Close() {
+	for _, s := range ms.screens {
+		s.Close()
+	}
+}
+
+// IsScreenSaverOn returns whether the screen saver is on for all the screens
+// the monitor is present on, or all the screens if it is present on none.
+func (ms *multiScreen) IsScreenSaverOn() bool {
+	present := ms.IsPresent()
+	for _, s := range ms.screens {
+		if (s.IsPresent() || !present) && !s.IsScreenSaverOn() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPresent returns whether the monitor is present on any of the screens.
+func (ms *multiScreen) IsPresent() bool {
+	for _, s := range ms.screens {
+		if s.IsPresent() {
+			return true
+		}
+	}
+	return false
+}
+
+// Blank forces the screen saver on for all the screens the monitor is
+// present on.
+func (ms *multiScreen) Blank() error {
+	return ms.forPresent(ScreenBackend.Blank)
+}
+
+// Unblank forces the screen saver off for all the screens the monitor is
+// present on.
+func (ms *multiScreen) Unblank() error {
+	return ms.forPresent(ScreenBackend.Unblank)
+}
+
+func (ms *multiScreen) forPresent(fn func(ScreenBackend) error) error {
+	for _, s := range ms.screens {
+		if !s.IsPresent() {
+			continue
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Watch watches all the screens until they are closed or one of them fails,
+// returning the first error.
+func (ms *multiScreen) Watch(watcher ScreenWatcher) error {
+	return ms.WatchContext(context.Background(), watcher)
+}
+
+// WatchContext is like [multiScreen.Watch] but also returns nil once ctx is
+// done.
+func (ms *multiScreen) WatchContext(ctx context.Context, watcher ScreenWatcher) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	mw := ScreenWatcherFunc(func(bool) error { return ms.changed(watcher) })
+	errs := make(chan error, len(ms.screens))
+	for _, s := range ms.screens {
+		s := s
+		go func() { errs <- watchContext(ctx, s, mw) }()
+	}
+	var err error
+	for range ms.screens {
+		if serr := <-errs; serr != nil && err == nil {
+			err = serr
+			cancel() // stop watching the other screens
+		}
+	}
+	return err
+}
+
+// changed is called when one of the screens changes, passing the combined
+// state of the screen saver to the watcher. A screen only calls its watcher
+// while the monitor is present on it, when its screen saver changes or the
+// monitor has just appeared on it, so every call is passed on. The screens
+// do not call their watchers when the monitor goes, so comparing with the
+// previous state would miss the monitor moving to another screen whose
+// screen saver is in the same state.
+func (ms *multiScreen) changed(watcher ScreenWatcher) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if !ms.IsPresent() {
+		return nil
+	}
+	return watcher.SSChange(ms.IsScreenSaverOn())
+}
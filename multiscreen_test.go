@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestMultiScreen(t *testing.T) {
+	is := is.New(t)
+	seat0 := NewFakeScreen(false /* ssOn */, true /* present */)
+	seat1 := NewFakeScreen(false /* ssOn */, false /* present */)
+	ms := newMultiScreen([]ScreenBackend{seat0, seat1})
+	is.True(ms.IsPresent())
+	is.True(!ms.IsScreenSaverOn())
+
+	changes := make(chan bool, 1) // as Send waits for the change
+	done := make(chan error)
+	go func() {
+		done <- ms.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	is.NoErr(seat0.Send(fakeSSOn))
+	is.Equal(true, <-changes)
+	is.NoErr(seat1.Send(fakeSSOff)) // monitor not on seat 1
+	is.NoErr(seat0.Send(fakeSSOff))
+	is.Equal(false, <-changes)
+
+	// The monitor is moved to seat 1, whose screen saver is on.
+	is.NoErr(seat0.Send(fakeAbsent))
+	is.NoErr(seat1.Send(fakeSSOn))
+	is.NoErr(seat1.Send(fakePresent))
+	is.Equal(true, <-changes)
+	is.True(ms.IsScreenSaverOn())
+
+	// The monitor is moved back to seat 0, whose screen saver is on too,
+	// so the TV is brought in line as when plugged into a single screen.
+	is.NoErr(seat1.Send(fakeAbsent))
+	is.NoErr(seat0.Send(fakeSSOn))
+	is.NoErr(seat0.Send(fakePresent))
+	is.Equal(true, <-changes)
+
+	ms.Close()
+	is.NoErr(<-done)
+}
@@ -0,0 +1,221 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anoopengineer/edidparser/edid"
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/randr"
+	"github.com/jezek/xgb/xproto"
+)
+
+// savedCRTCProperty is the prefix of the properties of the root window
+// holding the configuration of the CRTC that [Screen.Blank] disabled to turn
+// off a monitor's output, so it can be restored by [Screen.Unblank], possibly
+// in another process such as `offscreen wake`. The name of the output
+// follows the prefix, so screens managing different monitors of a display
+// each keep their own.
+const savedCRTCProperty = "_OFFSCREEN_SAVED_CRTC_"
+
+// savedCRTC is the configuration of a CRTC saved when turning off an output.
+type savedCRTC struct {
+	crtc     randr.Crtc
+	x, y     int16
+	mode     randr.Mode
+	rotation uint16
+	outputs  []randr.Output
+}
+
+// encode returns the configuration as the values of a property.
+func (c savedCRTC) encode() []uint32 {
+	v := []uint32{uint32(c.crtc), uint32(uint16(c.x)), uint32(uint16(c.y)), uint32(c.mode), uint32(c.rotation)}
+	for _, o := range c.outputs {
+		v = append(v, uint32(o))
+	}
+	return v
+}
+
+// decodeSavedCRTC returns the configuration encoded in the values of a
+// property by [savedCRTC.encode], or false if there is none.
+func decodeSavedCRTC(v []uint32) (savedCRTC, bool) {
+	if len(v) < 5 {
+		return savedCRTC{}, false
+	}
+	c := savedCRTC{
+		crtc:     randr.Crtc(v[0]),
+		x:        int16(v[1]),
+		y:        int16(v[2]),
+		mode:     randr.Mode(v[3]),
+		rotation: uint16(v[4]),
+		outputs:  make([]randr.Output, 0, len(v)-5),
+	}
+	for _, o := range v[5:] {
+		c.outputs = append(c.outputs, randr.Output(o))
+	}
+	return c, true
+}
+
+// BlankOutput makes [Screen.Blank] turn off only the monitor's RANDR output,
+// by disabling its CRTC, rather than blanking the whole X screen, for desks
+// where only the TV should sleep. The desktop is laid out again without the
+// output until [Screen.Unblank] restores it.
+func (s *Screen) BlankOutput() {
+	s.blankOutput = true
+}
+
+// disableOutput disables the CRTC driving the monitor's output, saving its
+// configuration in a property of the root window.
+func (s *Screen) disableOutput() error {
+	output, err := s.findOutput()
+	if err != nil {
+		return err
+	}
+	r, err := randr.GetScreenResourcesCurrent(s.xconn, s.rootWin).Reply()
+	if err != nil {
+		return fmt.Errorf("could not get screens: %w", err)
+	}
+	oi, err := randr.GetOutputInfo(s.xconn, output, r.ConfigTimestamp).Reply()
+	if err != nil {
+		return fmt.Errorf("could not get info for output: %w", err)
+	}
+	if oi.Crtc == 0 {
+		diag.Event("output %s is already off", oi.Name)
+		return nil
+	}
+	ci, err := randr.GetCrtcInfo(s.xconn, oi.Crtc, r.ConfigTimestamp).Reply()
+	if err != nil {
+		return fmt.Errorf("could not get info for CRTC: %w", err)
+	}
+	saved := savedCRTC{crtc: oi.Crtc, x: ci.X, y: ci.Y, mode: ci.Mode, rotation: ci.Rotation, outputs: ci.Outputs}
+	if err := s.setSavedCRTC(string(oi.Name), saved); err != nil {
+		return err
+	}
+	diag.Event("turning output %s off", oi.Name)
+	return s.setCRTCConfig(oi.Crtc, 0, 0, 0, randr.RotationRotate0, nil)
+}
+
+// enableOutput restores the CRTC configuration of the monitor's output saved
+// by disableOutput, if any. The saved configuration is only deleted once it
+// is restored, so a failed restore can be tried again.
+func (s *Screen) enableOutput() error {
+	output, err := s.findOutput()
+	if err != nil {
+		return err
+	}
+	r, err := randr.GetScreenResourcesCurrent(s.xconn, s.rootWin).Reply()
+	if err != nil {
+		return fmt.Errorf("could not get screens: %w", err)
+	}
+	oi, err := randr.GetOutputInfo(s.xconn, output, r.ConfigTimestamp).Reply()
+	if err != nil {
+		return fmt.Errorf("could not get info for output: %w", err)
+	}
+	atom, err := s.savedCRTCAtom(string(oi.Name))
+	if err != nil {
+		return err
+	}
+	prop, err := xproto.GetProperty(s.xconn, false /* Delete */, s.rootWin, atom, xproto.AtomCardinal, 0, 64).Reply()
+	if err != nil {
+		return fmt.Errorf("could not get saved CRTC configuration: %w", err)
+	}
+	if prop.Format != 32 {
+		return nil // nothing saved
+	}
+	v := make([]uint32, prop.ValueLen)
+	for i := range v {
+		v[i] = xgb.Get32(prop.Value[4*i:])
+	}
+	saved, ok := decodeSavedCRTC(v)
+	if !ok {
+		return nil
+	}
+	diag.Event("turning output %s back on", oi.Name)
+	if err := s.setCRTCConfig(saved.crtc, saved.x, saved.y, saved.mode, saved.rotation, saved.outputs); err != nil {
+		return err
+	}
+	if err := xproto.DeletePropertyChecked(s.xconn, s.rootWin, atom).Check(); err != nil {
+		return fmt.Errorf("could not delete saved CRTC configuration: %w", err)
+	}
+	return nil
+}
+
+// setSavedCRTC saves the configuration of the CRTC driving the named output.
+func (s *Screen) setSavedCRTC(output string, saved savedCRTC) error {
+	atom, err := s.savedCRTCAtom(output)
+	if err != nil {
+		return err
+	}
+	v := saved.encode()
+	data := make([]byte, 4*len(v))
+	for i, x := range v {
+		xgb.Put32(data[4*i:], x)
+	}
+	err = xproto.ChangePropertyChecked(s.xconn, xproto.PropModeReplace, s.rootWin, atom, xproto.AtomCardinal, 32, uint32(len(v)), data).Check()
+	if err != nil {
+		return fmt.Errorf("could not save CRTC configuration: %w", err)
+	}
+	return nil
+}
+
+// savedCRTCAtom returns the atom of the property holding the saved CRTC
+// configuration of the named output.
+func (s *Screen) savedCRTCAtom(output string) (xproto.Atom, error) {
+	name := savedCRTCProperty + output
+	atom, err := xproto.InternAtom(s.xconn, false /* OnlyIfExists */, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, fmt.Errorf("could not intern X11 atom: %w", err)
+	}
+	return atom.Atom, nil
+}
+
+func (s *Screen) setCRTCConfig(crtc randr.Crtc, x, y int16, mode randr.Mode, rotation uint16, outputs []randr.Output) error {
+	r, err := randr.GetScreenResourcesCurrent(s.xconn, s.rootWin).Reply()
+	if err != nil {
+		return fmt.Errorf("could not get screens: %w", err)
+	}
+	reply, err := randr.SetCrtcConfig(s.xconn, crtc, r.Timestamp, r.ConfigTimestamp, x, y, mode, rotation, outputs).Reply()
+	if err != nil {
+		return fmt.Errorf("could not configure CRTC: %w", err)
+	}
+	if reply.Status != randr.SetConfigSuccess {
+		return fmt.Errorf("could not configure CRTC: status %d", reply.Status)
+	}
+	return nil
+}
+
+// findOutput returns the RANDR output of the screen's monitor.
+func (s *Screen) findOutput() (randr.Output, error) {
+	if s.output != "" {
+		r, err := randr.GetScreenResourcesCurrent(s.xconn, s.rootWin).Reply()
+		if err != nil {
+			return 0, fmt.Errorf("could not get screens: %w", err)
+		}
+		for _, output := range r.Outputs {
+			oi, err := randr.GetOutputInfo(s.xconn, output, r.ConfigTimestamp).Reply()
+			if err != nil {
+				return 0, fmt.Errorf("could not get info for output: %w", err)
+			}
+			if string(oi.Name) == s.output {
+				return output, nil
+			}
+		}
+		return 0, fmt.Errorf("no output %s", s.output)
+	}
+	var found randr.Output
+	err := RangeEDID(s.xconn, s.rootWin, func(output randr.Output, e *edid.Edid) (bool, error) {
+		if e.ManufacturerId == s.manufacturerID && e.ProductCode == s.productCode && edidSerialMatches(e, s.serial) {
+			found = output
+			return false /* stop ranging */, nil
+		}
+		return true /* keep ranging */, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if found == 0 {
+		return 0, errors.New("monitor not connected")
+	}
+	return found, nil
+}
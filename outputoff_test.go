@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jezek/xgb/randr"
+	"github.com/matryer/is"
+)
+
+func TestSavedCRTC(t *testing.T) {
+	is := is.New(t)
+	saved := savedCRTC{crtc: 63, x: -1920, y: 0, mode: 70, rotation: randr.RotationRotate90, outputs: []randr.Output{66, 67}}
+	v := saved.encode()
+	is.Equal(v, []uint32{63, 0xf880, 0, 70, randr.RotationRotate90, 66, 67})
+	got, ok := decodeSavedCRTC(v)
+	is.True(ok)
+	is.Equal(got, saved)
+
+	_, ok = decodeSavedCRTC(nil)
+	is.True(!ok) // nothing saved
+	_, ok = decodeSavedCRTC(v[:4])
+	is.True(!ok) // truncated
+}
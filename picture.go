@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"foxygo.at/offscreen/pkg/bravia"
+)
+
+// powerSavingCache caches the power saving mode of TVs from before `sony
+// picture off` turned their picture off, keyed by hostname, so `sony picture
+// on` can restore it.
+var powerSavingCache = hostCache{what: "power saving cache", file: "powersaving.json", dir: os.UserCacheDir}
+
+// setPicture turns the picture of the TV at hostname off, leaving audio and
+// apps running, or back on. As the picture is turned off with the
+// "pictureOff" power saving mode, the mode it replaces is cached to be
+// restored when the picture is turned back on.
+func setPicture(ctx context.Context, c *bravia.RESTClient, hostname string, on bool) error {
+	mode, err := c.PowerSavingMode(ctx)
+	if err != nil {
+		return err
+	}
+	if on {
+		if mode != "pictureOff" {
+			return nil // already on, so leave power saving alone
+		}
+		mode = powerSavingCache.get(hostname)
+		if mode == "" {
+			mode = "off"
+		}
+		if err := c.SetPowerSavingMode(ctx, mode); err != nil {
+			return err
+		}
+		return powerSavingCache.set(hostname, "")
+	}
+	if mode != "pictureOff" {
+		if err := powerSavingCache.set(hostname, mode); err != nil {
+			return err
+		}
+	}
+	return c.SetPictureMute(ctx, true)
+}
+
+// parsePictureSettings parses picture settings of the form
+// "target=value,target=value", e.g. "brightness=10,colorTemperature=warm2".
+func parsePictureSettings(spec string) ([]bravia.PictureSetting, error) {
+	return parseSettings[bravia.PictureSetting]("picture", spec)
+}
+
+// pictureSchedule is a list of picture settings to apply at different times
+// of the day, sorted by the time of day they start. Each entry applies from
+// its start until the start of the next entry, with the last entry wrapping
+// around past midnight until the start of the first.
+type pictureSchedule []scheduledPicture
+
+type scheduledPicture struct {
+	start    time.Duration // since midnight
+	settings []bravia.PictureSetting
+}
+
+// parsePictureSchedule parses schedule entries of the form
+// "HH:MM target=value,target=value", e.g.
+// "21:00 brightness=10,colorTemperature=warm2".
+func parsePictureSchedule(specs []string) (pictureSchedule, error) {
+	ps := make(pictureSchedule, 0, len(specs))
+	for _, spec := range specs {
+		at, settings, ok := strings.Cut(strings.TrimSpace(spec), " ")
+		if !ok {
+			return nil, fmt.Errorf("%w: picture schedule %q: expected \"HH:MM target=value,...\"", ErrUsage, spec)
+		}
+		t, err := time.Parse("15:04", at)
+		if err != nil {
+			return nil, fmt.Errorf("%w: picture schedule %q: bad time: %v", ErrUsage, spec, err)
+		}
+		sp := scheduledPicture{start: time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute}
+		if sp.settings, err = parsePictureSettings(settings); err != nil {
+			return nil, fmt.Errorf("picture schedule %q: %w", spec, err)
+		}
+		ps = append(ps, sp)
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].start < ps[j].start })
+	return ps, nil
+}
+
+// at returns the picture settings that apply at the time of day of t, or
+// nil if the schedule is empty.
+func (ps pictureSchedule) at(t time.Time) []bravia.PictureSetting {
+	if len(ps) == 0 {
+		return nil
+	}
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	current := ps[len(ps)-1] // wraps around from the previous day
+	for _, sp := range ps {
+		if sp.start > sinceMidnight {
+			break
+		}
+		current = sp
+	}
+	return current.settings
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"foxygo.at/offscreen/pkg/bravia"
+	"github.com/matryer/is"
+)
+
+func TestPictureSchedule(t *testing.T) {
+	is := is.New(t)
+
+	ps, err := parsePictureSchedule([]string{
+		"21:00 brightness=10,colorTemperature=warm2",
+		"07:30 brightness=30,colorTemperature=neutral",
+	})
+	is.NoErr(err) // failed to parse schedule
+
+	day := []bravia.PictureSetting{{Target: "brightness", Value: "30"}, {Target: "colorTemperature", Value: "neutral"}}
+	night := []bravia.PictureSetting{{Target: "brightness", Value: "10"}, {Target: "colorTemperature", Value: "warm2"}}
+	tests := map[string][]bravia.PictureSetting{
+		"00:00": night,
+		"07:29": night,
+		"07:30": day,
+		"12:00": day,
+		"20:59": day,
+		"21:00": night,
+		"23:59": night,
+	}
+	for at, want := range tests {
+		tm, err := time.Parse("15:04", at)
+		is.NoErr(err)
+		is.Equal(want, ps.at(tm)) // wrong settings for time
+	}
+}
+
+func TestPictureScheduleErrors(t *testing.T) {
+	for _, spec := range []string{"21:00", "9pm brightness=10", "21:00 brightness", "21:00 brightness=10,"} {
+		t.Run(spec, func(t *testing.T) {
+			is := is.New(t)
+			_, err := parsePictureSchedule([]string{spec})
+			is.True(errors.Is(err, ErrUsage)) // expected usage error
+		})
+	}
+}
+
+func TestPictureScheduleEmpty(t *testing.T) {
+	is := is.New(t)
+	var ps pictureSchedule
+	is.Equal(nil, ps.at(time.Now())) // empty schedule should have no settings
+}
+
+func TestPictureOffOn(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	sim := newBraviaSim(t.Logf)
+	sim.saving = "low"
+	hostname, stop, err := sim.Start()
+	is.NoErr(err)
+	t.Cleanup(stop)
+	c := bravia.NewRESTClient(hostname, "")
+
+	is.NoErr(setPicture(ctx, c, hostname, false))
+	is.Equal("pictureOff", sim.saving)
+	is.NoErr(setPicture(ctx, c, hostname, false))
+	is.NoErr(setPicture(ctx, c, hostname, true))
+	is.Equal("low", sim.saving) // power saving mode not restored
+	is.NoErr(setPicture(ctx, c, hostname, true))
+	is.Equal("low", sim.saving) // power saving changed with picture already on
+
+	// Turned off some other way, so there is no saved mode.
+	is.NoErr(c.SetPictureMute(ctx, true))
+	is.NoErr(setPicture(ctx, c, hostname, true))
+	is.Equal("off", sim.saving) // power saving not turned off without a saved mode
+}
@@ -0,0 +1,150 @@
+package bravia
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// versionRetryInterval is how long to use the base versions after failing to
+// query the versions the TV supports, before querying again.
+const versionRetryInterval = time.Minute
+
+// compatibleVersions lists newer API versions of methods that accept the
+// same parameters as the version this package is written against and return a
+// superset of the same result, keyed by "service.method". If the TV supports
+// one of these, the highest is used in preference to the base version so
+// that newer firmware can return its richer payloads.
+var compatibleVersions = map[string][]string{
+	"avContent.getCurrentExternalInputsStatus": {"1.1"},
+	"audio.setAudioVolume":                     {"1.1", "1.2"},
+	"system.getSystemInformation":              {"1.7"},
+}
+
+// ServiceAPIInfo describes the API methods and versions a TV supports for a
+// service.
+type ServiceAPIInfo struct {
+	Service   string   `json:"service"`
+	Protocols []string `json:"protocols"`
+	APIs      []struct {
+		Name     string `json:"name"`
+		Versions []struct {
+			Version   string   `json:"version"`
+			Protocols []string `json:"protocols,omitempty"`
+		} `json:"versions"`
+	} `json:"apis"`
+}
+
+// SupportedAPIInfo returns the services, methods and versions the TV
+// supports. If services is empty, all services are returned.
+func (c *RESTClient) SupportedAPIInfo(ctx context.Context, services ...string) ([]ServiceAPIInfo, error) {
+	if services == nil {
+		services = []string{}
+	}
+	param := map[string][]string{"services": services}
+	info, err := post[[]ServiceAPIInfo](ctx, c, "guide", "getSupportedApiInfo", "1.0", param)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	return *info, nil
+}
+
+// apiVersion returns the API version to use for a method that the client
+// implements using the given base version. The first time it is called, the
+// versions supported by the TV are queried. If that fails, the base version
+// is used and the query made again after versionRetryInterval, as the TV may
+// just have been unreachable.
+func (c *RESTClient) apiVersion(ctx context.Context, service, method, version string) string {
+	// The guide service is used to negotiate versions, so its version
+	// is never negotiated.
+	if service == "guide" {
+		return version
+	}
+	c.versionsMu.Lock()
+	defer c.versionsMu.Unlock()
+	versions := c.versions
+	if versions == nil && time.Since(c.versionsFailed) >= versionRetryInterval {
+		var ok bool
+		versions, ok = c.negotiateVersions(ctx)
+		if ok {
+			c.versions = versions
+		} else if ctx.Err() == nil {
+			// Negotiate again next time if cancelled part way,
+			// and after a while otherwise.
+			c.versionsFailed = time.Now()
+		}
+	}
+	if v, ok := versions[service+"."+method]; ok {
+		return v
+	}
+	return version
+}
+
+// negotiateVersions returns the highest compatible version supported by the
+// TV for each method in compatibleVersions. It returns false if the supported
+// versions could not be queried.
+func (c *RESTClient) negotiateVersions(ctx context.Context) (map[string]string, bool) {
+	versions := map[string]string{}
+	infos, err := c.SupportedAPIInfo(ctx)
+	if err != nil {
+		diag.Event("could not query supported API versions, using base versions: %v", err)
+		return nil, false
+	}
+	for _, info := range infos {
+		for _, api := range info.APIs {
+			key := info.Service + "." + api.Name
+			for _, v := range api.Versions {
+				if isCompatibleVersion(key, v.Version) && versionLess(versions[key], v.Version) {
+					versions[key] = v.Version
+				}
+			}
+		}
+	}
+	diag.Event("negotiated API versions: %v", versions)
+	return versions, true
+}
+
+// pinVersion stops the negotiated version being used for a method, such as
+// when the TV rejects a version it claimed to support.
+func (c *RESTClient) pinVersion(service, method, version string) {
+	c.versionsMu.Lock()
+	defer c.versionsMu.Unlock()
+	if c.versions != nil {
+		c.versions[service+"."+method] = version
+	}
+}
+
+func isCompatibleVersion(key, version string) bool {
+	for _, v := range compatibleVersions[key] {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// versionLess returns true if API version a is lower than b. Versions are of
+// the form "major.minor". The empty string is lower than any version.
+func versionLess(a, b string) bool {
+	if a == "" {
+		return b != ""
+	}
+	amaj, amin, _ := strings.Cut(a, ".")
+	bmaj, bmin, _ := strings.Cut(b, ".")
+	if amaj != bmaj {
+		return atoi(amaj) < atoi(bmaj)
+	}
+	return atoi(amin) < atoi(bmin)
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// isUnsupportedVersion returns true if err is the Sony error for an
+// unsupported API version.
+func isUnsupportedVersion(err error) bool {
+	return IsSonyCode(err, SonyCodeUnsupportedVersion)
+}
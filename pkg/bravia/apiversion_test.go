@@ -0,0 +1,151 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// supportedAPIs returns a getSupportedApiInfo result for a single service.
+func supportedAPIs(service string, apis map[string][]string) []any {
+	var list []any
+	for name, versions := range apis {
+		var vs []any
+		for _, v := range versions {
+			vs = append(vs, map[string]string{"version": v})
+		}
+		list = append(list, map[string]any{"name": name, "versions": vs})
+	}
+	return []any{map[string]any{"service": service, "apis": list}}
+}
+
+func TestAPIVersionNegotiation(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	tv, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		switch method {
+		case "getSupportedApiInfo":
+			return supportedAPIs("avContent", map[string][]string{
+				"getCurrentExternalInputsStatus": {"1.0", "1.1", "9.9"},
+				"getPlayingContentInfo":          {"1.0"},
+			}), nil
+		case "getCurrentExternalInputsStatus":
+			return []map[string]string{{"uri": "extInput:hdmi?port=1", "title": "HDMI 1"}}, nil
+		case "getPlayingContentInfo":
+			return map[string]string{"uri": "extInput:hdmi?port=1"}, nil
+		}
+		return nil, []any{12, "No Such Method"}
+	})
+
+	_, err := c.Inputs(ctx)
+	is.NoErr(err)                                                  // Inputs failed
+	is.Equal("1.1", tv.versions["getCurrentExternalInputsStatus"]) // highest compatible version not used
+	_, err = c.SelectedInput(ctx)
+	is.NoErr(err)                                         // SelectedInput failed
+	is.Equal("1.0", tv.versions["getPlayingContentInfo"]) // base version not used
+}
+
+func TestAPIVersionFallback(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var calls int
+	tv, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		switch method {
+		case "getSupportedApiInfo":
+			return nil, []any{12, "No Such Method"}
+		case "getCurrentExternalInputsStatus":
+			calls++
+			return []map[string]string{}, nil
+		}
+		return nil, []any{12, "No Such Method"}
+	})
+
+	_, err := c.Inputs(ctx)
+	is.NoErr(err)                                                  // Inputs failed
+	is.Equal(1, calls)                                             // unexpected number of calls
+	is.Equal("1.0", tv.versions["getCurrentExternalInputsStatus"]) // did not fall back to base version
+}
+
+func TestAPIVersionRetry(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var queries int
+	available := false
+	tv, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		switch method {
+		case "getSupportedApiInfo":
+			queries++
+			if !available {
+				return nil, []any{40005, "Display Is Turned Off"}
+			}
+			return supportedAPIs("avContent", map[string][]string{
+				"getCurrentExternalInputsStatus": {"1.0", "1.1"},
+			}), nil
+		case "getCurrentExternalInputsStatus":
+			return []map[string]string{}, nil
+		}
+		return nil, []any{12, "No Such Method"}
+	})
+
+	_, err := c.Inputs(ctx)
+	is.NoErr(err)
+	is.Equal("1.0", tv.versions["getCurrentExternalInputsStatus"]) // base version not used
+	available = true
+	_, err = c.Inputs(ctx)
+	is.NoErr(err)
+	is.Equal(1, queries) // queried again straight after failing
+
+	c.versionsFailed = c.versionsFailed.Add(-versionRetryInterval) // a while later
+	_, err = c.Inputs(ctx)
+	is.NoErr(err)
+	is.Equal(2, queries)                                           // failure cached
+	is.Equal("1.1", tv.versions["getCurrentExternalInputsStatus"]) // negotiated version not used
+}
+
+func TestAPIVersionRejected(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var tv *fakeTV
+	tv, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		switch method {
+		case "getSupportedApiInfo":
+			return supportedAPIs("avContent", map[string][]string{
+				"getCurrentExternalInputsStatus": {"1.0", "1.1"},
+			}), nil
+		case "getCurrentExternalInputsStatus":
+			if tv.versions[method] != "1.0" {
+				return nil, []any{14, "Unsupported Version"}
+			}
+			return []map[string]string{}, nil
+		}
+		return nil, []any{12, "No Such Method"}
+	})
+
+	_, err := c.Inputs(ctx)
+	is.NoErr(err)                                                                            // Inputs did not fall back to base version
+	is.Equal("1.0", c.apiVersion(ctx, "avContent", "getCurrentExternalInputsStatus", "1.0")) // base version not pinned
+}
+
+func TestSupportedAPIInfoEmpty(t *testing.T) {
+	is := is.New(t)
+	_, c := newFakeTV(t, func(string, []json.RawMessage) (any, []any) { return nil, nil })
+	info, err := c.SupportedAPIInfo(context.Background())
+	is.NoErr(err)
+	is.Equal(0, len(info)) // no APIs from a TV with an empty result
+}
+
+func TestVersionLess(t *testing.T) {
+	is := is.New(t)
+	is.True(versionLess("", "1.0"))
+	is.True(versionLess("1.0", "1.1"))
+	is.True(versionLess("1.9", "1.10"))
+	is.True(versionLess("1.10", "2.0"))
+	is.True(!versionLess("1.1", "1.1"))
+	is.True(!versionLess("1.1", ""))
+}
@@ -0,0 +1,85 @@
+package bravia
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of making a request to the TV while
+// the [CircuitBreaker] is open after repeated failures to reach the TV.
+var ErrCircuitOpen = errors.New("TV unreachable")
+
+// CircuitBreaker short-circuits requests to the TV after it has been
+// unreachable for a number of requests in a row, so callers fail fast
+// instead of each waiting for the HTTP client timeout while the TV is
+// unplugged. Once the cool-down has passed, requests are made again and
+// the next failure re-opens the breaker. The zero value never opens.
+type CircuitBreaker struct {
+	// Threshold is the number of consecutive requests that must fail to
+	// reach the TV for the breaker to open, counting each retry of a
+	// request (see [RetryPolicy]). Zero disables the breaker.
+	Threshold int
+
+	// Cooldown is how long the breaker stays open before requests are
+	// made again.
+	Cooldown time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// allow returns an error wrapping [ErrCircuitOpen] if the breaker is open
+// and nil if a request may be made.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if wait := time.Until(b.openUntil); wait > 0 {
+		return fmt.Errorf("%w after %d failed requests, not retrying for %v", ErrCircuitOpen, b.failures, wait.Round(time.Second))
+	}
+	return nil
+}
+
+// record records the outcome of a request, opening the breaker if it has
+// failed to reach the TV Threshold times in a row. Error responses from
+// the TV show it is reachable, so they reset the count like successes.
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.Threshold <= 0 {
+		return
+	}
+	if !IsUnreachable(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.Threshold {
+		if b.openUntil.IsZero() || time.Now().After(b.openUntil) {
+			diag.Event("TV unreachable after %d failed requests; failing fast for %v", b.failures, b.Cooldown)
+		}
+		b.openUntil = time.Now().Add(b.Cooldown)
+	}
+}
+
+// reset closes the breaker, such as when the TV is expected to become
+// reachable after being woken.
+func (b *CircuitBreaker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+}
+
+// guard calls fn to make a request to the TV unless the client's circuit
+// breaker is open, recording the outcome with the breaker.
+func (c *RESTClient) guard(fn func() error) error {
+	if err := c.Breaker.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	c.Breaker.record(err)
+	return err
+}
@@ -0,0 +1,114 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	tv, _ := newFakeTV(t, func(string, []json.RawMessage) (any, []any) {
+		return map[string]string{"status": "standby"}, nil
+	})
+	// Drop connections while offline, as a TV does when it is unreachable.
+	var offline atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if offline.Load() {
+			if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+				conn.Close()
+			}
+			return
+		}
+		tv.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewRESTClient(strings.TrimPrefix(srv.URL, "http://"), "")
+	c.versions = map[string]string{} // skip version negotiation
+	c.Breaker = CircuitBreaker{Threshold: 2, Cooldown: time.Hour}
+	offline.Store(true)
+
+	_, err := c.PowerStatus(ctx)
+	is.True(IsUnreachable(err) && !errors.Is(err, ErrCircuitOpen)) // first failure should reach the TV
+	_, err = c.PowerStatus(ctx)
+	is.True(IsUnreachable(err) && !errors.Is(err, ErrCircuitOpen)) // second failure should reach the TV
+	_, err = c.PowerStatus(ctx)
+	is.True(errors.Is(err, ErrCircuitOpen)) // breaker not open after threshold
+	is.True(IsUnreachable(err))             // open breaker should look unreachable
+
+	offline.Store(false)
+	_, err = c.PowerStatus(ctx)
+	is.True(errors.Is(err, ErrCircuitOpen)) // breaker closed before cool-down
+
+	c.Breaker.reset()
+	status, err := c.PowerStatus(ctx)
+	is.NoErr(err)
+	is.Equal("standby", status)
+}
+
+func TestCircuitBreakerCountsAttempts(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewRESTClient(strings.TrimPrefix(srv.URL, "http://"), "")
+	c.versions = map[string]string{} // skip version negotiation
+	c.Retry = RetryPolicy{Attempts: 5, Backoff: time.Millisecond}
+	c.Breaker = CircuitBreaker{Threshold: 2, Cooldown: time.Hour}
+
+	_, err := c.PowerStatus(ctx)
+	is.True(errors.Is(err, ErrCircuitOpen)) // breaker should open during the retries
+	is.Equal(int32(2), calls.Load())        // and stop them
+}
+
+func TestCircuitBreakerIgnoresCancel(t *testing.T) {
+	is := is.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		hang(r)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewRESTClient(strings.TrimPrefix(srv.URL, "http://"), "")
+	c.versions = map[string]string{} // skip version negotiation
+	c.Breaker = CircuitBreaker{Threshold: 1, Cooldown: time.Hour}
+
+	_, err := c.PowerStatus(ctx)
+	is.True(errors.Is(err, context.Canceled))
+	is.True(!IsUnreachable(err)) // cancelling should not look unreachable
+	is.NoErr(c.Breaker.allow())  // nor open the breaker
+
+	c.HTTPClient.Timeout = 10 * time.Millisecond
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hang(r)
+	})
+	_, err = c.PowerStatus(context.Background())
+	is.True(IsUnreachable(err)) // a TV not responding is unreachable
+}
+
+// hang waits for the client to go away, as an unresponsive TV does, until
+// the test server's connection is closed or 200ms have passed.
+func hang(r *http.Request) {
+	select {
+	case <-r.Context().Done():
+	case <-time.After(200 * time.Millisecond):
+	}
+}
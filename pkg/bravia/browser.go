@@ -0,0 +1,39 @@
+package bravia
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// OpenURL opens url in the TV's web browser. TVs without the browser
+// service, such as most Android TV models, open it in the web app runtime
+// instead.
+func (c *RESTClient) OpenURL(ctx context.Context, u string) error {
+	ctx = withRetryable(ctx, neverRetry) // a retry could open the URL twice
+	err := c.browserOpenURL(ctx, u)
+	if err == nil || !(IsUnsupported(err) || isUnknownService(err)) {
+		return err
+	}
+	diag.Event("browser service unsupported, opening URL in web app runtime: %v", err)
+	return c.SetActiveApp(ctx, "localapp://webappruntime?url="+url.QueryEscape(u))
+}
+
+// browserOpenURL opens url with the browser service.
+func (c *RESTClient) browserOpenURL(ctx context.Context, u string) error {
+	param := map[string]string{"control": "start"}
+	if _, err := post[empty](ctx, c, "browser", "actBrowserControl", "1.0", param); err != nil {
+		return err
+	}
+	param = map[string]string{"url": u}
+	_, err := post[empty](ctx, c, "browser", "setTextUrl", "1.0", param)
+	return err
+}
+
+// isUnknownService returns true if err is an error for a service the TV does
+// not have, which TVs return either as an HTTP status or a Sony error.
+func isUnknownService(err error) bool {
+	var herr HTTPStatusError
+	return IsSonyCode(err, SonyCodeNotFound) || (errors.As(err, &herr) && herr == http.StatusNotFound)
+}
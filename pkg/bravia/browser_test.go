@@ -0,0 +1,34 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestOpenURLFallback(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var launched string
+	_, c := newFakeTV(t, func(method string, params []json.RawMessage) (any, []any) {
+		switch method {
+		case "actBrowserControl":
+			return nil, []any{404, "Not Found"}
+		case "setActiveApp":
+			var p struct {
+				URI string `json:"uri"`
+			}
+			_ = json.Unmarshal(params[0], &p)
+			launched = p.URI
+			return nil, nil
+		}
+		return nil, []any{12, "No Such Method"}
+	})
+
+	err := c.OpenURL(ctx, "https://example.com/a?b=c")
+	is.NoErr(err) // OpenURL failed
+	is.Equal("localapp://webappruntime?url=https%3A%2F%2Fexample.com%2Fa%3Fb%3Dc", launched)
+}
@@ -0,0 +1,40 @@
+package bravia
+
+import "context"
+
+// The REST IP control protocol only has setters for the HDMI-CEC settings of
+// the TV, not getters, so they can be changed but not read back.
+
+// SetCECControl turns HDMI-CEC control of and by connected devices on or
+// off. Turning it off stops other devices turning the TV on or changing its
+// input, and the TV turning them off.
+func (c *RESTClient) SetCECControl(ctx context.Context, enabled bool) error {
+	param := map[string]bool{"enabled": enabled}
+	_, err := post[empty](ctx, c, "cec", "setCecControlMode", "1.0", param)
+	return err
+}
+
+// SetCECPowerSync sets whether devices connected by HDMI-CEC are turned off
+// when the TV is turned off (sinkPowerOffSync), and whether the TV is turned
+// on when a connected device is turned on (sourcePowerOnSync). A nil value
+// leaves that setting unchanged.
+func (c *RESTClient) SetCECPowerSync(ctx context.Context, sinkPowerOffSync, sourcePowerOnSync *bool) error {
+	param := map[string]bool{}
+	if sinkPowerOffSync != nil {
+		param["sinkPowerOffSync"] = *sinkPowerOffSync
+	}
+	if sourcePowerOnSync != nil {
+		param["sourcePowerOnSync"] = *sourcePowerOnSync
+	}
+	_, err := post[empty](ctx, c, "cec", "setPowerSyncMode", "1.0", param)
+	return err
+}
+
+// SetMHLAutoInput sets whether the TV switches to the input of a device
+// connected by MHL (Mobile High-Definition Link), such as a phone, when the
+// device is connected. The TV has no such setting for HDMI-CEC devices.
+func (c *RESTClient) SetMHLAutoInput(ctx context.Context, enabled bool) error {
+	param := map[string]bool{"enabled": enabled}
+	_, err := post[empty](ctx, c, "cec", "setMhlAutoInputChangeMode", "1.0", param)
+	return err
+}
@@ -0,0 +1,99 @@
+package bravia
+
+import (
+	"context"
+	"fmt"
+)
+
+// listPageSize is how many items are requested at a time when listing
+// channels or recordings. The TV limits how many can be returned in one
+// response.
+const listPageSize = 50
+
+// Channel is a broadcast channel of one of the TV's tuners.
+type Channel struct {
+	URI              string `json:"uri"`
+	Title            string `json:"title"`
+	Index            int    `json:"index"`
+	DispNum          string `json:"dispNum"`
+	ProgramMediaType string `json:"programMediaType"`
+}
+
+// TunerSources returns the URIs of the TV's tuner sources, such as "tv:dvbt"
+// and "tv:dvbc".
+func (c *RESTClient) TunerSources(ctx context.Context) ([]string, error) {
+	type sourceResponse struct {
+		Source string `json:"source"`
+	}
+	param := map[string]string{"scheme": "tv"}
+	sources, err := post[[]sourceResponse](ctx, c, "avContent", "getSourceList", "1.0", param)
+	if err != nil {
+		return nil, err
+	}
+	if sources == nil {
+		return nil, noResult("getSourceList")
+	}
+	result := make([]string, 0, len(*sources))
+	for _, s := range *sources {
+		result = append(result, s.Source)
+	}
+	return result, nil
+}
+
+// ChannelCount returns the number of channels of a tuner source, such as
+// "tv:dvbt".
+func (c *RESTClient) ChannelCount(ctx context.Context, source string) (int, error) {
+	type countResponse struct {
+		Count int `json:"count"`
+	}
+	param := map[string]string{"uri": source}
+	count, err := post[countResponse](ctx, c, "avContent", "getContentCount", "1.1", param)
+	if err != nil {
+		return 0, err
+	}
+	if count == nil {
+		return 0, noResult("getContentCount")
+	}
+	return count.Count, nil
+}
+
+// Channels returns all the channels of a tuner source, such as "tv:dvbt".
+func (c *RESTClient) Channels(ctx context.Context, source string) ([]Channel, error) {
+	count, err := c.ChannelCount(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	channels := make([]Channel, 0, count)
+	for len(channels) < count {
+		param := map[string]any{"uri": source, "stIdx": len(channels), "cnt": listPageSize}
+		page, err := post[[]Channel](ctx, c, "avContent", "getContentList", "1.5", param)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil || len(*page) == 0 {
+			break
+		}
+		channels = append(channels, *page...)
+	}
+	return channels, nil
+}
+
+// TunerChannels returns the channels of the given tuner sources, or of all
+// the TV's tuner sources if none are given.
+func (c *RESTClient) TunerChannels(ctx context.Context, sources ...string) ([]Channel, error) {
+	if len(sources) == 0 {
+		var err error
+		if sources, err = c.TunerSources(ctx); err != nil {
+			return nil, fmt.Errorf("tuner sources: %w", err)
+		}
+	}
+	var channels []Channel
+	for _, source := range sources {
+		cs, err := c.Channels(ctx, source)
+		if err != nil {
+			return nil, fmt.Errorf("channels of %s: %w", source, err)
+		}
+		channels = append(channels, cs...)
+	}
+	return channels, nil
+}
@@ -0,0 +1,49 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestChannelsPaging(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	const count = listPageSize*2 + 3
+	_, c := newFakeTV(t, func(method string, params []json.RawMessage) (any, []any) {
+		switch method {
+		case "getContentCount":
+			var p struct {
+				URI string `json:"uri"`
+			}
+			if err := json.Unmarshal(params[0], &p); err != nil || p.URI != "tv:dvbt" {
+				return nil, []any{3, "Illegal Argument"}
+			}
+			return map[string]int{"count": count}, nil
+		case "getContentList":
+			var p struct {
+				StIdx int `json:"stIdx"`
+				Cnt   int `json:"cnt"`
+			}
+			if err := json.Unmarshal(params[0], &p); err != nil {
+				return nil, []any{3, "Illegal Argument"}
+			}
+			var page []Channel
+			for i := p.StIdx; i < count && i < p.StIdx+p.Cnt; i++ {
+				page = append(page, Channel{URI: fmt.Sprintf("tv:dvbt?trip=%d", i), Index: i, DispNum: fmt.Sprint(i + 1)})
+			}
+			return page, nil
+		}
+		return nil, []any{12, "No Such Method"}
+	})
+
+	channels, err := c.Channels(ctx, "tv:dvbt")
+	is.NoErr(err)                              // Channels failed
+	is.Equal(count, len(channels))             // wrong number of channels
+	is.Equal("1", channels[0].DispNum)         // wrong first channel
+	is.Equal(count-1, channels[count-1].Index) // wrong last channel
+}
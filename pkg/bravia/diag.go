@@ -0,0 +1,32 @@
+package bravia
+
+// Diagnostics records what the clients do, for troubleshooting. Event is
+// called with events such as retries and fallbacks, TVResponse with the body
+// of each response from the TV (or the error if there was none), and
+// AddSecret with credentials such as PSKs and auth cookies so they can be
+// kept out of anything written out.
+type Diagnostics interface {
+	Event(format string, args ...any)
+	TVResponse(service, method string, body []byte, err error)
+	AddSecret(secret string)
+}
+
+// diag is where the clients record diagnostics. It discards them until set
+// with [SetDiagnostics].
+var diag Diagnostics = nopDiagnostics{}
+
+// SetDiagnostics sets where all clients record diagnostics. It should be
+// called before any clients are created, as it is not safe to call
+// concurrently with their use. A nil d discards diagnostics.
+func SetDiagnostics(d Diagnostics) {
+	if d == nil {
+		d = nopDiagnostics{}
+	}
+	diag = d
+}
+
+type nopDiagnostics struct{}
+
+func (nopDiagnostics) Event(string, ...any)                     {}
+func (nopDiagnostics) TVResponse(string, string, []byte, error) {}
+func (nopDiagnostics) AddSecret(string)                         {}
@@ -0,0 +1,161 @@
+//nolint:goerr113 // dynamic errors are OK
+package bravia
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ssdpAddr is the multicast address SSDP searches are sent to.
+const ssdpAddr = "239.255.255.250:1900"
+
+// scalarWebAPIService is the UPnP service type advertised over SSDP by TVs
+// with the REST API.
+const scalarWebAPIService = "urn:schemas-sony-com:service:ScalarWebAPI:1"
+
+// ssdpSearch is the SSDP search request for TVs with the REST API.
+const ssdpSearch = "M-SEARCH * HTTP/1.1\r\n" +
+	"HOST: " + ssdpAddr + "\r\n" +
+	"MAN: \"ssdp:discover\"\r\n" +
+	"MX: 1\r\n" +
+	"ST: " + scalarWebAPIService + "\r\n" +
+	"\r\n"
+
+// DiscoveredTV is a TV found on the local network by [Discover], with the
+// details from its UPnP device description.
+type DiscoveredTV struct {
+	// Host is the host of the TV's REST API, to pass to [NewRESTClient].
+	Host         string
+	FriendlyName string
+	ModelName    string
+	SerialNumber string // empty if the TV does not give it
+}
+
+// Discover searches the local network for Bravia TVs with the REST API by
+// sending an SSDP search and waiting for responses for the given time, or
+// until ctx is done. The UPnP device descriptions of the TVs that respond are
+// fetched for their details. TVs whose description cannot be fetched are
+// skipped.
+func Discover(ctx context.Context, wait time.Duration) ([]DiscoveredTV, error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, fmt.Errorf("could not listen for SSDP responses: %w", err)
+	}
+	defer conn.Close()
+	addr, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteTo([]byte(ssdpSearch), addr); err != nil {
+		return nil, fmt.Errorf("could not send SSDP search: %w", err)
+	}
+	deadline := time.Now().Add(wait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var locations []string
+	seen := map[string]bool{}
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		var nerr net.Error
+		if errors.As(err, &nerr) && nerr.Timeout() {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read SSDP response: %w", err)
+		}
+		if location := ssdpLocation(buf[:n]); location != "" && !seen[location] {
+			seen[location] = true
+			locations = append(locations, location)
+		}
+	}
+	diag.Event("SSDP found %d TVs", len(locations))
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	tvs := make([]DiscoveredTV, 0, len(locations))
+	for _, location := range locations {
+		tv, err := describeTV(ctx, client, location)
+		if err != nil {
+			diag.Event("could not describe TV at %s: %v", location, err)
+			continue
+		}
+		tvs = append(tvs, tv)
+	}
+	return tvs, nil
+}
+
+// ssdpLocation returns the location of the UPnP device description in an
+// SSDP response for TVs with the REST API, or the empty string if b is not
+// one.
+func ssdpLocation(b []byte) string {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), nil)
+	if err != nil {
+		return ""
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ST") != scalarWebAPIService {
+		return ""
+	}
+	return resp.Header.Get("Location")
+}
+
+// describeTV fetches the UPnP device description of a TV from location. The
+// host of the TV's REST API is taken from the description if it gives it,
+// and is the host of location otherwise.
+func describeTV(ctx context.Context, client *http.Client, location string) (DiscoveredTV, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return DiscoveredTV{}, fmt.Errorf("new request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return DiscoveredTV{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return DiscoveredTV{}, HTTPStatusError(resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return DiscoveredTV{}, err
+	}
+	var desc struct {
+		Device struct {
+			FriendlyName string `xml:"friendlyName"`
+			ModelName    string `xml:"modelName"`
+			SerialNumber string `xml:"serialNumber"`
+			BaseURL      string `xml:"X_ScalarWebAPI_DeviceInfo>X_ScalarWebAPI_BaseURL"`
+		} `xml:"device"`
+	}
+	if err := xml.Unmarshal(body, &desc); err != nil {
+		return DiscoveredTV{}, InvalidResponseError{wrapped: err, Body: body}
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return DiscoveredTV{}, err
+	}
+	host := u.Hostname()
+	if base, err := url.Parse(desc.Device.BaseURL); err == nil && base.Host != "" {
+		host = base.Host
+	}
+	return DiscoveredTV{
+		Host:         host,
+		FriendlyName: desc.Device.FriendlyName,
+		ModelName:    desc.Device.ModelName,
+		SerialNumber: desc.Device.SerialNumber,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package bravia
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSSDPLocation(t *testing.T) {
+	is := is.New(t)
+	resp := "HTTP/1.1 200 OK\r\n" +
+		"CACHE-CONTROL: max-age=1800\r\n" +
+		"EXT:\r\n" +
+		"LOCATION: http://192.168.1.20:52323/dmr.xml\r\n" +
+		"SERVER: Linux/4.9 UPnP/1.0 KDL/1.0\r\n" +
+		"ST: urn:schemas-sony-com:service:ScalarWebAPI:1\r\n" +
+		"USN: uuid:00000000-0000-1010-8000-123456789abc::urn:schemas-sony-com:service:ScalarWebAPI:1\r\n" +
+		"\r\n"
+	is.Equal(ssdpLocation([]byte(resp)), "http://192.168.1.20:52323/dmr.xml")
+	is.Equal(ssdpLocation([]byte("M-SEARCH * HTTP/1.1\r\n\r\n")), "") // not a response
+	other := "HTTP/1.1 200 OK\r\nLOCATION: http://192.168.1.30/desc.xml\r\nST: upnp:rootdevice\r\n\r\n"
+	is.Equal(ssdpLocation([]byte(other)), "") // not a TV
+}
+
+func TestDescribeTV(t *testing.T) {
+	is := is.New(t)
+	desc := `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0" xmlns:av="urn:schemas-sony-com:av">
+  <device>
+    <friendlyName>Living Room TV</friendlyName>
+    <manufacturer>Sony Corporation</manufacturer>
+    <modelName>KD-55X85J</modelName>
+    <serialNumber>7001234</serialNumber>
+    <av:X_ScalarWebAPI_DeviceInfo>
+      <av:X_ScalarWebAPI_Version>1.0</av:X_ScalarWebAPI_Version>
+      <av:X_ScalarWebAPI_BaseURL>http://192.168.1.20/sony</av:X_ScalarWebAPI_BaseURL>
+    </av:X_ScalarWebAPI_DeviceInfo>
+  </device>
+</root>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old.xml" {
+			w.Write([]byte(`<root><device><modelName>KDL-50W800C</modelName></device></root>`)) //nolint:errcheck // test server
+			return
+		}
+		w.Write([]byte(desc)) //nolint:errcheck // test server
+	}))
+	t.Cleanup(srv.Close)
+
+	tv, err := describeTV(context.Background(), srv.Client(), srv.URL+"/dmr.xml")
+	is.NoErr(err)
+	is.Equal(tv, DiscoveredTV{Host: "192.168.1.20", FriendlyName: "Living Room TV", ModelName: "KD-55X85J", SerialNumber: "7001234"})
+
+	// Without the base URL of the REST API, the TV is at the host of the
+	// description.
+	tv, err = describeTV(context.Background(), srv.Client(), srv.URL+"/old.xml")
+	is.NoErr(err)
+	u, _ := url.Parse(srv.URL)
+	is.Equal(tv, DiscoveredTV{Host: u.Hostname(), ModelName: "KDL-50W800C"})
+}
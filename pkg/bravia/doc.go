@@ -0,0 +1,13 @@
+// Package bravia controls Sony Bravia TVs and professional displays.
+//
+// [RESTClient] uses the REST IP control protocol of Bravia smart TVs and
+// covers power, inputs, volume, picture and sound settings, apps, channels,
+// remote control keys and change notifications. [SimpleIPClient] and
+// [SerialClient] use the Simple IP control and RS-232C control protocols
+// for TVs and displays without the REST API, and only cover power, inputs
+// and volume. All three implement [TV].
+//
+// The package is used by the offscreen command and is versioned with the
+// foxygo.at/offscreen module, following semantic versioning: the exported
+// API only changes incompatibly in a new major version.
+package bravia
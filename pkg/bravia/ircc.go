@@ -0,0 +1,215 @@
+//nolint:goerr113 // dynamic errors are OK
+package bravia
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// IRCCPower is the IRCC code of the power key, which toggles the power.
+const IRCCPower = "AAAAAQAAAAEAAAAVAw=="
+
+// irccCodes maps the names of remote control keys, as named by the TV's
+// remote controller info, to their IRCC codes. These are common to most
+// Bravia models that support IRCC-IP and are used if the key map cannot be
+// fetched from the TV with [RESTClient.IRCCCodes]. Look up names with
+// [IRCCCode] as the lookup is case-insensitive.
+var irccCodes = map[string]string{
+	"Power":       IRCCPower,
+	"PowerOff":    "AAAAAQAAAAEAAAAvAw==",
+	"Input":       "AAAAAQAAAAEAAAAlAw==",
+	"Home":        "AAAAAQAAAAEAAABgAw==",
+	"Return":      "AAAAAgAAAJcAAAAjAw==",
+	"Options":     "AAAAAgAAAJcAAAA2Aw==",
+	"ActionMenu":  "AAAAAgAAAMQAAABLAw==",
+	"Display":     "AAAAAQAAAAEAAAA6Aw==",
+	"Exit":        "AAAAAQAAAAEAAABjAw==",
+	"Up":          "AAAAAQAAAAEAAAB0Aw==",
+	"Down":        "AAAAAQAAAAEAAAB1Aw==",
+	"Left":        "AAAAAQAAAAEAAAA0Aw==",
+	"Right":       "AAAAAQAAAAEAAAAzAw==",
+	"Confirm":     "AAAAAQAAAAEAAABlAw==",
+	"VolumeUp":    "AAAAAQAAAAEAAAASAw==",
+	"VolumeDown":  "AAAAAQAAAAEAAAATAw==",
+	"Mute":        "AAAAAQAAAAEAAAAUAw==",
+	"ChannelUp":   "AAAAAQAAAAEAAAAQAw==",
+	"ChannelDown": "AAAAAQAAAAEAAAARAw==",
+	"GGuide":      "AAAAAQAAAAEAAAAOAw==",
+	"Num1":        "AAAAAQAAAAEAAAAAAw==",
+	"Num2":        "AAAAAQAAAAEAAAABAw==",
+	"Num3":        "AAAAAQAAAAEAAAACAw==",
+	"Num4":        "AAAAAQAAAAEAAAADAw==",
+	"Num5":        "AAAAAQAAAAEAAAAEAw==",
+	"Num6":        "AAAAAQAAAAEAAAAFAw==",
+	"Num7":        "AAAAAQAAAAEAAAAGAw==",
+	"Num8":        "AAAAAQAAAAEAAAAHAw==",
+	"Num9":        "AAAAAQAAAAEAAAAIAw==",
+	"Num0":        "AAAAAQAAAAEAAAAJAw==",
+	"Red":         "AAAAAgAAAJcAAAAlAw==",
+	"Green":       "AAAAAgAAAJcAAAAmAw==",
+	"Yellow":      "AAAAAgAAAJcAAAAnAw==",
+	"Blue":        "AAAAAgAAAJcAAAAkAw==",
+	"Play":        "AAAAAgAAAJcAAAAaAw==",
+	"Pause":       "AAAAAgAAAJcAAAAZAw==",
+	"Stop":        "AAAAAgAAAJcAAAAYAw==",
+	"Forward":     "AAAAAgAAAJcAAAAcAw==",
+	"Rewind":      "AAAAAgAAAJcAAAAbAw==",
+	"Next":        "AAAAAgAAAJcAAAA9Aw==",
+	"Prev":        "AAAAAgAAAJcAAAA8Aw==",
+	"Hdmi1":       "AAAAAgAAABoAAABaAw==",
+	"Hdmi2":       "AAAAAgAAABoAAABbAw==",
+	"Hdmi3":       "AAAAAgAAABoAAABcAw==",
+	"Hdmi4":       "AAAAAgAAABoAAABdAw==",
+	"Netflix":     "AAAAAgAAABoAAAB8Aw==",
+	"YouTube":     "AAAAAgAAAMQAAABHAw==",
+}
+
+// IRCCCode returns the IRCC code for the named remote control key in codes,
+// ignoring case. If name is not a known key but is itself an IRCC code
+// (base64 encoded, and long enough not to be mistaken for a key name), it is
+// returned as is.
+func IRCCCode(codes map[string]string, name string) (string, bool) {
+	for key, code := range codes {
+		if strings.EqualFold(key, name) {
+			return code, true
+		}
+	}
+	if b, err := base64.StdEncoding.DecodeString(name); err == nil && len(b) >= 8 {
+		return name, true
+	}
+	return "", false
+}
+
+// RemoteControllerInfo returns the remote control keys of the TV, mapping
+// the names of the keys to their IRCC codes.
+func (c *RESTClient) RemoteControllerInfo(ctx context.Context) (map[string]string, error) {
+	// The first result is information about the remote control, which we
+	// do not need. The second is the list of keys.
+	results, err := postResults[json.RawMessage](ctx, c, "system", "getRemoteControllerInfo", "1.0", nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) < 2 {
+		return nil, fmt.Errorf("remote controller info: expected 2 results, got %d", len(results))
+	}
+	var keys []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(results[1], &keys); err != nil {
+		return nil, fmt.Errorf("remote controller info: %w", err)
+	}
+	codes := make(map[string]string, len(keys))
+	for _, key := range keys {
+		codes[key.Name] = key.Value
+	}
+	return codes, nil
+}
+
+// IRCCCodes returns the remote control key map of the TV, mapping key names
+// to IRCC codes, as the keys and codes vary by model. The map is fetched
+// from the TV the first time it is needed. If it cannot be fetched, the
+// built-in map of keys common to most models is returned.
+func (c *RESTClient) IRCCCodes(ctx context.Context) map[string]string {
+	if c.irccCodes != nil {
+		return c.irccCodes
+	}
+	codes, err := c.RemoteControllerInfo(ctx)
+	if err != nil || len(codes) == 0 {
+		diag.Event("could not get remote control keys from TV, using built-in keys: %v", err)
+		codes = irccCodes
+	}
+	c.irccCodes = codes
+	return codes
+}
+
+// irccEnvelope is the SOAP request body for sending an IRCC code. The code
+// is substituted for the %s.
+const irccEnvelope = `<?xml version="1.0"?>` +
+	`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+	`<s:Body><u:X_SendIRCC xmlns:u="urn:schemas-sony-com:service:IRCC:1"><IRCCCode>%s</IRCCCode></u:X_SendIRCC></s:Body>` +
+	`</s:Envelope>`
+
+const (
+	// irccPollInterval is how often the power status is polled after
+	// sending the IRCC power key to see if it took effect.
+	irccPollInterval = 500 * time.Millisecond
+
+	// irccPollTimeout is how long to poll for the power status to change
+	// after sending the IRCC power key.
+	irccPollTimeout = 10 * time.Second
+)
+
+// SendIRCC sends an IRCC (InfraRed Compatible Control) code to the TV over
+// IP, as if the corresponding key was pressed on the remote control. Codes
+// are base64 strings as listed by the TV's remote controller info.
+func (c *RESTClient) SendIRCC(ctx context.Context, code string) error {
+	u, err := url.JoinPath(c.BaseURL, "IRCC")
+	if err != nil {
+		return fmt.Errorf("join path: %w", err)
+	}
+	body := fmt.Sprintf(irccEnvelope, code)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader([]byte(body)))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", `text/xml; charset=UTF-8`)
+	req.Header.Set("SOAPACTION", `"urn:schemas-sony-com:service:IRCC:1#X_SendIRCC"`)
+	c.authorize(req)
+	var resp []byte
+	err = c.guard(func() error {
+		resp, err = c.do(req)
+		return err
+	})
+	diag.TVResponse("IRCC", "X_SendIRCC", resp, err)
+	if err != nil {
+		return fmt.Errorf("http: %w", err)
+	}
+	return nil
+}
+
+// IsUnsupported returns true if err is a Sony error indicating the TV does
+// not support the requested method in its current state, as opposed to a
+// communication or authentication error.
+func IsUnsupported(err error) bool {
+	return IsSonyCode(err, SonyCodeIllegalState, SonyCodeNoSuchMethod, SonyCodeUnsupportedOperation, SonyCodeNotImplemented)
+}
+
+// irccSetPowerStatus sets the power status of the TV by sending the IRCC
+// power key, which toggles the power. As it is a toggle, the key is only
+// sent if the TV is not already in the requested state, and the power
+// status is then polled until the TV reaches the requested state.
+func (c *RESTClient) irccSetPowerStatus(ctx context.Context, status bool) error {
+	want := "standby"
+	if status {
+		want = "active"
+	}
+	current, err := c.SettledPowerStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if current == want {
+		return nil
+	}
+	if err := c.SendIRCC(ctx, IRCCPower); err != nil {
+		return fmt.Errorf("ircc power: %w", err)
+	}
+	deadline := time.Now().Add(irccPollTimeout)
+	for time.Now().Before(deadline) {
+		if err := sleep(ctx, irccPollInterval); err != nil {
+			return err
+		}
+		current, err := c.PowerStatus(ctx)
+		if err == nil && current == want {
+			return nil
+		}
+	}
+	return fmt.Errorf("ircc power: TV did not become %s within %v", want, irccPollTimeout)
+}
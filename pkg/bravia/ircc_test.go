@@ -0,0 +1,53 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestIRCCCodesFromTV(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		if method == "getRemoteControllerInfo" {
+			return results{
+				map[string]string{"bundled": "true", "type": "RM-J1100"},
+				[]map[string]string{
+					{"name": "PowerOff", "value": "AAAAAQAAAAEAAAAvAw=="},
+					{"name": "Netflix", "value": "AAAAAgAAABoAAAB8Aw=="},
+					{"name": "Tv_Radio", "value": "AAAAAgAAABoAAABXAw=="},
+				},
+			}, nil
+		}
+		return nil, []any{12, "No Such Method"}
+	})
+
+	codes := c.IRCCCodes(ctx)
+	is.Equal(3, len(codes)) // wrong number of keys
+	code, ok := IRCCCode(codes, "tv_radio")
+	is.True(ok)                            // model-specific key not found
+	is.Equal("AAAAAgAAABoAAABXAw==", code) // wrong code for key
+	_, ok = IRCCCode(codes, "Hdmi1")
+	is.True(!ok) // key not on this model should not be found
+}
+
+func TestIRCCCodesFallback(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		return nil, []any{12, "No Such Method"}
+	})
+
+	codes := c.IRCCCodes(ctx)
+	code, ok := IRCCCode(codes, "hdmi1")
+	is.True(ok)                        // built-in key not found
+	is.Equal(irccCodes["Hdmi1"], code) // wrong code for built-in key
+	code, ok = IRCCCode(codes, "AAAAAQAAAAEAAAAVAw==")
+	is.True(ok)               // raw IRCC code not accepted
+	is.Equal(IRCCPower, code) // raw IRCC code not returned as is
+}
@@ -0,0 +1,44 @@
+package bravia
+
+import "sort"
+
+// applyLabelOverrides sets the labels of inputs from overrides, replacing
+// the labels set on the TV. If several labels are given for an input, the
+// first in sorted order is used.
+func applyLabelOverrides(overrides map[string]string, inputs []Input) {
+	labels := sortedLabels(overrides)
+	for i := len(labels) - 1; i >= 0; i-- {
+		for j := range inputs {
+			if inputs[j].URI == overrides[labels[i]] {
+				inputs[j].Label = labels[i]
+			}
+		}
+	}
+}
+
+// overriddenInputLabels returns the map of input URIs to labels and labels
+// to URIs of inputs, as per [InputLabelMap], adding the label overrides for
+// content that is not an external input, such as a tuner. The labels of
+// inputs should already have been overridden with [applyLabelOverrides].
+func overriddenInputLabels(overrides map[string]string, inputs []Input) map[string]string {
+	labels := InputLabelMap(inputs)
+	for _, label := range sortedLabels(overrides) {
+		uri := overrides[label]
+		if _, ok := labels[uri]; !ok {
+			labels[uri] = label
+			labels[label] = uri
+		}
+	}
+	return labels
+}
+
+// sortedLabels returns the labels of overrides in sorted order, so the same
+// label wins each time when several are given for the same URI.
+func sortedLabels(overrides map[string]string) []string {
+	labels := make([]string, 0, len(overrides))
+	for label := range overrides {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
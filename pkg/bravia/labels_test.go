@@ -0,0 +1,41 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLabelOverrides(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		if method != "getCurrentExternalInputsStatus" {
+			return nil, []any{SonyCodeNoSuchMethod, "No Such Method"}
+		}
+		return []Input{
+			{URI: "extInput:hdmi?port=1", Title: "HDMI 1", Label: "desk"},
+			{URI: "extInput:hdmi?port=2", Title: "HDMI 2", Label: "desk"},
+		}, nil
+	})
+	c.versions = map[string]string{} // skip version negotiation
+	c.LabelOverrides = map[string]string{
+		"laptop":  "extInput:hdmi?port=2",
+		"work":    "extInput:hdmi?port=2",
+		"news":    "tv:dvbt",
+		"weather": "tv:dvbt",
+	}
+	inputs, err := c.Inputs(ctx)
+	is.NoErr(err)
+	is.Equal("desk", inputs[0].Label)   // label from TV not kept
+	is.Equal("laptop", inputs[1].Label) // label not overridden by the first in order
+
+	labels, err := c.InputLabels(ctx)
+	is.NoErr(err)
+	is.Equal("extInput:hdmi?port=1", labels["desk"]) // colliding label not resolved by override
+	is.Equal("extInput:hdmi?port=2", labels["laptop"])
+	is.Equal("tv:dvbt", labels["news"]) // override for non-input content missing
+	is.Equal("news", labels["tv:dvbt"]) // not the first label in order
+}
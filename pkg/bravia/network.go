@@ -0,0 +1,29 @@
+package bravia
+
+import "context"
+
+// NetworkInterface is the network settings of one of the TV's network
+// interfaces.
+type NetworkInterface struct {
+	Netif    string   `json:"netif"`
+	HWAddr   string   `json:"hwAddr"`
+	IPAddrV4 string   `json:"ipAddrV4"`
+	IPAddrV6 string   `json:"ipAddrV6"`
+	Netmask  string   `json:"netmask"`
+	Gateway  string   `json:"gateway"`
+	DNS      []string `json:"dns"`
+}
+
+// NetworkSettings returns the settings of the TV's network interfaces, such
+// as "eth0" and "wlan0".
+func (c *RESTClient) NetworkSettings(ctx context.Context) ([]NetworkInterface, error) {
+	param := map[string]string{"netif": ""}
+	netifs, err := post[[]NetworkInterface](ctx, c, "system", "getNetworkSettings", "1.0", param)
+	if err != nil {
+		return nil, err
+	}
+	if netifs == nil {
+		return nil, noResult("getNetworkSettings")
+	}
+	return *netifs, nil
+}
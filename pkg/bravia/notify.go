@@ -0,0 +1,187 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// notifyServices maps the services that [RESTClient.Subscribe] subscribes
+// to for notifications to the notifications wanted from each.
+var notifyServices = map[string][]string{
+	"system":    {"notifyPowerStatus"},
+	"avContent": {"notifyPlayingContentInfo", "notifyExternalInputStatus"},
+	"audio":     {"notifyVolumeInformation"},
+}
+
+// Notification is a notification pushed by the TV when its state changes,
+// such as when it is turned off with the remote control.
+type Notification struct {
+	Service string
+	Method  string
+	Params  json.RawMessage
+}
+
+// notifyAPI is a notification API as listed by switchNotifications.
+type notifyAPI struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Subscribe subscribes to the power, input and volume change notifications
+// of the TV over WebSockets, calling fn with each notification received
+// until ctx is done or a connection fails. If methods are given, such as
+// "notifyPowerStatus", only those notifications are subscribed to. Only
+// newer TVs support notifications.
+func (c *RESTClient) Subscribe(ctx context.Context, fn func(Notification), methods ...string) error {
+	conns := map[string]*websocket.Conn{}
+	defer func() {
+		for _, conn := range conns {
+			conn.Close() //nolint:errcheck,gosec // nothing to do
+		}
+	}()
+	for service, wanted := range notifyServices {
+		if len(methods) > 0 {
+			wanted = filterMethods(wanted, methods)
+		}
+		if len(wanted) == 0 {
+			continue
+		}
+		conn, err := c.subscribe(ctx, service, wanted)
+		if err != nil {
+			return fmt.Errorf("subscribe to %s notifications: %w", service, err)
+		}
+		conns[service] = conn
+	}
+
+	errs := make(chan error, len(conns))
+	var mu sync.Mutex // serialises calls to fn
+	for service, conn := range conns {
+		go func(service string, conn *websocket.Conn) {
+			for {
+				var msg struct {
+					Method string            `json:"method"`
+					Params []json.RawMessage `json:"params"`
+				}
+				if err := conn.ReadJSON(&msg); err != nil {
+					errs <- fmt.Errorf("%s notifications: %w", service, err)
+					return
+				}
+				if msg.Method == "" {
+					continue // a response, not a notification
+				}
+				n := Notification{Service: service, Method: msg.Method}
+				if len(msg.Params) > 0 {
+					n.Params = msg.Params[0]
+				}
+				diag.TVResponse(service, msg.Method, n.Params, nil)
+				mu.Lock()
+				fn(n)
+				mu.Unlock()
+			}
+		}(service, conn)
+	}
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
+
+// filterMethods returns the notifications of wanted that are in methods.
+func filterMethods(wanted, methods []string) []string {
+	var filtered []string
+	for _, name := range wanted {
+		for _, method := range methods {
+			if name == method {
+				filtered = append(filtered, name)
+			}
+		}
+	}
+	return filtered
+}
+
+// subscribe connects to the WebSocket of the service and enables the wanted
+// notifications that the TV supports.
+func (c *RESTClient) subscribe(ctx context.Context, service string, wanted []string) (*websocket.Conn, error) {
+	u := strings.Replace(c.BaseURL, "http", "ws", 1) + "/" + service
+	req, err := http.NewRequest(http.MethodGet, u, nil) //nolint:noctx // only used for its auth headers
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	c.authorize(req)
+	dialer := *websocket.DefaultDialer
+	dialer.HandshakeTimeout = c.HTTPClient.Timeout
+	dialer.TLSClientConfig = c.tlsConfig
+	conn, resp, err := dialer.DialContext(ctx, u, req.Header)
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			return nil, HTTPStatusError(resp.StatusCode)
+		}
+		return nil, err
+	}
+
+	// Calling switchNotifications with no APIs to enable or disable
+	// returns those that are available, then call it again to enable the
+	// ones we want.
+	available, err := switchNotifications(conn, nil)
+	if err != nil {
+		conn.Close() //nolint:errcheck,gosec // nothing to do
+		return nil, err
+	}
+	var enable []notifyAPI
+	for _, api := range available {
+		for _, name := range wanted {
+			if api.Name == name {
+				enable = append(enable, api)
+			}
+		}
+	}
+	if _, err := switchNotifications(conn, enable); err != nil {
+		conn.Close() //nolint:errcheck,gosec // nothing to do
+		return nil, err
+	}
+	diag.Event("subscribed to %s notifications %v", service, enable)
+	return conn, nil
+}
+
+// switchNotifications enables the given notification APIs on conn, and
+// returns all the notification APIs available, enabled or not.
+func switchNotifications(conn *websocket.Conn, enable []notifyAPI) ([]notifyAPI, error) {
+	param := map[string][]notifyAPI{}
+	if enable != nil {
+		param["enabled"] = enable
+	}
+	req := map[string]any{
+		"method":  "switchNotifications",
+		"version": "1.0",
+		"id":      1,
+		"params":  []any{param},
+	}
+	if err := conn.WriteJSON(req); err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Result []struct {
+			Enabled  []notifyAPI `json:"enabled"`
+			Disabled []notifyAPI `json:"disabled"`
+		} `json:"result"`
+		Error []any `json:"error"`
+	}
+	if err := conn.ReadJSON(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, NewSonyError(resp.Error, nil)
+	}
+	if len(resp.Result) == 0 {
+		return nil, nil
+	}
+	return append(resp.Result[0].Enabled, resp.Result[0].Disabled...), nil
+}
@@ -0,0 +1,115 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/matryer/is"
+)
+
+// notifyingTV is a fake TV that accepts notification subscriptions over
+// WebSockets and pushes a power status notification once subscribed. The
+// services subscribed to are sent to services if it is not nil.
+func notifyingTV(t *testing.T, services chan<- string) string {
+	t.Helper()
+	var upgrader websocket.Upgrader
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		service := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if services != nil {
+			services <- service
+		}
+		for {
+			var req struct {
+				Params []struct {
+					Enabled []notifyAPI `json:"enabled"`
+				} `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			var apis []notifyAPI
+			for _, name := range notifyServices[service] {
+				apis = append(apis, notifyAPI{Name: name, Version: "1.0"})
+			}
+			result := map[string]any{"enabled": []notifyAPI{}, "disabled": apis}
+			enabled := req.Params[0].Enabled
+			if enabled != nil {
+				result = map[string]any{"enabled": enabled, "disabled": []notifyAPI{}}
+			}
+			_ = conn.WriteJSON(map[string]any{"id": 1, "result": []any{result}})
+			if service == "system" && len(enabled) > 0 {
+				_ = conn.WriteJSON(map[string]any{
+					"method":  "notifyPowerStatus",
+					"version": "1.0",
+					"params":  []any{map[string]string{"status": "standby"}},
+				})
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestSubscribe(t *testing.T) {
+	is := is.New(t)
+	c := NewRESTClient(notifyingTV(t, nil), "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan Notification, 1)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- c.Subscribe(ctx, func(n Notification) { got <- n })
+	}()
+	n := <-got
+	cancel()
+	is.NoErr(<-errs) // Subscribe failed
+
+	is.Equal("system", n.Service)
+	is.Equal("notifyPowerStatus", n.Method)
+	var params map[string]string
+	is.NoErr(json.Unmarshal(n.Params, &params))
+	is.Equal("standby", params["status"])
+}
+
+func TestSubscribeMethods(t *testing.T) {
+	is := is.New(t)
+	services := make(chan string, len(notifyServices))
+	c := NewRESTClient(notifyingTV(t, services), "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan Notification, 1)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- c.Subscribe(ctx, func(n Notification) { got <- n }, "notifyPowerStatus")
+	}()
+	n := <-got
+	cancel()
+	is.NoErr(<-errs) // Subscribe failed
+	is.Equal("notifyPowerStatus", n.Method)
+	close(services)
+	var subscribed []string
+	for service := range services {
+		subscribed = append(subscribed, service)
+	}
+	is.Equal([]string{"system"}, subscribed) // only the service with the wanted notification
+}
+
+func TestSubscribeUnsupported(t *testing.T) {
+	is := is.New(t)
+	_, c := newFakeTV(t, func(string, []json.RawMessage) (any, []any) { return nil, nil })
+
+	err := c.Subscribe(context.Background(), func(Notification) {})
+	var herr HTTPStatusError
+	is.True(err != nil && errors.As(err, &herr)) // expected HTTP error from TV without notifications
+}
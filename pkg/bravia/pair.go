@@ -0,0 +1,79 @@
+//nolint:goerr113 // dynamic errors are OK
+package bravia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// multiParams can be passed as the params of a request to send each element
+// as a separate parameter, for the few methods that take more than one.
+type multiParams []any
+
+// ErrPINRequired is returned by [RESTClient.Register] when the TV needs a
+// PIN to pair. The TV displays the PIN when this is returned.
+var ErrPINRequired = errors.New("PIN required")
+
+// ErrPairingExpired is returned when the TV no longer accepts the auth
+// cookie from pairing, such as when it has expired. It is not refreshed, as
+// that would have the TV display a PIN that only a user can enter, so the
+// TV needs to be paired again with [RESTClient.Register].
+var ErrPairingExpired = errors.New("TV no longer accepts the auth cookie; pair with the TV again")
+
+// registerParams returns the parameters of the accessControl actRegister
+// method that pairs offscreen with the TV.
+func registerParams() multiParams {
+	host, _ := os.Hostname()
+	client := map[string]string{
+		"clientid": "offscreen:" + host,
+		"nickname": "offscreen (" + host + ")",
+		"level":    "private",
+	}
+	functions := []map[string]string{{"function": "WOL", "value": "yes"}}
+	return multiParams{client, functions}
+}
+
+// Register pairs offscreen with the TV, as an alternative to a Pre-Shared
+// Key, and returns the auth cookie to be used as [RESTClient.AuthCookie].
+// Pairing is done in two steps: first call Register with an empty pin,
+// which makes the TV display a PIN and returns [ErrPINRequired], then call it
+// again with that PIN. If the client already has an auth cookie that the TV
+// still accepts, it is refreshed without needing a PIN.
+func (c *RESTClient) Register(ctx context.Context, pin string) (string, error) {
+	req, err := c.newRequest(ctx, "accessControl", "actRegister", "1.0", registerParams())
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	if pin != "" {
+		req.SetBasicAuth("", pin)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	diag.TVResponse("accessControl", "actRegister", nil, err)
+	if err != nil {
+		return "", fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // nothing to do about it
+	if resp.StatusCode == http.StatusUnauthorized && pin == "" {
+		return "", ErrPINRequired
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http: %w", HTTPStatusError(resp.StatusCode))
+	}
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "auth" {
+			diag.AddSecret(cookie.Value)
+			return cookie.Value, nil
+		}
+	}
+	return "", fmt.Errorf("TV did not return an auth cookie")
+}
+
+// isAuthError returns true if err is an HTTP status error for a request that
+// was not authorized, such as when an auth cookie has expired.
+func isAuthError(err error) bool {
+	var herr HTTPStatusError
+	return errors.As(err, &herr) && (herr == http.StatusUnauthorized || herr == http.StatusForbidden)
+}
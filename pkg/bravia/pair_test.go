@@ -0,0 +1,88 @@
+package bravia
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// pairingTV is a fake TV that only accepts requests with a valid auth
+// cookie, which it issues for the right PIN or a previously issued cookie.
+// An expired cookie can only be used to get a new one.
+type pairingTV struct {
+	pin     string
+	cookie  string
+	expired string
+	issued  int
+}
+
+func (tv *pairingTV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cookie, _ := r.Cookie("auth")
+	authed := cookie != nil && cookie.Value == tv.cookie
+	if strings.HasSuffix(r.URL.Path, "/accessControl") {
+		_, pin, _ := r.BasicAuth()
+		expired := cookie != nil && cookie.Value == tv.expired
+		if !authed && !expired && pin != tv.pin {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		tv.issued++
+		tv.cookie = strings.Repeat("c", tv.issued)
+		http.SetCookie(w, &http.Cookie{Name: "auth", Value: tv.cookie})
+		_, _ = w.Write([]byte(`{"result":[],"id":1}`))
+		return
+	}
+	if !authed {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	_, _ = w.Write([]byte(`{"result":[{"status":"active"}],"id":1}`))
+}
+
+func newPairingTV(t *testing.T) (*pairingTV, *RESTClient) {
+	t.Helper()
+	tv := &pairingTV{pin: "1234"}
+	srv := httptest.NewServer(tv)
+	t.Cleanup(srv.Close)
+	return tv, NewRESTClient(strings.TrimPrefix(srv.URL, "http://"), "")
+}
+
+func TestRegister(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	tv, c := newPairingTV(t)
+
+	_, err := c.Register(ctx, "")
+	is.Equal(ErrPINRequired, err) // expected TV to ask for PIN
+	_, err = c.Register(ctx, "0000")
+	is.True(isAuthError(err)) // expected wrong PIN to be rejected
+
+	cookie, err := c.Register(ctx, "1234")
+	is.NoErr(err)               // pairing with PIN failed
+	is.Equal(tv.cookie, cookie) // wrong auth cookie
+
+	c.AuthCookie = cookie
+	status, err := c.PowerStatus(ctx)
+	is.NoErr(err) // request with auth cookie failed
+	is.Equal("active", status)
+}
+
+func TestAuthCookieExpired(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	tv, c := newPairingTV(t)
+
+	cookie, err := c.Register(ctx, "1234")
+	is.NoErr(err) // pairing with PIN failed
+	c.AuthCookie = cookie
+
+	tv.expired, tv.cookie = tv.cookie, ""
+	_, err = c.PowerStatus(ctx)
+	is.True(errors.Is(err, ErrPairingExpired)) // expected expired pairing
+	is.Equal(1, tv.issued)                     // pairing started for expired cookie
+}
@@ -0,0 +1,84 @@
+package bravia
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PictureSetting is the value of a picture quality setting of the TV, such
+// as "brightness" or "colorTemperature". Values are always strings in the
+// REST IP control protocol, even for numeric settings.
+type PictureSetting struct {
+	Target string `json:"target"`
+	Value  string `json:"value"`
+}
+
+// PictureSettingInfo describes the current value of a picture quality
+// setting and the values it can be set to.
+type PictureSettingInfo struct {
+	Target       string `json:"target"`
+	CurrentValue string `json:"currentValue"`
+	IsAvailable  bool   `json:"isAvailable"`
+	Candidate    []struct {
+		Value string `json:"value"`
+		Min   int    `json:"min"`
+		Max   int    `json:"max"`
+		Step  int    `json:"step"`
+	} `json:"candidate"`
+}
+
+// PictureQualitySettings returns the current picture quality setting for
+// the given target, or all settings if target is the empty string.
+func (c *RESTClient) PictureQualitySettings(ctx context.Context, target string) ([]PictureSettingInfo, error) {
+	param := map[string]string{"target": target}
+	settings, err := post[[]PictureSettingInfo](ctx, c, "video", "getPictureQualitySettings", "1.0", param)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, noResult("getPictureQualitySettings")
+	}
+	return *settings, nil
+}
+
+// SetPictureQualitySettings changes the given picture quality settings.
+func (c *RESTClient) SetPictureQualitySettings(ctx context.Context, settings []PictureSetting) error {
+	param := map[string][]PictureSetting{"settings": settings}
+	_, err := post[empty](ctx, c, "video", "setPictureQualitySettings", "1.0", param)
+	return err
+}
+
+// CandidateValues returns the values the setting can be set to, as a comma
+// separated list or a "min-max" range for numeric settings, or the empty
+// string if the TV did not say.
+func (si PictureSettingInfo) CandidateValues() string {
+	values := make([]string, 0, len(si.Candidate))
+	for _, c := range si.Candidate {
+		if c.Value == "" && c.Max > c.Min {
+			values = append(values, fmt.Sprintf("%d-%d", c.Min, c.Max))
+			continue
+		}
+		values = append(values, c.Value)
+	}
+	return strings.Join(values, ",")
+}
+
+// PictureMuted returns true if the TV's picture is muted: its panel is off
+// while audio and apps keep running.
+func (c *RESTClient) PictureMuted(ctx context.Context) (bool, error) {
+	mode, err := c.PowerSavingMode(ctx)
+	return mode == "pictureOff", err
+}
+
+// SetPictureMute turns the TV's panel off (mute == true) while leaving audio
+// and apps running, such as to listen to a music app, or back on. It uses
+// the "pictureOff" power saving mode, so turning the panel back on turns
+// power saving off.
+func (c *RESTClient) SetPictureMute(ctx context.Context, mute bool) error {
+	mode := "off"
+	if mute {
+		mode = "pictureOff"
+	}
+	return c.SetPowerSavingMode(ctx, mode)
+}
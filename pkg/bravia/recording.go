@@ -0,0 +1,74 @@
+package bravia
+
+import (
+	"context"
+	"time"
+)
+
+// Recording is a scheduled recording on the TV.
+type Recording struct {
+	URI             string `json:"uri"`
+	Title           string `json:"title"`
+	ChannelName     string `json:"channelName"`
+	StartDateTime   string `json:"startDateTime"`
+	DurationSec     int    `json:"durationSec"`
+	Type            string `json:"type"`
+	RecordingStatus string `json:"recordingStatus"`
+}
+
+// RecordingStatus returns whether the TV is recording: "recording" or
+// "notRecording".
+func (c *RESTClient) RecordingStatus(ctx context.Context) (string, error) {
+	type recordingStatusResponse struct {
+		Status string `json:"status"`
+	}
+	resp, err := post[recordingStatusResponse](ctx, c, "recording", "getRecordingStatus", "1.0", nil)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil {
+		return "", noResult("getRecordingStatus")
+	}
+	return resp.Status, nil
+}
+
+// Recordings returns the scheduled recordings on the TV.
+func (c *RESTClient) Recordings(ctx context.Context) ([]Recording, error) {
+	var recordings []Recording
+	for {
+		param := map[string]int{"stIdx": len(recordings), "cnt": listPageSize}
+		page, err := post[[]Recording](ctx, c, "recording", "getScheduleList", "1.0", param)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil || len(*page) == 0 {
+			return recordings, nil
+		}
+		recordings = append(recordings, *page...)
+		if len(*page) < listPageSize {
+			return recordings, nil
+		}
+	}
+}
+
+// AddRecording schedules a recording of the channel with the given URI from
+// start for the given duration.
+func (c *RESTClient) AddRecording(ctx context.Context, channelURI, title string, start time.Time, duration time.Duration) error {
+	param := map[string]any{
+		"uri":           channelURI,
+		"title":         title,
+		"startDateTime": start.Format("2006-01-02T15:04:05-0700"),
+		"durationSec":   int(duration.Seconds()),
+		"repeatType":    "none",
+	}
+	ctx = withRetryable(ctx, neverRetry) // a retry could add the recording twice
+	_, err := post[empty](ctx, c, "recording", "addSchedule", "1.0", param)
+	return err
+}
+
+// DeleteRecording deletes the scheduled recording with the given URI.
+func (c *RESTClient) DeleteRecording(ctx context.Context, uri string) error {
+	param := map[string]string{"uri": uri}
+	_, err := post[empty](ctx, c, "recording", "deleteSchedule", "1.0", param)
+	return err
+}
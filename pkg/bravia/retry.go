@@ -0,0 +1,102 @@
+package bravia
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy describes how requests to the TV are retried when they fail
+// with a transient error, such as a network blip or the TV being briefly
+// unresponsive just after waking. The zero value does not retry.
+type RetryPolicy struct {
+	// Attempts is the maximum number of times a request is made. Zero
+	// or one means a request is made once and not retried.
+	Attempts int
+
+	// Backoff is how long to wait before the first retry. The wait is
+	// doubled for each subsequent retry, up to MaxBackoff if it is set.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Retryable returns true if a request that failed with err should be
+	// retried. If nil, [isTransient] is used. It is overridden for the
+	// requests made with a context from [withRetryable].
+	Retryable func(err error) bool
+}
+
+// retryableKey is the context key for the Retryable override of requests
+// made with the context (see [withRetryable]).
+type retryableKey struct{}
+
+// withRetryable returns a context whose requests are retried only for the
+// errors for which retryable returns true, instead of as per the client's
+// [RetryPolicy]. It is for requests that are not idempotent, which must
+// never be retried (see [neverRetry]), and for requests whose caller
+// handles an unreachable TV itself, such as by waking it with Wake-on-LAN,
+// which must not wait for retries first (see [isServerError]).
+func withRetryable(ctx context.Context, retryable func(err error) bool) context.Context {
+	return context.WithValue(ctx, retryableKey{}, retryable)
+}
+
+// neverRetry is a Retryable for requests that must not be retried.
+func neverRetry(error) bool { return false }
+
+// do calls fn until it succeeds, fails with an error that is not retryable
+// or the attempts are exhausted, backing off between attempts. It returns
+// the error from the last call, or ctx's error if ctx is done while backing
+// off.
+func (p RetryPolicy) do(ctx context.Context, what string, fn func() error) error {
+	retryable := p.Retryable
+	if r, ok := ctx.Value(retryableKey{}).(func(error) bool); ok {
+		retryable = r
+	}
+	if retryable == nil {
+		retryable = isTransient
+	}
+	backoff := p.Backoff
+	err := fn()
+	for attempt := 2; attempt <= p.Attempts && err != nil && retryable(err); attempt++ {
+		diag.Event("%s: %v; retrying in %v (attempt %d of %d)", what, err, backoff, attempt, p.Attempts)
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+		err = fn()
+	}
+	return err
+}
+
+// sleep waits for d, returning early with ctx's error if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// isTransient returns true if err is an error that may go away if the
+// request is retried: the TV could not be reached, or it responded with an
+// HTTP server error (see [isServerError]). Requests are not made while the
+// circuit breaker is open, so that is not retried.
+func isTransient(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	return isServerError(err) || IsUnreachable(err)
+}
+
+// isServerError returns true if err is an HTTP server error response, such
+// as 503 Service Unavailable from a TV still starting up.
+func isServerError(err error) bool {
+	var herr HTTPStatusError
+	return errors.As(err, &herr) && herr >= http.StatusInternalServerError
+}
@@ -0,0 +1,109 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRetryTransient(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id": 1, "result": [{"status": "active"}]}`)) //nolint:errcheck,gosec // test
+	}))
+	t.Cleanup(srv.Close)
+	c := NewRESTClient(strings.TrimPrefix(srv.URL, "http://"), "")
+	c.versions = map[string]string{} // skip version negotiation
+
+	_, err := c.PowerStatus(ctx)
+	is.True(errors.Is(err, ErrHTTPStatus)) // expected HTTP error without retries
+	is.Equal(1, calls)
+
+	calls = 0
+	c.Retry = RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+	status, err := c.PowerStatus(ctx)
+	is.NoErr(err)
+	is.Equal("active", status)
+	is.Equal(3, calls) // expected two retries
+}
+
+func TestRetrySkipsTVErrors(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	var calls int
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		if method == "getPowerStatus" {
+			calls++
+		}
+		return nil, []any{40005, "Display Is Turned off"}
+	})
+	c.Retry = RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+
+	_, err := c.PowerStatus(ctx)
+	is.True(err != nil)
+	is.Equal(1, calls) // error responses from the TV should not be retried
+}
+
+func TestRetrySkipsRelativeVolume(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	c := NewRESTClient(strings.TrimPrefix(srv.URL, "http://"), "")
+	c.versions = map[string]string{} // skip version negotiation
+	c.Retry = RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+
+	is.True(c.SetAudioVolume(ctx, "speaker", "20") != nil)
+	is.Equal(3, calls) // absolute volume is retried
+
+	calls = 0
+	is.True(c.SetAudioVolume(ctx, "speaker", "+1") != nil)
+	is.Equal(1, calls) // relative volume must not be retried
+}
+
+func TestRetrySkipsNonIdempotent(t *testing.T) {
+	ctx := context.Background()
+	for name, call := range map[string]func(c *RESTClient) error{
+		"AddRecording": func(c *RESTClient) error {
+			return c.AddRecording(ctx, "tv:dvbt?trip=1.2.3", "News", time.Now(), time.Hour)
+		},
+		"SetActiveApp":  func(c *RESTClient) error { return c.SetActiveApp(ctx, "com.sony.dtv.example") },
+		"TerminateApps": func(c *RESTClient) error { return c.TerminateApps(ctx) },
+		"Reboot":        func(c *RESTClient) error { return c.Reboot(ctx) },
+		"OpenURL":       func(c *RESTClient) error { return c.OpenURL(ctx, "https://example.com") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			var calls int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			t.Cleanup(srv.Close)
+			c := NewRESTClient(strings.TrimPrefix(srv.URL, "http://"), "")
+			c.versions = map[string]string{} // skip version negotiation
+			c.Retry = RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+
+			is.True(call(c) != nil)
+			is.Equal(1, calls) // non-idempotent request must not be retried
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package bravia
+
+import "context"
+
+// SceneSetting returns the scene setting of the TV, such as "auto", "game"
+// or "graphics", which tunes the picture for the type of content.
+func (c *RESTClient) SceneSetting(ctx context.Context) (string, error) {
+	type sceneSettingResponse struct {
+		CurrentValue string `json:"currentValue"`
+	}
+	resp, err := post[sceneSettingResponse](ctx, c, "videoScreen", "getSceneSetting", "1.0", nil)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil {
+		return "", noResult("getSceneSetting")
+	}
+	return resp.CurrentValue, nil
+}
+
+// SetSceneSetting sets the scene setting of the TV. The scenes available
+// vary by model.
+func (c *RESTClient) SetSceneSetting(ctx context.Context, scene string) error {
+	param := map[string]string{"value": scene}
+	_, err := post[empty](ctx, c, "videoScreen", "setSceneSetting", "1.0", param)
+	return err
+}
@@ -0,0 +1,285 @@
+//nolint:goerr113 // dynamic errors are OK
+package bravia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The RS-232C control protocol of Bravia professional displays sends
+// commands as a header, a category, a function, the length of the data
+// including the checksum, the data and a checksum of the sum of all the
+// preceding bytes. Queries have 0xFF 0xFF as their data.
+const (
+	serialControl  = 0x8C // header of a command that sets a value
+	serialQuery    = 0x83 // header of a command that queries a value
+	serialResponse = 0x70 // header of a response
+	serialCategory = 0x00 // category of all the commands the client uses
+
+	serialPower  = 0x00 // function to turn the display on and off
+	serialInput  = 0x02 // function to select the input
+	serialVolume = 0x05 // function to set the volume
+	serialMute   = 0x06 // function to mute and unmute
+
+	serialDirect = 0x01 // sets a volume or mute state directly
+)
+
+// serialAnswers maps the answer codes of failed commands to their meaning.
+var serialAnswers = map[byte]string{
+	0x01: "value over limit",
+	0x02: "value under limit",
+	0x03: "command cancelled",
+	0x04: "parse error",
+}
+
+// serialInputTypes maps the input types of the input select function to
+// the kinds of extInput URIs used by the REST API.
+var serialInputTypes = map[byte]string{
+	0x02: "composite",
+	0x03: "component",
+	0x04: "hdmi",
+	0x05: "pc",
+}
+
+// ErrSerial is returned when the display answers a command sent over the
+// serial port with an error.
+var ErrSerial = errors.New("serial control error")
+
+// SerialClient controls a Bravia professional display, such as the BZ
+// series, with its RS-232C control protocol, for installations where the
+// display has no network connection. It only supports power, input, volume
+// and mute. The display must have RS-232C control enabled, and to be turned
+// on with it, "Standby mode" set to allow serial control in standby.
+type SerialClient struct {
+	// Device is the path of the serial port the display is connected to,
+	// such as /dev/ttyUSB0.
+	Device string
+
+	// LabelOverrides maps input labels to input URIs. As the protocol
+	// cannot get the labels set on the display, these are the only labels.
+	LabelOverrides map[string]string
+
+	// open opens the serial port. It is replaced in tests.
+	open func(device string) (io.ReadWriteCloser, error)
+}
+
+// NewSerialClient returns a client for the display connected to the serial
+// port device.
+func NewSerialClient(device string) *SerialClient {
+	return &SerialClient{Device: device, open: openSerialPort}
+}
+
+// PowerStatus returns "active" if the display is on and "standby" if it is
+// off.
+func (c *SerialClient) PowerStatus(ctx context.Context) (string, error) {
+	data, err := c.send(ctx, serialQuery, serialPower, 0xFF, 0xFF)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > 0 && data[len(data)-1] == 0x01 {
+		return "active", nil
+	}
+	return "standby", nil
+}
+
+// SettledPowerStatus returns the power status of the display, which is
+// always settled as the protocol does not report transitional statuses.
+func (c *SerialClient) SettledPowerStatus(ctx context.Context) (string, error) {
+	return c.PowerStatus(ctx)
+}
+
+// SetPowerStatus turns the display on (status == true) or off.
+func (c *SerialClient) SetPowerStatus(ctx context.Context, status bool) error {
+	_, err := c.send(ctx, serialControl, serialPower, serialBool(status))
+	return err
+}
+
+// SelectedInput returns the URI of the input the display is showing, in
+// the form used by the REST API such as "extInput:hdmi?port=1".
+func (c *SerialClient) SelectedInput(ctx context.Context) (string, error) {
+	data, err := c.send(ctx, serialQuery, serialInput, 0xFF, 0xFF)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < 2 {
+		return "", fmt.Errorf("%w: invalid input %x", ErrSerial, data)
+	}
+	typ, port := data[len(data)-2], data[len(data)-1]
+	kind, ok := serialInputTypes[typ]
+	if !ok {
+		return fmt.Sprintf("serial:input?type=%d&port=%d", typ, port), nil
+	}
+	return fmt.Sprintf("extInput:%s?port=%d", kind, port), nil
+}
+
+// SetInput selects the external input with the given URI, such as
+// "extInput:hdmi?port=1".
+func (c *SerialClient) SetInput(ctx context.Context, uri string) error {
+	kind, query, _ := strings.Cut(strings.TrimPrefix(uri, "extInput:"), "?port=")
+	port, err := strconv.Atoi(query)
+	if err != nil || !strings.HasPrefix(uri, "extInput:") || port < 1 || port > 0xFF {
+		return fmt.Errorf("%w: serial control can only select external inputs, not %q", ErrSerial, uri)
+	}
+	for typ, k := range serialInputTypes {
+		if k == kind {
+			_, err := c.send(ctx, serialControl, serialInput, typ, byte(port))
+			return err
+		}
+	}
+	return fmt.Errorf("%w: unknown input type %q", ErrSerial, kind)
+}
+
+// Inputs returns the four HDMI inputs most displays have, as the protocol
+// cannot list the inputs of the display. Their labels are only set from
+// LabelOverrides and their connection status is unknown.
+func (c *SerialClient) Inputs(_ context.Context) ([]Input, error) {
+	inputs := make([]Input, 0, 4)
+	for port := 1; port <= 4; port++ {
+		inputs = append(inputs, Input{
+			URI:   fmt.Sprintf("extInput:hdmi?port=%d", port),
+			Title: fmt.Sprintf("HDMI %d", port),
+		})
+	}
+	applyLabelOverrides(c.LabelOverrides, inputs)
+	return inputs, nil
+}
+
+// InputLabels returns a map of input URIs to labels and labels to URIs,
+// from LabelOverrides.
+func (c *SerialClient) InputLabels(ctx context.Context) (map[string]string, error) {
+	inputs, err := c.Inputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return overriddenInputLabels(c.LabelOverrides, inputs), nil
+}
+
+// VolumeInformation returns the volume and mute state of the display's
+// speakers.
+func (c *SerialClient) VolumeInformation(ctx context.Context) ([]VolumeInfo, error) {
+	volume, err := c.volume(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mute, err := c.send(ctx, serialQuery, serialMute, 0xFF, 0xFF)
+	if err != nil {
+		return nil, err
+	}
+	muted := len(mute) > 0 && mute[len(mute)-1] == 0x01
+	return []VolumeInfo{{Target: "speaker", Volume: volume, Mute: muted, MaxVolume: 100}}, nil
+}
+
+// SetAudioVolume sets the volume of the display to a number, e.g. "25", or
+// changes it when prefixed with "+" or "-", e.g. "+2". The display has a
+// single volume, so target must be "speaker" or empty.
+func (c *SerialClient) SetAudioVolume(ctx context.Context, target, volume string) error {
+	if target != "" && target != "speaker" {
+		return fmt.Errorf("%w: serial control cannot set the volume of %s", ErrSerial, target)
+	}
+	n, err := strconv.Atoi(volume)
+	if err != nil {
+		return fmt.Errorf("%w: invalid volume %q", ErrSerial, volume)
+	}
+	if strings.HasPrefix(volume, "+") || strings.HasPrefix(volume, "-") {
+		current, err := c.volume(ctx)
+		if err != nil {
+			return err
+		}
+		n += current
+	}
+	if n < 0 {
+		n = 0
+	} else if n > 100 {
+		n = 100
+	}
+	_, err = c.send(ctx, serialControl, serialVolume, serialDirect, byte(n))
+	return err
+}
+
+// SetAudioMute mutes (mute == true) or unmutes the display.
+func (c *SerialClient) SetAudioMute(ctx context.Context, mute bool) error {
+	_, err := c.send(ctx, serialControl, serialMute, serialDirect, serialBool(mute))
+	return err
+}
+
+func (c *SerialClient) volume(ctx context.Context) (int, error) {
+	data, err := c.send(ctx, serialQuery, serialVolume, 0xFF, 0xFF)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) == 0 {
+		return 0, fmt.Errorf("%w: no volume in response", ErrSerial)
+	}
+	return int(data[len(data)-1]), nil
+}
+
+// send sends a command to the display and returns the data of its
+// response, which is empty for commands that set a value. The serial port
+// is opened for each command so the display can be unplugged and plugged
+// back in while the client is in use.
+func (c *SerialClient) send(ctx context.Context, header, function byte, data ...byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	port, err := c.open(c.Device)
+	if err != nil {
+		return nil, fmt.Errorf("serial control: %w", err)
+	}
+	defer port.Close() //nolint:errcheck // nothing to do
+
+	cmd := append([]byte{header, serialCategory, function, byte(len(data) + 1)}, data...)
+	cmd = append(cmd, serialChecksum(cmd))
+	if _, err := port.Write(cmd); err != nil {
+		return nil, fmt.Errorf("serial control: %w", err)
+	}
+
+	// A response is the header, an answer code, the length of the data
+	// including the checksum (or 0 if there is no data), the data and a
+	// checksum.
+	resp := make([]byte, 3)
+	if _, err := io.ReadFull(port, resp); err != nil {
+		return nil, fmt.Errorf("serial control: %w", err)
+	}
+	n := int(resp[2])
+	if n == 0 {
+		n = 1
+	}
+	rest := make([]byte, n)
+	if _, err := io.ReadFull(port, rest); err != nil {
+		return nil, fmt.Errorf("serial control: %w", err)
+	}
+	resp = append(resp, rest...)
+	diag.TVResponse("serial", fmt.Sprintf("%#02x", function), []byte(fmt.Sprintf("% x", resp)), nil)
+
+	if resp[0] != serialResponse || serialChecksum(resp[:len(resp)-1]) != resp[len(resp)-1] {
+		return nil, fmt.Errorf("%w: invalid response % x", ErrSerial, resp)
+	}
+	if resp[1] != 0x00 {
+		msg, ok := serialAnswers[resp[1]]
+		if !ok {
+			msg = fmt.Sprintf("answer %#02x", resp[1])
+		}
+		return nil, fmt.Errorf("%w: %s", ErrSerial, msg)
+	}
+	return resp[3 : len(resp)-1], nil
+}
+
+// serialChecksum returns the checksum of b: the low byte of its sum.
+func serialChecksum(b []byte) byte {
+	var sum byte
+	for _, c := range b {
+		sum += c
+	}
+	return sum
+}
+
+func serialBool(b bool) byte {
+	if b {
+		return 0x01
+	}
+	return 0x00
+}
@@ -0,0 +1,6 @@
+package bravia
+
+import "syscall"
+
+// ioctlSetTermios is the ioctl request that sets the termios of a terminal.
+const ioctlSetTermios = syscall.TIOCSETA
@@ -0,0 +1,102 @@
+package bravia
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// fakeDisplay is a Bravia professional display on a fake serial port. It
+// keeps the value of each function and responds to commands written to it.
+type fakeDisplay struct {
+	values map[byte][]byte
+	resp   bytes.Buffer
+}
+
+func newFakeDisplay() (*fakeDisplay, *SerialClient) {
+	d := &fakeDisplay{values: map[byte][]byte{
+		serialPower:  {0x00},
+		serialInput:  {0x04, 0x01},
+		serialVolume: {0x01, 10},
+		serialMute:   {0x01, 0x00},
+	}}
+	c := NewSerialClient("/dev/fake")
+	c.open = func(string) (io.ReadWriteCloser, error) { return d, nil }
+	return d, c
+}
+
+func (d *fakeDisplay) Write(cmd []byte) (int, error) {
+	if serialChecksum(cmd[:len(cmd)-1]) != cmd[len(cmd)-1] {
+		d.respond(0x04)
+		return len(cmd), nil
+	}
+	header, function, data := cmd[0], cmd[2], cmd[4:len(cmd)-1]
+	switch {
+	case header == serialQuery:
+		d.respond(0x00, d.values[function]...)
+	case function == serialVolume && data[1] > 100:
+		d.respond(0x01)
+	default:
+		d.values[function] = append([]byte(nil), data...)
+		d.respond(0x00)
+	}
+	return len(cmd), nil
+}
+
+func (d *fakeDisplay) respond(answer byte, data ...byte) {
+	resp := []byte{serialResponse, answer, 0x00}
+	if len(data) > 0 {
+		resp[2] = byte(len(data) + 1)
+		resp = append(resp, data...)
+	}
+	d.resp.Write(append(resp, serialChecksum(resp)))
+}
+
+func (d *fakeDisplay) Read(b []byte) (int, error) { return d.resp.Read(b) }
+func (d *fakeDisplay) Close() error               { return nil }
+
+func TestSerialPowerAndInput(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	d, c := newFakeDisplay()
+
+	status, err := c.PowerStatus(ctx)
+	is.NoErr(err)
+	is.Equal("standby", status)
+	is.NoErr(c.SetPowerStatus(ctx, true))
+	is.Equal([]byte{0x01}, d.values[serialPower]) // display not turned on
+
+	input, err := c.SelectedInput(ctx)
+	is.NoErr(err)
+	is.Equal("extInput:hdmi?port=1", input)
+	is.NoErr(c.SetInput(ctx, "extInput:hdmi?port=2"))
+	is.Equal([]byte{0x04, 0x02}, d.values[serialInput])       // input not selected
+	is.True(errors.Is(c.SetInput(ctx, "tv:dvbt"), ErrSerial)) // expected error for tuner
+}
+
+func TestSerialVolumeAndMute(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	d, c := newFakeDisplay()
+
+	is.NoErr(c.SetAudioVolume(ctx, "speaker", "+5"))
+	is.Equal([]byte{0x01, 15}, d.values[serialVolume]) // volume not turned up
+	is.NoErr(c.SetAudioMute(ctx, true))
+	infos, err := c.VolumeInformation(ctx)
+	is.NoErr(err)
+	is.Equal([]VolumeInfo{{Target: "speaker", Volume: 15, Mute: true, MaxVolume: 100}}, infos)
+}
+
+func TestSerialErrorAnswer(t *testing.T) {
+	is := is.New(t)
+	d, c := newFakeDisplay()
+
+	_, err := c.send(context.Background(), serialControl, serialVolume, serialDirect, 101)
+	is.True(errors.Is(err, ErrSerial)) // expected error answer
+	is.Equal("serial control error: value over limit", err.Error())
+	is.Equal([]byte{0x01, 10}, d.values[serialVolume]) // volume changed
+}
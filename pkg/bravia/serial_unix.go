@@ -0,0 +1,38 @@
+//go:build linux || darwin
+
+package bravia
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// serialReadTimeout is how long to wait for the display to respond, in
+// tenths of a second as set in the termios VTIME setting.
+const serialReadTimeout = 20
+
+// openSerialPort opens the serial port device set to 9600 baud, 8 data
+// bits, no parity and 1 stop bit as used by the display, in raw mode with
+// a read timeout.
+func openSerialPort(device string) (io.ReadWriteCloser, error) {
+	f, err := os.OpenFile(device, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+	t := syscall.Termios{
+		Cflag:  syscall.B9600 | syscall.CS8 | syscall.CREAD | syscall.CLOCAL,
+		Ispeed: syscall.B9600,
+		Ospeed: syscall.B9600,
+	}
+	t.Cc[syscall.VMIN] = 0
+	t.Cc[syscall.VTIME] = serialReadTimeout
+	//nolint:gosec // unsafe is needed to pass termios to ioctl
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlSetTermios, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		f.Close() //nolint:errcheck,gosec // returning the ioctl error
+		return nil, fmt.Errorf("configure %s: %w", device, errno)
+	}
+	return f, nil
+}
@@ -0,0 +1,12 @@
+package bravia
+
+import (
+	"fmt"
+	"io"
+)
+
+// openSerialPort returns an error as serial ports are not supported on
+// Windows.
+func openSerialPort(device string) (io.ReadWriteCloser, error) {
+	return nil, fmt.Errorf("%w: serial ports are not supported on Windows", ErrSerial)
+}
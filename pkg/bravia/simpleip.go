@@ -0,0 +1,277 @@
+//nolint:goerr113 // dynamic errors are OK
+package bravia
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// simpleIPPort is the TCP port of Simple IP control on the TV.
+const simpleIPPort = "20060"
+
+// Simple IP control messages are 24 bytes: "*S", a message type, a four
+// character command, a 16 character parameter and a newline. The TV also
+// sends notifications ('N') of changes of state, which the client ignores.
+const (
+	simpleIPControl = 'C' // sets a value
+	simpleIPEnquiry = 'E' // queries a value
+	simpleIPAnswer  = 'A' // answers a control or enquiry
+
+	simpleIPQuery    = "################" // parameter of an enquiry
+	simpleIPError    = "FFFFFFFFFFFFFFFF" // answer if the command failed
+	simpleIPNotFound = "NNNNNNNNNNNNNNNN" // answer if there is no such value
+)
+
+// ErrSimpleIP is returned when the TV answers a Simple IP control command
+// with an error.
+var ErrSimpleIP = errors.New("simple IP control error")
+
+// simpleIPInputTypes maps the input types of the Simple IP control INPT
+// command to the kinds of extInput URIs used by the REST API.
+var simpleIPInputTypes = map[int]string{
+	1: "hdmi",
+	2: "scart",
+	3: "composite",
+	4: "component",
+	5: "widi",
+	6: "pc",
+}
+
+// SimpleIPClient controls a Sony Bravia TV with the Simple IP control
+// protocol over TCP port 20060, for TVs that have the REST API disabled or
+// firewalled. It only supports power, input and volume. Enable it on the
+// TV in Settings → Network → Home network → IP control → Simple IP control.
+type SimpleIPClient struct {
+	// Addr is the host:port of the TV.
+	Addr string
+
+	// Timeout is how long to wait for the TV to answer a command.
+	Timeout time.Duration
+
+	// LabelOverrides maps input labels to input URIs. As Simple IP control
+	// cannot get the labels set on the TV, these are the only labels.
+	LabelOverrides map[string]string
+}
+
+// NewSimpleIPClient returns a client for the TV with the given hostname,
+// using the Simple IP control port unless the hostname has a port.
+func NewSimpleIPClient(hostname string) *SimpleIPClient {
+	addr := hostname
+	if _, _, err := net.SplitHostPort(hostname); err != nil {
+		addr = net.JoinHostPort(hostname, simpleIPPort)
+	}
+	return &SimpleIPClient{Addr: addr, Timeout: 10 * time.Second}
+}
+
+// PowerStatus returns "active" if the TV is on and "standby" if it is off.
+func (c *SimpleIPClient) PowerStatus(ctx context.Context) (string, error) {
+	param, err := c.send(ctx, simpleIPEnquiry, "POWR", simpleIPQuery)
+	if err != nil {
+		return "", err
+	}
+	if n, _ := strconv.Atoi(param); n == 1 {
+		return "active", nil
+	}
+	return "standby", nil
+}
+
+// SettledPowerStatus returns the power status of the TV, which is always
+// settled as Simple IP control does not report transitional statuses.
+func (c *SimpleIPClient) SettledPowerStatus(ctx context.Context) (string, error) {
+	return c.PowerStatus(ctx)
+}
+
+// SetPowerStatus turns the TV on (status == true) or off (status == false).
+func (c *SimpleIPClient) SetPowerStatus(ctx context.Context, status bool) error {
+	n := 0
+	if status {
+		n = 1
+	}
+	_, err := c.send(ctx, simpleIPControl, "POWR", simpleIPNumber(n))
+	return err
+}
+
+// SelectedInput returns the URI of the input the TV is showing, in the form
+// used by the REST API such as "extInput:hdmi?port=1".
+func (c *SimpleIPClient) SelectedInput(ctx context.Context) (string, error) {
+	param, err := c.send(ctx, simpleIPEnquiry, "INPT", simpleIPQuery)
+	if err != nil {
+		return "", err
+	}
+	typ, _ := strconv.Atoi(param[:8])
+	port, _ := strconv.Atoi(param[8:])
+	kind, ok := simpleIPInputTypes[typ]
+	if !ok {
+		// Not an external input, such as the tuner or an app.
+		return "simpleip:input?param=" + param, nil
+	}
+	return fmt.Sprintf("extInput:%s?port=%d", kind, port), nil
+}
+
+// SetInput selects the external input with the given URI, such as
+// "extInput:hdmi?port=1".
+func (c *SimpleIPClient) SetInput(ctx context.Context, uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil || !strings.EqualFold(u.Scheme, "extInput") {
+		return fmt.Errorf("%w: simple IP control can only select external inputs, not %q", ErrSimpleIP, uri)
+	}
+	port, err := strconv.Atoi(u.Query().Get("port"))
+	if err != nil {
+		return fmt.Errorf("%w: input has no port: %q", ErrSimpleIP, uri)
+	}
+	for typ, kind := range simpleIPInputTypes {
+		if kind == u.Opaque {
+			param := fmt.Sprintf("%08d%08d", typ, port)
+			_, err := c.send(ctx, simpleIPControl, "INPT", param)
+			return err
+		}
+	}
+	return fmt.Errorf("%w: unknown input type %q", ErrSimpleIP, u.Opaque)
+}
+
+// Inputs returns the four HDMI inputs most TVs have, as Simple IP control
+// cannot list the inputs of the TV. Their labels are only set from
+// LabelOverrides and their connection status is unknown.
+func (c *SimpleIPClient) Inputs(_ context.Context) ([]Input, error) {
+	inputs := make([]Input, 0, 4)
+	for port := 1; port <= 4; port++ {
+		inputs = append(inputs, Input{
+			URI:   fmt.Sprintf("extInput:hdmi?port=%d", port),
+			Title: fmt.Sprintf("HDMI %d", port),
+		})
+	}
+	applyLabelOverrides(c.LabelOverrides, inputs)
+	return inputs, nil
+}
+
+// InputLabels returns a map of input URIs to labels and labels to URIs,
+// from LabelOverrides.
+func (c *SimpleIPClient) InputLabels(ctx context.Context) (map[string]string, error) {
+	inputs, err := c.Inputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return overriddenInputLabels(c.LabelOverrides, inputs), nil
+}
+
+// VolumeInformation returns the volume and mute state of the TV's speakers.
+func (c *SimpleIPClient) VolumeInformation(ctx context.Context) ([]VolumeInfo, error) {
+	volume, err := c.volume(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mute, err := c.send(ctx, simpleIPEnquiry, "AMUT", simpleIPQuery)
+	if err != nil {
+		return nil, err
+	}
+	muted, _ := strconv.Atoi(mute)
+	return []VolumeInfo{{Target: "speaker", Volume: volume, Mute: muted == 1, MaxVolume: 100}}, nil
+}
+
+// SetAudioVolume sets the volume of the TV to a number, e.g. "25", or
+// changes it when prefixed with "+" or "-", e.g. "+2". Simple IP control
+// has a single volume, so target must be "speaker" or empty.
+func (c *SimpleIPClient) SetAudioVolume(ctx context.Context, target, volume string) error {
+	if target != "" && target != "speaker" {
+		return fmt.Errorf("%w: simple IP control cannot set the volume of %s", ErrSimpleIP, target)
+	}
+	n, err := strconv.Atoi(volume)
+	if err != nil {
+		return fmt.Errorf("%w: invalid volume %q", ErrSimpleIP, volume)
+	}
+	if strings.HasPrefix(volume, "+") || strings.HasPrefix(volume, "-") {
+		current, err := c.volume(ctx)
+		if err != nil {
+			return err
+		}
+		n += current
+	}
+	if n < 0 {
+		n = 0
+	} else if n > 100 {
+		n = 100
+	}
+	_, err = c.send(ctx, simpleIPControl, "VOLU", simpleIPNumber(n))
+	return err
+}
+
+// SetAudioMute mutes (mute == true) or unmutes the TV.
+func (c *SimpleIPClient) SetAudioMute(ctx context.Context, mute bool) error {
+	n := 0
+	if mute {
+		n = 1
+	}
+	_, err := c.send(ctx, simpleIPControl, "AMUT", simpleIPNumber(n))
+	return err
+}
+
+func (c *SimpleIPClient) volume(ctx context.Context) (int, error) {
+	param, err := c.send(ctx, simpleIPEnquiry, "VOLU", simpleIPQuery)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid volume %q", ErrSimpleIP, param)
+	}
+	return n, nil
+}
+
+// send sends a command to the TV on a new connection and returns the
+// parameter of its answer, skipping any notifications sent before it.
+func (c *SimpleIPClient) send(ctx context.Context, typ byte, cmd, param string) (string, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return "", fmt.Errorf("simple IP control: %w", err)
+	}
+	defer conn.Close() //nolint:errcheck // nothing to do
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline) //nolint:errcheck,gosec // the read or write fails anyway
+	}
+
+	msg := fmt.Sprintf("*S%c%s%s\n", typ, cmd, param)
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		return "", fmt.Errorf("simple IP control: %w", err)
+	}
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("simple IP control: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) != 23 || !strings.HasPrefix(line, "*S") {
+			return "", fmt.Errorf("%w: invalid answer %q", ErrSimpleIP, line)
+		}
+		if line[2] != simpleIPAnswer || line[3:7] != cmd {
+			continue // a notification or the answer to another command
+		}
+		diag.TVResponse("simpleip", cmd, []byte(line), nil)
+		switch answer := line[7:]; answer {
+		case simpleIPError:
+			return "", fmt.Errorf("%w: %s failed", ErrSimpleIP, cmd)
+		case simpleIPNotFound:
+			return "", fmt.Errorf("%w: %s not available", ErrSimpleIP, cmd)
+		default:
+			return answer, nil
+		}
+	}
+}
+
+// simpleIPNumber formats n as a Simple IP control parameter.
+func simpleIPNumber(n int) string {
+	return fmt.Sprintf("%016d", n)
+}
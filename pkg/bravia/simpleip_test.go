@@ -0,0 +1,122 @@
+package bravia
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// fakeSimpleIPTV is a minimal Simple IP control server for tests. It keeps
+// the power, input and volume state and notifies power changes before
+// answering, as TVs do.
+type fakeSimpleIPTV struct {
+	mu    sync.Mutex
+	state map[string]string
+}
+
+func newFakeSimpleIPTV(t *testing.T) (*fakeSimpleIPTV, *SimpleIPClient) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	tv := &fakeSimpleIPTV{state: map[string]string{
+		"POWR": "0000000000000000",
+		"INPT": "0000000100000001",
+		"VOLU": "0000000000000010",
+		"AMUT": "0000000000000000",
+	}}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go tv.serve(conn)
+		}
+	}()
+	return tv, NewSimpleIPClient(l.Addr().String())
+}
+
+func (tv *fakeSimpleIPTV) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		typ, cmd, param := line[2], line[3:7], line[7:23]
+		tv.mu.Lock()
+		answer, ok := tv.state[cmd]
+		switch {
+		case !ok:
+			answer = simpleIPError
+		case typ == 'C':
+			tv.state[cmd] = param
+			answer = "0000000000000000"
+			fmt.Fprintf(conn, "*SN%s%s\n", cmd, param)
+		}
+		tv.mu.Unlock()
+		fmt.Fprintf(conn, "*SA%s%s\n", cmd, answer)
+	}
+}
+
+func TestSimpleIPPowerAndInput(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	tv, c := newFakeSimpleIPTV(t)
+
+	status, err := c.PowerStatus(ctx)
+	is.NoErr(err)
+	is.Equal("standby", status)
+	is.NoErr(c.SetPowerStatus(ctx, true))
+	is.Equal("0000000000000001", tv.state["POWR"]) // TV not turned on
+	status, err = c.PowerStatus(ctx)
+	is.NoErr(err)
+	is.Equal("active", status)
+
+	input, err := c.SelectedInput(ctx)
+	is.NoErr(err)
+	is.Equal("extInput:hdmi?port=1", input)
+	is.NoErr(c.SetInput(ctx, "extInput:hdmi?port=3"))
+	is.Equal("0000000100000003", tv.state["INPT"])              // input not selected
+	is.True(errors.Is(c.SetInput(ctx, "tv:dvbt"), ErrSimpleIP)) // expected error for tuner
+}
+
+func TestSimpleIPVolume(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	tv, c := newFakeSimpleIPTV(t)
+
+	is.NoErr(c.SetAudioVolume(ctx, "speaker", "+5"))
+	is.Equal("0000000000000015", tv.state["VOLU"]) // volume not turned up
+	is.NoErr(c.SetAudioVolume(ctx, "", "-20"))
+	is.Equal("0000000000000000", tv.state["VOLU"]) // volume not clamped
+	is.NoErr(c.SetAudioVolume(ctx, "speaker", "25"))
+	infos, err := c.VolumeInformation(ctx)
+	is.NoErr(err)
+	is.Equal([]VolumeInfo{{Target: "speaker", Volume: 25, MaxVolume: 100}}, infos)
+
+	err = c.SetAudioVolume(ctx, "headphone", "1")
+	is.True(errors.Is(err, ErrSimpleIP)) // expected error for headphone
+}
+
+func TestSimpleIPLabels(t *testing.T) {
+	is := is.New(t)
+	_, c := newFakeSimpleIPTV(t)
+	c.LabelOverrides = map[string]string{"desk": "extInput:hdmi?port=2"}
+
+	labels, err := c.InputLabels(context.Background())
+	is.NoErr(err)
+	is.Equal("extInput:hdmi?port=2", labels["desk"])
+	is.Equal("desk", labels["extInput:hdmi?port=2"])
+	is.Equal("", labels["extInput:hdmi?port=1"]) // unlabelled input should have no label
+}
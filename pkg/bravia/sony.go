@@ -0,0 +1,898 @@
+//nolint:goerr113 // dynamic errors are OK
+package bravia
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RESTClient talks to a Sony Bravia TV using the [REST IP control protocol].
+//
+// The full API is not implemented, only just enough to power the set on and
+// off based on a condition of an input being selected, and to switch inputs.
+// This allows the display to be turned off if X11 XSS says the screen has been
+// blanked, and that can be filtered to only turn if off if a particular input
+// is selected. The screen should not be turned off if it is not displaying the
+// screen that was just blanked. When XSS says a screen has been unblanked, if
+// the screen is off, turn it on and select a particular input.
+//
+// [REST IP control protocol]: https://pro-bravia.sony.net/develop/integrate/rest-api/spec/index.html
+type RESTClient struct {
+	// BaseURL is the URL to address the TV set to be controlled. It will
+	// typically be a hostname and the `/sony` path.
+	BaseURL string
+
+	// PSK is the Pre-Shared Key configured on the TV set. It is
+	// essentially a password on the service (that is sent in plain-text on
+	// the network).
+	PSK string
+
+	// MAC is the MAC address of the TV. If set, a Wake-on-LAN magic
+	// packet is sent to turn on the TV if it cannot be reached, such as
+	// when eco mode disables network standby.
+	MAC string
+
+	// AuthCookie is the auth cookie from pairing with the TV using a PIN
+	// (see [RESTClient.Register]), used instead of a PSK. When it
+	// expires, requests fail with [ErrPairingExpired] until the TV is
+	// paired again.
+	AuthCookie string
+
+	// RenderingControlURL is the control URL of the TV's UPnP
+	// RenderingControl service, used for the volume and mute of the
+	// speakers if the TV rejects the audio REST API, as older firmware
+	// does. If empty, UPnP is not used.
+	RenderingControlURL string
+
+	// LabelOverrides maps input labels to input URIs, overriding the
+	// labels set on the TV or adding labels for inputs without one.
+	LabelOverrides map[string]string
+
+	// InputsTTL is how long [RESTClient.Inputs] caches the inputs of the
+	// TV, as fetching them is slow and they rarely change. Zero disables
+	// caching. Use [RESTClient.InvalidateInputs] to refetch them sooner.
+	InputsTTL time.Duration
+
+	// HTTPClient makes the requests to the TV. Use [RESTClient.Use] to
+	// add middleware rather than replacing it, to keep its timeout.
+	HTTPClient *http.Client
+
+	// Retry is how requests that fail with a transient error are retried.
+	// The zero value does not retry.
+	Retry RetryPolicy
+
+	// Breaker fails requests fast while the TV is unreachable. The zero
+	// value never does.
+	Breaker CircuitBreaker
+
+	// Verify is how changes of power status and input are verified. The
+	// zero value does not verify them.
+	Verify VerifyPolicy
+
+	// IdempotentPower makes [RESTClient.SetPowerStatus] get the power
+	// status first and do nothing if the TV is already on or off as
+	// requested, rather than have some TVs return an error.
+	IdempotentPower bool
+
+	// versions holds the API version negotiated for each method, keyed by
+	// "service.method". It is nil until negotiated. versionsFailed is when
+	// negotiation last failed.
+	versions       map[string]string
+	versionsFailed time.Time
+	versionsMu     sync.Mutex
+
+	// tlsConfig is the TLS config set by [RESTClient.SkipTLSVerify], kept
+	// for WebSocket connections as the transport may be wrapped by
+	// middleware. It is nil to use the default.
+	tlsConfig *tls.Config
+
+	// irccCodes is the remote control key map of the TV, fetched by
+	// [RESTClient.IRCCCodes]. It is nil until fetched.
+	irccCodes map[string]string
+
+	// inputs caches the inputs of the TV for InputsTTL, without the label
+	// overrides applied.
+	inputs       []Input
+	inputsExpiry time.Time
+	inputsMu     sync.Mutex
+}
+
+var (
+	// ErrHTTPStatus is a sentinel error for all HTTP status-based errors, It is
+	// intended to be used with `errors.Is(err, ErrHTTPStatus)`.
+	ErrHTTPStatus = errors.New("http")
+
+	// ErrSony is a sentinel error for errors returned by the REST IP
+	// control protocol in the body of a response.
+	ErrSony = errors.New("sony")
+
+	// ErrNoResult is returned when the TV responds to a method without
+	// the result the method should have.
+	ErrNoResult = errors.New("no result")
+)
+
+// noResult returns an [ErrNoResult] error for method.
+func noResult(method string) error {
+	return fmt.Errorf("%s: %w", method, ErrNoResult)
+}
+
+// HTTPStatusError captures the status code of a HTTP response that is to be
+// treated as an error. It is not necessarily just a 4xx or 5xx error - it
+// could be any status code that is unhandled.
+type HTTPStatusError int
+
+// Error formats a HTTP status code as its text description as per
+// http.StatusText().
+func (err HTTPStatusError) Error() string {
+	return http.StatusText(int(err))
+}
+
+// Unwrap returns ErrHTTP for all errors so HTTPStatusErrors can be checked
+// with `errors.Is(err, ErrHTTP)`, instead of the two-line
+// `var errHTTP HTTPStatusErr; errorsAs(err, &errHTTP)`. The latter is still
+// possible if the error type is required.
+func (err HTTPStatusError) Unwrap() error {
+	return ErrHTTPStatus
+}
+
+// SonyError captures an error returned by the Sony REST IP control protocol
+// as an error returned in the payload of an HTTP response. These errors are
+// returned as an error code and a string describing it.
+type SonyError struct {
+	Code    int
+	Message string
+}
+
+// NewSonyError returns a SonyError from the response. The []any parameter
+// is expected to have two elements, a float64 (code) and a string (message).
+// If the size or types are not as just described, a InvalidResponseError
+// is returned instead with the body that could not be parsed.
+func NewSonyError(resp []any, body []byte) error {
+	if len(resp) != 2 {
+		return InvalidResponseError{
+			wrapped: errors.New("wrong number of error parameters"),
+			Body:    body,
+		}
+	}
+	code, ok := resp[0].(float64) // float64 as JSON decodes numbers as float64
+	if !ok {
+		return InvalidResponseError{
+			wrapped: errors.New("first parameter is not a number"),
+			Body:    body,
+		}
+	}
+	msg, ok := resp[1].(string)
+	if !ok {
+		return InvalidResponseError{
+			wrapped: errors.New("second parameter is not a string"),
+			Body:    body,
+		}
+	}
+	return SonyError{Code: int(code), Message: msg}
+}
+
+// Error returns the message portion of the error.
+func (err SonyError) Error() string {
+	return err.Message
+}
+
+// Unwrap returns ErrSony for all errors so SonyErrors can easily be checked
+// with `errors.Is(err, ErrSony)` for when you don't care about the specific
+// code or message of the error (when you would use `errors.As()`).
+func (err SonyError) Unwrap() error {
+	return ErrSony
+}
+
+// Codes of the common [SonyError]s as defined by the protocol docs. Use
+// [IsSonyCode] or the predicates for specific errors, such as
+// [IsDisplayOff], to check for them.
+const (
+	SonyCodeAny                  = 1
+	SonyCodeTimeout              = 2
+	SonyCodeIllegalArgument      = 3
+	SonyCodeIllegalRequest       = 5
+	SonyCodeIllegalState         = 7
+	SonyCodeNoSuchMethod         = 12
+	SonyCodeUnsupportedVersion   = 14
+	SonyCodeUnsupportedOperation = 15
+	SonyCodeUnauthorized         = 401
+	SonyCodeForbidden            = 403
+	SonyCodeNotFound             = 404
+	SonyCodeNotImplemented       = 501
+	SonyCodeDisplayIsTurnedOff   = 40005
+)
+
+// IsSonyCode returns true if err is, or wraps, a [SonyError] with one of the
+// given codes.
+func IsSonyCode(err error, codes ...int) bool {
+	var serr SonyError
+	if !errors.As(err, &serr) {
+		return false
+	}
+	for _, code := range codes {
+		if serr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDisplayOff returns true if err is the error the TV returns for requests
+// it cannot handle while its display is off, such as for the selected input.
+func IsDisplayOff(err error) bool {
+	return IsSonyCode(err, SonyCodeDisplayIsTurnedOff)
+}
+
+// IsIllegalArgument returns true if err is the error the TV returns when it
+// rejects a parameter value, such as an input that does not exist.
+func IsIllegalArgument(err error) bool {
+	return IsSonyCode(err, SonyCodeIllegalArgument)
+}
+
+// IsForbidden returns true if err shows the TV refused the request because
+// of the PSK or auth cookie, which it does either as a Sony error or an HTTP
+// status.
+func IsForbidden(err error) bool {
+	var herr HTTPStatusError
+	return IsSonyCode(err, SonyCodeForbidden) || (errors.As(err, &herr) && herr == http.StatusForbidden)
+}
+
+// InvalidResponseError captures a response from the TV that could not be parsed
+// as expected. It wraps an error describing the error condition and the body that
+// could not be parsed.
+type InvalidResponseError struct {
+	wrapped error
+	Body    []byte
+}
+
+// Error returns the error string of the wrapped error with the body appended.
+func (err InvalidResponseError) Error() string {
+	return fmt.Sprintf("%v\nBody: %s", err.wrapped, string(err.Body))
+}
+
+// Unwrap returns the error that err wraps.
+func (err InvalidResponseError) Unwrap() error {
+	return err.wrapped
+}
+
+// NewRESTClient creates and returns a BraviaClient reachable at the given
+// hostname, using the Pre-Shared Key given as psk as the password. If psk is
+// the empty string, it is not used. The hostname may be prefixed with
+// "https://" to talk to the TV over TLS so the PSK is not sent in plain-text,
+// or "http://", which is the default.
+func NewRESTClient(hostname, psk string) *RESTClient {
+	diag.AddSecret(psk)
+	scheme := "http://"
+	if strings.Contains(hostname, "://") {
+		scheme = ""
+	}
+	baseURL := scheme + hostname + "/sony"
+	return &RESTClient{
+		BaseURL:             baseURL,
+		PSK:                 psk,
+		RenderingControlURL: renderingControlURL(baseURL),
+		HTTPClient: &http.Client{
+			// Timeout after 10s. Arguably that's too long.
+			// This doesn't really need to be configurable.
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// SkipTLSVerify stops the client verifying the TLS certificate of the TV
+// when talking to it over https, as TVs typically have a self-signed
+// certificate. It replaces the client's HTTP transport, so call it before
+// [RESTClient.Use].
+func (c *RESTClient) SkipTLSVerify() {
+	c.tlsConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // opted in by the caller
+	c.HTTPClient.Transport = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: c.tlsConfig,
+	}
+}
+
+// Middleware wraps an [http.RoundTripper] to observe or change the requests
+// made to the TV and their responses, such as to log, trace or mock them.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an [http.RoundTripper] implemented by a function, for
+// writing [Middleware].
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req). RoundTrip implements [http.RoundTripper].
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Use installs middleware around the client's HTTP transport, keeping the
+// rest of the HTTP client, such as its timeout. The first middleware given
+// is the outermost, seeing requests first and responses last. Middleware
+// does not see the WebSocket connections of [RESTClient.Subscribe].
+func (c *RESTClient) Use(mw ...Middleware) {
+	rt := c.HTTPClient.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for i := len(mw) - 1; i >= 0; i-- {
+		rt = mw[i](rt)
+	}
+	c.HTTPClient.Transport = rt
+}
+
+const (
+	// powerSettlePollInterval is how often the power status is polled
+	// while waiting for a transitional power status to settle.
+	powerSettlePollInterval = 500 * time.Millisecond
+
+	// powerSettleTimeout is how long to wait for a transitional power
+	// status to settle.
+	powerSettleTimeout = 15 * time.Second
+)
+
+// empty is a type to be used with `post[T]()` for when a response is not returned.
+// e.g. `_, err := post[empty](...)`.
+type empty struct{}
+
+// PowerStatus returns the power status of the TV - i.e. whether it is on
+// or off. On is returned as "active", off as "standby". If an error occurred
+// communicating with the TV, an error is returned with an empty string status.
+func (c *RESTClient) PowerStatus(ctx context.Context) (string, error) {
+	type powerStatusResponse struct {
+		Status string `json:"status"`
+	}
+	resp, err := post[powerStatusResponse](ctx, c, "system", "getPowerStatus", "1.0", nil)
+	if err != nil {
+		return "", err
+	}
+	return resp.Status, nil
+}
+
+// PowerStatusDetails returns all the fields of the TV's power status as
+// returned by the TV. As well as "status", some models return additional
+// fields, such as why the TV is in standby.
+func (c *RESTClient) PowerStatusDetails(ctx context.Context) (map[string]any, error) {
+	resp, err := post[map[string]any](ctx, c, "system", "getPowerStatus", "1.0", nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return map[string]any{}, nil
+	}
+	return *resp, nil
+}
+
+// SettledPowerStatus returns the power status of the TV like
+// [RESTClient.PowerStatus], but if the TV reports a transitional status
+// (e.g. "shuttingDown" or "startingUp" on some models) it waits for the TV to
+// settle as "active" or "standby". An error is returned if it has not settled
+// within powerSettleTimeout.
+func (c *RESTClient) SettledPowerStatus(ctx context.Context) (string, error) {
+	deadline := time.Now().Add(powerSettleTimeout)
+	for {
+		status, err := c.PowerStatus(ctx)
+		if err != nil || IsSettledPowerStatus(status) {
+			return status, err
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("power status still %q after %v", status, powerSettleTimeout)
+		}
+		diag.Event("power status is %q, waiting for it to settle", status)
+		if err := sleep(ctx, powerSettlePollInterval); err != nil {
+			return "", err
+		}
+	}
+}
+
+// IsSettledPowerStatus returns true if status is not a transitional power
+// status. Only "active" and "standby" are settled; anything else is
+// considered to be on its way to one of these.
+func IsSettledPowerStatus(status string) bool {
+	return status == "active" || status == "standby"
+}
+
+// SetPowerStatus sets the TV power status to on (status == true) or off
+// (status == false). If the TV cannot be reached when turning it on and its
+// MAC address is known, it is woken with Wake-on-LAN first. Some firmware
+// rejects setPowerStatus while still accepting remote control keys, so if
+// the TV reports that the method is not supported, the IRCC power key is
+// sent instead. The change is verified as per [RESTClient.Verify], and
+// skipped as per [RESTClient.IdempotentPower].
+func (c *RESTClient) SetPowerStatus(ctx context.Context, status bool) error {
+	want := "standby"
+	if status {
+		want = "active"
+	}
+	if c.IdempotentPower {
+		// If the TV cannot be reached, it is set anyway to wake it.
+		if power, err := c.SettledPowerStatus(ctx); err == nil && power == want {
+			diag.Event("TV is already %s", want)
+			return nil
+		}
+	}
+	return c.Verify.do(ctx, "power "+want, func() error {
+		return c.setPowerStatus(ctx, status)
+	}, func() (bool, error) {
+		power, err := c.SettledPowerStatus(ctx)
+		return power == want, err
+	})
+}
+
+func (c *RESTClient) setPowerStatus(ctx context.Context, status bool) error {
+	param := map[string]bool{"status": status}
+	wol := status && c.MAC != ""
+	pctx := ctx
+	if wol {
+		// Wake the TV straight away rather than retrying first.
+		pctx = withRetryable(ctx, isServerError)
+	}
+	_, err := post[empty](pctx, c, "system", "setPowerStatus", "1.0", param)
+	if wol && IsUnreachable(err) {
+		if err := c.wake(ctx); err != nil {
+			return err
+		}
+		_, err = post[empty](ctx, c, "system", "setPowerStatus", "1.0", param)
+	}
+	if IsUnsupported(err) {
+		diag.Event("setPowerStatus unsupported (%v), falling back to IRCC power key", err)
+		return c.irccSetPowerStatus(ctx, status)
+	}
+	return err
+}
+
+// PlayingContentInfo describes what the TV is currently showing. For
+// external inputs, only the source, URI and title are set. For tuner
+// channels, the channel and current program are described too.
+type PlayingContentInfo struct {
+	Source           string `json:"source"`
+	URI              string `json:"uri"`
+	Title            string `json:"title"`
+	DispNum          string `json:"dispNum,omitempty"`
+	ProgramTitle     string `json:"programTitle,omitempty"`
+	ProgramMediaType string `json:"programMediaType,omitempty"`
+	StartDateTime    string `json:"startDateTime,omitempty"`
+	DurationSec      int    `json:"durationSec,omitempty"`
+	TripletStr       string `json:"tripletStr,omitempty"`
+}
+
+// PlayingContent returns what the TV is currently showing.
+func (c *RESTClient) PlayingContent(ctx context.Context) (*PlayingContentInfo, error) {
+	return post[PlayingContentInfo](ctx, c, "avContent", "getPlayingContentInfo", "1.0", nil)
+}
+
+// SelectedInput returns the TVs currently selected input. Inputs are described
+// in the form of a URI. The empty string is returned if the TV does not say
+// what it is playing.
+func (c *RESTClient) SelectedInput(ctx context.Context) (string, error) {
+	selected, err := c.PlayingContent(ctx)
+	if err != nil || selected == nil {
+		return "", err
+	}
+	return selected.URI, nil
+}
+
+// Input is an external input of the TV, such as an HDMI port.
+type Input struct {
+	URI        string `json:"uri"`
+	Title      string `json:"title"`
+	Label      string `json:"label"`
+	Icon       string `json:"icon"`
+	Connection bool   `json:"connection"`
+	Status     string `json:"status,omitempty"`
+}
+
+// Inputs returns all the external inputs of the TV, with their labels
+// overridden by [RESTClient.LabelOverrides]. They are cached for
+// [RESTClient.InputsTTL].
+func (c *RESTClient) Inputs(ctx context.Context) ([]Input, error) {
+	c.inputsMu.Lock()
+	cached := c.inputs
+	if time.Now().After(c.inputsExpiry) {
+		cached = nil
+	}
+	c.inputsMu.Unlock()
+
+	if cached == nil {
+		resp, err := post[[]Input](ctx, c, "avContent", "getCurrentExternalInputsStatus", "1.0", nil)
+		if err != nil || resp == nil {
+			return nil, err
+		}
+		cached = *resp
+		if c.InputsTTL > 0 {
+			c.inputsMu.Lock()
+			c.inputs, c.inputsExpiry = cached, time.Now().Add(c.InputsTTL)
+			c.inputsMu.Unlock()
+		}
+	}
+	inputs := append([]Input(nil), cached...)
+	applyLabelOverrides(c.LabelOverrides, inputs)
+	return inputs, nil
+}
+
+// InvalidateInputs discards the inputs cached by [RESTClient.Inputs], so
+// they are fetched from the TV the next time they are needed, such as when
+// the TV notifies that an input was connected or relabelled.
+func (c *RESTClient) InvalidateInputs() {
+	c.inputsMu.Lock()
+	defer c.inputsMu.Unlock()
+	c.inputs = nil
+}
+
+// InputLabels returns a map of all the inputs available, mapping each
+// input's URI to its label, and its label to its URI if it has a label. This
+// allows inputs to be looked up by either URI or label. Label overrides for
+// content that is not an external input, such as a tuner, are included.
+func (c *RESTClient) InputLabels(ctx context.Context) (map[string]string, error) {
+	inputs, err := c.Inputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return overriddenInputLabels(c.LabelOverrides, inputs), nil
+}
+
+// InputLabelMap returns a map of the URIs of inputs to their labels, and of
+// their labels to their URIs for those that have a label.
+func InputLabelMap(inputs []Input) map[string]string {
+	result := map[string]string{}
+	for _, input := range inputs {
+		result[input.URI] = input.Label
+		if input.Label != "" {
+			result[input.Label] = input.URI
+		}
+	}
+	return result
+}
+
+// SetInput sets the current input of the TV to the given URI. The change is
+// verified as per [RESTClient.Verify].
+func (c *RESTClient) SetInput(ctx context.Context, uri string) error {
+	param := map[string]string{"uri": uri}
+	return c.Verify.do(ctx, "input "+uri, func() error {
+		_, err := post[empty](ctx, c, "avContent", "setPlayContent", "1.0", param)
+		return err
+	}, func() (bool, error) {
+		selected, err := c.SelectedInput(ctx)
+		return isSelectedInput(selected, uri), err
+	})
+}
+
+// PowerSavingMode returns the TV's power saving mode: "off", "low", "high"
+// or "pictureOff".
+func (c *RESTClient) PowerSavingMode(ctx context.Context) (string, error) {
+	type powerSavingModeResponse struct {
+		Mode string `json:"mode"`
+	}
+	resp, err := post[powerSavingModeResponse](ctx, c, "system", "getPowerSavingMode", "1.0", nil)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil {
+		return "", noResult("getPowerSavingMode")
+	}
+	return resp.Mode, nil
+}
+
+// SetPowerSavingMode sets the TV's power saving mode to "off", "low", "high"
+// or "pictureOff". "pictureOff" turns off the panel while audio and apps
+// keep running.
+func (c *RESTClient) SetPowerSavingMode(ctx context.Context, mode string) error {
+	param := map[string]string{"mode": mode}
+	_, err := post[empty](ctx, c, "system", "setPowerSavingMode", "1.0", param)
+	return err
+}
+
+// VolumeInfo describes the volume of one of the TV's audio outputs, such as
+// "speaker" or "headphone".
+type VolumeInfo struct {
+	Target    string `json:"target"`
+	Volume    int    `json:"volume"`
+	Mute      bool   `json:"mute"`
+	MaxVolume int    `json:"maxVolume"`
+	MinVolume int    `json:"minVolume"`
+}
+
+// VolumeInformation returns the volume of each of the TV's audio outputs.
+func (c *RESTClient) VolumeInformation(ctx context.Context) ([]VolumeInfo, error) {
+	info, err := post[[]VolumeInfo](ctx, c, "audio", "getVolumeInformation", "1.0", nil)
+	if c.useRenderingControl(err, "") {
+		diag.Event("getVolumeInformation unsupported (%v), falling back to UPnP", err)
+		return c.upnpVolumeInformation(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, noResult("getVolumeInformation")
+	}
+	return *info, nil
+}
+
+// SetAudioVolume sets the volume of the given audio output ("speaker" or
+// "headphone"). The volume is a number as a string, e.g. "25", or a relative
+// change when prefixed with "+" or "-", e.g. "+2". If target is the empty
+// string, the volume of all outputs is set. Relative changes are not
+// retried, as a retry after a lost response would change it twice.
+func (c *RESTClient) SetAudioVolume(ctx context.Context, target, volume string) error {
+	param := map[string]string{"target": target, "volume": volume}
+	if strings.HasPrefix(volume, "+") || strings.HasPrefix(volume, "-") {
+		ctx = withRetryable(ctx, neverRetry)
+	}
+	_, err := post[empty](ctx, c, "audio", "setAudioVolume", "1.0", param)
+	if c.useRenderingControl(err, target) {
+		diag.Event("setAudioVolume unsupported (%v), falling back to UPnP", err)
+		return c.upnpSetVolume(ctx, volume)
+	}
+	return err
+}
+
+// SetAudioMute mutes (mute == true) or unmutes the TV.
+func (c *RESTClient) SetAudioMute(ctx context.Context, mute bool) error {
+	param := map[string]bool{"status": mute}
+	_, err := post[empty](ctx, c, "audio", "setAudioMute", "1.0", param)
+	if c.useRenderingControl(err, "") {
+		diag.Event("setAudioMute unsupported (%v), falling back to UPnP", err)
+		return c.upnpSetMute(ctx, mute)
+	}
+	return err
+}
+
+// App is an application installed on the TV.
+type App struct {
+	Title string `json:"title"`
+	URI   string `json:"uri"`
+	Icon  string `json:"icon"`
+	Data  string `json:"data"`
+}
+
+// Apps returns the applications installed on the TV.
+func (c *RESTClient) Apps(ctx context.Context) ([]App, error) {
+	apps, err := post[[]App](ctx, c, "appControl", "getApplicationList", "1.0", nil)
+	if err != nil {
+		return nil, err
+	}
+	if apps == nil {
+		return nil, noResult("getApplicationList")
+	}
+	return *apps, nil
+}
+
+// SetActiveApp launches the application with the given URI. It is not
+// retried, as the app may have been launched when the request failed.
+func (c *RESTClient) SetActiveApp(ctx context.Context, uri string) error {
+	param := map[string]string{"uri": uri}
+	ctx = withRetryable(ctx, neverRetry) // a retry could launch the app twice
+	_, err := post[empty](ctx, c, "appControl", "setActiveApp", "1.0", param)
+	return err
+}
+
+// TerminateApps terminates all running applications that can be
+// terminated. It is not retried, as like launching apps it is not
+// idempotent.
+func (c *RESTClient) TerminateApps(ctx context.Context) error {
+	ctx = withRetryable(ctx, neverRetry)
+	_, err := post[empty](ctx, c, "appControl", "terminateApps", "1.0", nil)
+	return err
+}
+
+// SystemInfo is the system information of the TV.
+type SystemInfo struct {
+	Product    string `json:"product"`
+	Name       string `json:"name"`
+	Model      string `json:"model"`
+	Serial     string `json:"serial"`
+	MACAddr    string `json:"macAddr"`
+	Generation string `json:"generation"`
+	Region     string `json:"region"`
+	Area       string `json:"area"`
+	Language   string `json:"language"`
+}
+
+// SystemInformation returns the TV's model, serial number, MAC address,
+// firmware generation and region.
+func (c *RESTClient) SystemInformation(ctx context.Context) (*SystemInfo, error) {
+	return post[SystemInfo](ctx, c, "system", "getSystemInformation", "1.0", nil)
+}
+
+// Reboot reboots the TV.
+func (c *RESTClient) Reboot(ctx context.Context) error {
+	ctx = withRetryable(ctx, neverRetry) // a retry could reboot the TV twice
+	_, err := post[empty](ctx, c, "system", "requestReboot", "1.0", nil)
+	return err
+}
+
+// post[T] executes a REST IP control command returning the result of type T or
+// an error if the command did not succeed. If no data was returned from the
+// HTTP call, the returned value will be nil. The `empty` type can be used when
+// no response is expected:
+//
+//	_, err := post[empty](ctx, client, service, method, version, params)
+//
+// The protocol docs define service, method and version. Params is any value
+// that can be marshaled as JSON and will be passed in the `params` part of the
+// JSON payload of the HTTP request. Note that the method argument is not an
+// HTTP method, but a method as defined in the protocol docs.
+//
+// The `result` field in the JSON response will be unmarshaled into a variable
+// of type T and returned.
+//
+// The version is the base version of the method that the params and T are
+// written for. A newer compatible version is used if the TV supports one (see
+// [RESTClient.apiVersion]), falling back to the base version if the TV
+// rejects it.
+func post[T any](ctx context.Context, c *RESTClient, service, method, version string, params any) (*T, error) {
+	v := c.apiVersion(ctx, service, method, version)
+	resp, err := postVersion[T](ctx, c, service, method, v, params)
+	if v != version && isUnsupportedVersion(err) {
+		diag.Event("%s.%s version %s unsupported, falling back to %s", service, method, v, version)
+		c.pinVersion(service, method, version)
+		return postVersion[T](ctx, c, service, method, version, params)
+	}
+	return resp, err
+}
+
+// Call calls a method of the REST IP control protocol with exactly the
+// given version, for methods without a [RESTClient] method of their own.
+// params is marshaled as the method's parameter and may be nil. The first
+// element of the result is returned as raw JSON, or nil if there is none.
+// As the method may not be idempotent, the call is not retried.
+func (c *RESTClient) Call(ctx context.Context, service, method, version string, params any) (json.RawMessage, error) {
+	ctx = withRetryable(ctx, neverRetry)
+	result, err := postVersion[json.RawMessage](ctx, c, service, method, version, params)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return *result, nil
+}
+
+// postVersion[T] is [post] without version negotiation.
+func postVersion[T any](ctx context.Context, c *RESTClient, service, method, version string, params any) (*T, error) {
+	results, err := postResults[T](ctx, c, service, method, version, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil //nolint:nilnil // T can be `empty` for no result expected. not an error.
+	}
+	return &results[0], nil
+}
+
+// postResults[T] is [postVersion] for the few methods that return more than
+// one element in the `result` field of the JSON response. Each element is
+// unmarshaled into a T, which will typically be [json.RawMessage] as the
+// elements are of different types.
+func postResults[T any](ctx context.Context, c *RESTClient, service, method, version string, params any) ([]T, error) {
+	var body []byte
+	// Each attempt is recorded by the breaker, so it opens without waiting
+	// for all the retries of each request.
+	err := c.Retry.do(ctx, service+"."+method, func() error {
+		return c.guard(func() error {
+			var err error
+			body, err = c.postBody(ctx, service, method, version, params)
+			return err
+		})
+	})
+	diag.TVResponse(service, method, body, err)
+	if err != nil {
+		return nil, err
+	}
+	bresp, err := decodeResp[T](body)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return bresp, nil
+}
+
+// postBody makes a request to the TV and returns the body of the response.
+// If the TV no longer accepts the auth cookie, [ErrPairingExpired] is
+// returned.
+func (c *RESTClient) postBody(ctx context.Context, service, method, version string, params any) ([]byte, error) {
+	brq, err := c.newRequest(ctx, service, method, version, params)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	body, err := c.do(brq)
+	if isAuthError(err) && c.AuthCookie != "" {
+		return nil, fmt.Errorf("%w: %v", ErrPairingExpired, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	return body, nil
+}
+
+func (c *RESTClient) newRequest(ctx context.Context, service, method, version string, params any) (*http.Request, error) {
+	payload := struct {
+		Method  string `json:"method"`
+		Version string `json:"version"`
+		ID      int    `json:"id"`
+		Params  []any  `json:"params"`
+	}{
+		Method:  method,
+		Version: version,
+		Params:  makeParams(params),
+		ID:      1, // ID 0 is invalid, but we don't care about this
+	}
+	u, err := url.JoinPath(c.BaseURL, service)
+	if err != nil {
+		return nil, fmt.Errorf("join path: %w", err)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	c.authorize(req)
+	return req, nil
+}
+
+// authorize adds the PSK or auth cookie to req.
+func (c *RESTClient) authorize(req *http.Request) {
+	if c.PSK != "" {
+		req.Header.Add("X-Auth-PSK", c.PSK)
+	}
+	if c.AuthCookie != "" {
+		req.AddCookie(&http.Cookie{Name: "auth", Value: c.AuthCookie})
+	}
+}
+
+func (c *RESTClient) do(req *http.Request) ([]byte, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if req.Context().Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			// The HTTP client timed out, not the caller's context, so
+			// the TV is unreachable (see [IsUnreachable]).
+			return nil, fmt.Errorf("%w: %v", errNoResponse, err) //nolint:errorlint // not a context error
+		}
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // When does this close ever fail meaningfully?
+	if resp.StatusCode != http.StatusOK {
+		return nil, HTTPStatusError(resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("readall: %w", err)
+	}
+	return body, nil
+}
+
+func decodeResp[T any](body []byte) ([]T, error) {
+	bresp := struct {
+		Result []T   `json:"result"`
+		Error  []any `json:"error"`
+	}{}
+
+	if err := json.Unmarshal(body, &bresp); err != nil {
+		return nil, InvalidResponseError{
+			wrapped: err,
+			Body:    body,
+		}
+	}
+	// Errors are returned like: `{"error": [40005, "Display Is Turned Off"]}`
+	if bresp.Error != nil {
+		return nil, NewSonyError(bresp.Error, body)
+	}
+	return bresp.Result, nil
+}
+
+func makeParams(v any) []any {
+	if v == nil {
+		return []any{}
+	}
+	if mp, ok := v.(multiParams); ok {
+		return mp
+	}
+	return []any{v}
+}
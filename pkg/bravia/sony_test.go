@@ -0,0 +1,402 @@
+package bravia
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// fakeTV is a minimal Bravia REST IP control server for tests. Requests for
+// REST methods are passed to the handler function which returns the result
+// (to be wrapped in a list) or a Sony error. IRCC codes and the version of
+// each method last called are recorded.
+type fakeTV struct {
+	mu       sync.Mutex
+	handler  func(method string, params []json.RawMessage) (result any, sonyErr []any)
+	ircc     []string
+	versions map[string]string
+}
+
+// results can be returned by a fakeTV handler as the whole result list, for
+// methods that return more than one result.
+type results []any
+
+func newFakeTV(t *testing.T, handler func(method string, params []json.RawMessage) (any, []any)) (*fakeTV, *RESTClient) {
+	t.Helper()
+	tv := &fakeTV{handler: handler, versions: map[string]string{}}
+	srv := httptest.NewServer(tv)
+	t.Cleanup(srv.Close)
+	return tv, NewRESTClient(strings.TrimPrefix(srv.URL, "http://"), "")
+}
+
+func (tv *fakeTV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tv.mu.Lock()
+	defer tv.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	if strings.HasSuffix(r.URL.Path, "/IRCC") {
+		_, code, _ := strings.Cut(string(body), "<IRCCCode>")
+		code, _, _ = strings.Cut(code, "</IRCCCode>")
+		tv.ircc = append(tv.ircc, code)
+		return
+	}
+	var req struct {
+		Method  string            `json:"method"`
+		Version string            `json:"version"`
+		Params  []json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tv.versions[req.Method] = req.Version
+	result, sonyErr := tv.handler(req.Method, req.Params)
+	resp := map[string]any{"id": 1}
+	if sonyErr != nil {
+		resp["error"] = sonyErr
+	} else if r, ok := result.(results); ok {
+		resp["result"] = r
+	} else if result != nil {
+		resp["result"] = []any{result}
+	} else {
+		resp["result"] = []any{}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func TestSetPowerStatusIRCCFallback(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	status := "standby"
+	var tv *fakeTV
+	tv, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		switch method {
+		case "getPowerStatus":
+			// The IRCC power key takes effect once it has been sent.
+			if len(tv.ircc) > 0 {
+				status = "active"
+			}
+			return map[string]string{"status": status}, nil
+		case "setPowerStatus":
+			return nil, []any{501, "Not Implemented"}
+		}
+		return nil, []any{12, "No Such Method"}
+	})
+
+	err := c.SetPowerStatus(ctx, true)
+	is.NoErr(err)                          // SetPowerStatus failed
+	is.Equal([]string{IRCCPower}, tv.ircc) // IRCC power key not sent
+	is.Equal("active", status)             // TV not turned on
+}
+
+func TestSetPowerStatusIRCCFallbackAlreadyInState(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	tv, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		if method == "getPowerStatus" {
+			return map[string]string{"status": "active"}, nil
+		}
+		return nil, []any{7, "Illegal State"}
+	})
+
+	err := c.SetPowerStatus(ctx, true)
+	is.NoErr(err)             // SetPowerStatus failed
+	is.Equal(0, len(tv.ircc)) // IRCC power key should not be sent when already on
+}
+
+func TestSettledPowerStatus(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	statuses := []string{"startingUp", "startingUp", "active"}
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		status := statuses[0]
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		return map[string]string{"status": status}, nil
+	})
+
+	status, err := c.SettledPowerStatus(ctx)
+	is.NoErr(err)              // SettledPowerStatus failed
+	is.Equal("active", status) // did not wait for status to settle
+}
+
+func TestHTTPS(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	tv := &fakeTV{versions: map[string]string{}, handler: func(method string, _ []json.RawMessage) (any, []any) {
+		if method == "getPowerStatus" {
+			return map[string]string{"status": "active"}, nil
+		}
+		return nil, []any{12, "No Such Method"}
+	}}
+	srv := httptest.NewTLSServer(tv)
+	t.Cleanup(srv.Close)
+	c := NewRESTClient(srv.URL, "")
+	is.True(strings.HasPrefix(c.BaseURL, "https://")) // https scheme not kept
+
+	_, err := c.PowerStatus(ctx)
+	var certErr x509.UnknownAuthorityError
+	is.True(errors.As(err, &certErr)) // expected error for self-signed cert
+
+	c.SkipTLSVerify()
+	status, err := c.PowerStatus(ctx)
+	is.NoErr(err) // PowerStatus over https failed
+	is.Equal("active", status)
+}
+
+func TestContextCancelled(t *testing.T) {
+	is := is.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		return map[string]string{"status": "shuttingDown"}, nil
+	})
+	c.Retry = RetryPolicy{Attempts: 3, Backoff: time.Hour}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := c.SettledPowerStatus(ctx)
+	is.True(errors.Is(err, context.Canceled)) // waiting for power status not cancelled
+
+	_, err = c.PowerStatus(ctx)
+	is.True(errors.Is(err, context.Canceled)) // request not cancelled
+}
+
+func TestMiddleware(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		return map[string]string{"status": "active"}, nil
+	})
+	c.versions = map[string]string{} // skip version negotiation
+
+	var order []string
+	record := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" "+req.URL.Path)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	c.Use(record("outer"), record("inner"))
+
+	status, err := c.PowerStatus(ctx)
+	is.NoErr(err)
+	is.Equal("active", status)
+	is.Equal([]string{"outer /sony/system", "inner /sony/system"}, order)
+	is.Equal(10*time.Second, c.HTTPClient.Timeout) // timeout not kept
+
+	// Middleware can mock the TV entirely.
+	c.Use(func(http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			rec := httptest.NewRecorder()
+			rec.WriteString(`{"id": 1, "result": [{"status": "standby"}]}`) //nolint:errcheck,gosec // test
+			return rec.Result(), nil
+		})
+	})
+	status, err = c.PowerStatus(ctx)
+	is.NoErr(err)
+	is.Equal("standby", status)
+}
+
+func TestSonyErrorPredicates(t *testing.T) {
+	is := is.New(t)
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		return nil, []any{SonyCodeDisplayIsTurnedOff, "Display Is Turned off"}
+	})
+	c.versions = map[string]string{} // skip version negotiation
+
+	_, err := c.SelectedInput(context.Background())
+	is.True(IsDisplayOff(err))                                                    // display off not detected
+	is.True(IsSonyCode(err, SonyCodeIllegalArgument, SonyCodeDisplayIsTurnedOff)) // code not matched
+	is.True(!IsIllegalArgument(err))                                              // wrong code matched
+	is.True(!IsDisplayOff(fmt.Errorf("wrapped: %w", HTTPStatusError(http.StatusNotFound))))
+
+	is.True(IsForbidden(SonyError{Code: SonyCodeForbidden}))
+	is.True(IsForbidden(fmt.Errorf("http: %w", HTTPStatusError(http.StatusForbidden))))
+	is.True(!IsForbidden(nil))
+}
+
+func TestInputsCache(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	calls := 0
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		calls++
+		return []Input{{URI: "extInput:hdmi?port=1", Label: "desk"}}, nil
+	})
+	c.versions = map[string]string{} // skip version negotiation
+	c.LabelOverrides = map[string]string{"laptop": "extInput:hdmi?port=1"}
+
+	_, err := c.Inputs(ctx)
+	is.NoErr(err)
+	_, err = c.Inputs(ctx)
+	is.NoErr(err)
+	is.Equal(2, calls) // inputs cached without a TTL
+
+	c.InputsTTL = time.Hour
+	_, err = c.Inputs(ctx)
+	is.NoErr(err)
+	labels, err := c.InputLabels(ctx)
+	is.NoErr(err)
+	is.Equal(3, calls)                                 // inputs not cached
+	is.Equal("extInput:hdmi?port=1", labels["laptop"]) // override not applied to cached inputs
+
+	c.InvalidateInputs()
+	_, err = c.Inputs(ctx)
+	is.NoErr(err)
+	is.Equal(4, calls) // inputs not refetched after invalidation
+}
+
+func TestVerifyPowerStatus(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	power, sets := "standby", 0
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		switch method {
+		case "setPowerStatus":
+			sets++
+			if sets > 1 { // the first request is ignored
+				power = "active"
+			}
+			return nil, nil
+		case "getPowerStatus":
+			return map[string]string{"status": power}, nil
+		}
+		return nil, []any{SonyCodeNoSuchMethod, "No Such Method"}
+	})
+	c.versions = map[string]string{} // skip version negotiation
+
+	c.Verify = VerifyPolicy{Attempts: 3}
+	is.NoErr(c.SetPowerStatus(ctx, true))
+	is.Equal(2, sets) // ignored request not repeated
+
+	c.Verify = VerifyPolicy{Attempts: 2}
+	err := c.SetPowerStatus(ctx, false)
+	is.True(errors.Is(err, ErrNotConfirmed)) // expected unconfirmed change
+	is.Equal(4, sets)
+
+	is.True(isSelectedInput("tv:dvbt?trip=1.2.3", "tv:dvbt"))                 // channel of tuner not selected
+	is.True(!isSelectedInput("extInput:hdmi?port=1", "extInput:hdmi?port=2")) // wrong input selected
+}
+
+func TestIdempotentPower(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	sets := 0
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		switch method {
+		case "setPowerStatus":
+			sets++
+			return nil, nil
+		case "getPowerStatus":
+			return map[string]string{"status": "active"}, nil
+		}
+		return nil, []any{SonyCodeNoSuchMethod, "No Such Method"}
+	})
+	c.versions = map[string]string{} // skip version negotiation
+
+	is.NoErr(c.SetPowerStatus(ctx, true))
+	is.Equal(1, sets) // power not set without IdempotentPower
+
+	c.IdempotentPower = true
+	is.NoErr(c.SetPowerStatus(ctx, true))
+	is.Equal(1, sets) // power set when already on
+	is.NoErr(c.SetPowerStatus(ctx, false))
+	is.Equal(2, sets) // power not set when on
+}
+
+func TestSelectedInputNothingPlaying(t *testing.T) {
+	is := is.New(t)
+	_, c := newFakeTV(t, func(string, []json.RawMessage) (any, []any) { return nil, nil })
+	c.versions = map[string]string{} // skip version negotiation
+	input, err := c.SelectedInput(context.Background())
+	is.NoErr(err)
+	is.Equal("", input) // input selected with nothing playing
+}
+
+// emptyResultCalls are the methods of RESTClient that need a result from
+// the TV, keyed by the TV method they call.
+var emptyResultCalls = map[string]func(context.Context, *RESTClient) error{
+	"getApplicationStatusList": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.ApplicationStatus(ctx)
+		return err
+	},
+	"getVolumeInformation": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.VolumeInformation(ctx)
+		return err
+	},
+	"getApplicationList": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.Apps(ctx)
+		return err
+	},
+	"getNetworkSettings": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.NetworkSettings(ctx)
+		return err
+	},
+	"getWolMode": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.WOLMode(ctx)
+		return err
+	},
+	"getWebAppStatus": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.WebAppStatus(ctx)
+		return err
+	},
+	"getSoundSettings": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.SoundSettings(ctx, "")
+		return err
+	},
+	"getPictureQualitySettings": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.PictureQualitySettings(ctx, "")
+		return err
+	},
+	"getSourceList": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.TunerSources(ctx)
+		return err
+	},
+	"getContentCount": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.ChannelCount(ctx, "tv:dvbt")
+		return err
+	},
+	"getRecordingStatus": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.RecordingStatus(ctx)
+		return err
+	},
+	"getSceneSetting": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.SceneSetting(ctx)
+		return err
+	},
+	"getPowerSavingMode": func(ctx context.Context, c *RESTClient) error {
+		_, err := c.PowerSavingMode(ctx)
+		return err
+	},
+}
+
+func TestEmptyResult(t *testing.T) {
+	for method, call := range emptyResultCalls {
+		t.Run(method, func(t *testing.T) {
+			is := is.New(t)
+			_, c := newFakeTV(t, func(string, []json.RawMessage) (any, []any) { return nil, nil })
+			c.versions = map[string]string{} // skip version negotiation
+			err := call(context.Background(), c)
+			is.True(errors.Is(err, ErrNoResult)) // expected no result error
+		})
+	}
+}
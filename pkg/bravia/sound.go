@@ -0,0 +1,80 @@
+//nolint:goerr113 // dynamic errors are OK
+package bravia
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SoundSetting is the value of a sound setting of the TV, such as
+// "outputTerminal" or "soundMode".
+type SoundSetting struct {
+	Target string `json:"target"`
+	Value  string `json:"value"`
+}
+
+// SoundSettingInfo describes the current value of a sound setting and the
+// values it can be set to.
+type SoundSettingInfo struct {
+	Target       string `json:"target"`
+	CurrentValue string `json:"currentValue"`
+	Candidate    []struct {
+		Value       string `json:"value"`
+		Title       string `json:"title,omitempty"`
+		IsAvailable *bool  `json:"isAvailable,omitempty"`
+	} `json:"candidate,omitempty"`
+}
+
+// SoundSettings returns the current sound setting for the given target, or
+// all settings if target is the empty string.
+func (c *RESTClient) SoundSettings(ctx context.Context, target string) ([]SoundSettingInfo, error) {
+	param := map[string]string{"target": target}
+	settings, err := post[[]SoundSettingInfo](ctx, c, "audio", "getSoundSettings", "1.1", param)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, noResult("getSoundSettings")
+	}
+	return *settings, nil
+}
+
+// SetSoundSettings changes the given sound settings.
+func (c *RESTClient) SetSoundSettings(ctx context.Context, settings []SoundSetting) error {
+	param := map[string][]SoundSetting{"settings": settings}
+	_, err := post[empty](ctx, c, "audio", "setSoundSettings", "1.1", param)
+	return err
+}
+
+// AudioOutput returns the audio output terminal of the TV, such as "speaker"
+// or "audioSystem".
+func (c *RESTClient) AudioOutput(ctx context.Context) (string, error) {
+	settings, err := c.SoundSettings(ctx, "outputTerminal")
+	if err != nil {
+		return "", err
+	}
+	for _, s := range settings {
+		if s.Target == "outputTerminal" {
+			return s.CurrentValue, nil
+		}
+	}
+	return "", fmt.Errorf("TV did not return the outputTerminal setting")
+}
+
+// SetAudioOutput sets the audio output terminal of the TV. The terminals a
+// TV supports vary by model; they can be found with
+// [RESTClient.SoundSettings].
+func (c *RESTClient) SetAudioOutput(ctx context.Context, terminal string) error {
+	return c.SetSoundSettings(ctx, []SoundSetting{{Target: "outputTerminal", Value: terminal}})
+}
+
+// CandidateValues returns the values the setting can be set to, as a comma
+// separated list, or the empty string if the TV did not say.
+func (si SoundSettingInfo) CandidateValues() string {
+	values := make([]string, 0, len(si.Candidate))
+	for _, c := range si.Candidate {
+		values = append(values, c.Value)
+	}
+	return strings.Join(values, ",")
+}
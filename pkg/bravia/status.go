@@ -0,0 +1,127 @@
+package bravia
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// TVStatus is a summary of the state of the TV. Fields that could not be
+// determined, such as the input while the TV is off or showing an app, are
+// left empty.
+type TVStatus struct {
+	Power      string `json:"power"`
+	Input      string `json:"input,omitempty"`
+	InputLabel string `json:"inputLabel,omitempty"`
+	Volume     *int   `json:"volume,omitempty"`
+	Mute       *bool  `json:"mute,omitempty"`
+	App        string `json:"app,omitempty"`
+
+	// AppStatus is the status of the TV's application indicators, such as
+	// "textInput" being "on" when the TV is waiting for text entry.
+	AppStatus map[string]string `json:"appStatus,omitempty"`
+}
+
+// WebAppStatus returns the URL of the web app running on the TV, or the
+// empty string if none is running. The REST IP control protocol does not
+// report which native app is running, only web apps.
+func (c *RESTClient) WebAppStatus(ctx context.Context) (string, error) {
+	type webAppStatusResponse struct {
+		Active bool   `json:"active"`
+		URL    string `json:"url"`
+	}
+	resp, err := post[webAppStatusResponse](ctx, c, "appControl", "getWebAppStatus", "1.0", nil)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil {
+		return "", noResult("getWebAppStatus")
+	}
+	if !resp.Active {
+		return "", nil
+	}
+	return resp.URL, nil
+}
+
+// ApplicationStatus returns the status of the TV's application indicators
+// keyed by name: "textInput" (the TV is waiting for text entry),
+// "cursorDisplay" (a cursor is displayed) and "webBrowse" (the web browser is
+// in use). Statuses are "on" or "off".
+func (c *RESTClient) ApplicationStatus(ctx context.Context) (map[string]string, error) {
+	type appStatusResponse struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	}
+	statuses, err := post[[]appStatusResponse](ctx, c, "appControl", "getApplicationStatusList", "1.0", nil)
+	if err != nil {
+		return nil, err
+	}
+	if statuses == nil {
+		return nil, noResult("getApplicationStatusList")
+	}
+	result := make(map[string]string, len(*statuses))
+	for _, s := range *statuses {
+		result[s.Name] = s.Status
+	}
+	return result, nil
+}
+
+// Status returns the power status, selected input, speaker volume, running
+// web app and application indicators of the TV. Only the power status must be retrieved without
+// error; the rest is only available while the TV is on and is retrieved
+// concurrently. Errors retrieving it are recorded in the diagnostics, as the
+// TV returns errors for the selected input when it is showing an app or the
+// home screen.
+func (c *RESTClient) Status(ctx context.Context) (*TVStatus, error) {
+	power, err := c.SettledPowerStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("power status: %w", err)
+	}
+	status := &TVStatus{Power: power}
+	if power != "active" {
+		return status, nil
+	}
+
+	var wg sync.WaitGroup
+	var inputs map[string]string
+	run := func(what string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				diag.Event("status: could not get %s: %v", what, err)
+			}
+		}()
+	}
+	run("selected input", func() (err error) {
+		status.Input, err = c.SelectedInput(ctx)
+		return err
+	})
+	run("inputs", func() (err error) {
+		inputs, err = c.InputLabels(ctx)
+		return err
+	})
+	run("volume", func() error {
+		infos, err := c.VolumeInformation(ctx)
+		for i := range infos {
+			if infos[i].Target == "speaker" || len(infos) == 1 {
+				status.Volume, status.Mute = &infos[i].Volume, &infos[i].Mute
+			}
+		}
+		return err
+	})
+	run("web app status", func() (err error) {
+		status.App, err = c.WebAppStatus(ctx)
+		return err
+	})
+	run("application status", func() (err error) {
+		status.AppStatus, err = c.ApplicationStatus(ctx)
+		return err
+	})
+	wg.Wait()
+
+	if label := inputs[status.Input]; label != status.Input {
+		status.InputLabel = label
+	}
+	return status, nil
+}
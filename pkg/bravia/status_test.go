@@ -0,0 +1,61 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestStatus(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		switch method {
+		case "getPowerStatus":
+			return map[string]string{"status": "active"}, nil
+		case "getPlayingContentInfo":
+			return map[string]string{"uri": "extInput:hdmi?port=2"}, nil
+		case "getCurrentExternalInputsStatus":
+			return []map[string]string{
+				{"uri": "extInput:hdmi?port=1", "label": "console"},
+				{"uri": "extInput:hdmi?port=2", "label": "desktop"},
+			}, nil
+		case "getVolumeInformation":
+			return []VolumeInfo{{Target: "headphone", Volume: 5}, {Target: "speaker", Volume: 12, Mute: true}}, nil
+		case "getWebAppStatus":
+			return map[string]any{"active": false}, nil
+		case "getApplicationStatusList":
+			return []map[string]string{{"name": "textInput", "status": "on"}, {"name": "webBrowse", "status": "off"}}, nil
+		}
+		return nil, []any{12, "No Such Method"}
+	})
+
+	status, err := c.Status(ctx)
+	is.NoErr(err) // Status failed
+	is.Equal("active", status.Power)
+	is.Equal("extInput:hdmi?port=2", status.Input)
+	is.Equal("desktop", status.InputLabel)
+	is.Equal(12, *status.Volume) // wrong speaker volume
+	is.True(*status.Mute)        // speaker should be muted
+	is.Equal("", status.App)     // no web app should be running
+	is.Equal(map[string]string{"textInput": "on", "webBrowse": "off"}, status.AppStatus)
+}
+
+func TestStatusStandby(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	_, c := newFakeTV(t, func(method string, _ []json.RawMessage) (any, []any) {
+		if method == "getPowerStatus" {
+			return map[string]string{"status": "standby"}, nil
+		}
+		return nil, []any{40005, "Display Is Turned off"}
+	})
+
+	status, err := c.Status(ctx)
+	is.NoErr(err) // Status failed
+	is.Equal(&TVStatus{Power: "standby"}, status)
+}
@@ -0,0 +1,50 @@
+package bravia
+
+import "context"
+
+// TV is the control of a TV needed to turn it on and off, select its input
+// and change its volume. It is implemented by [RESTClient] for the REST IP
+// control API, by [SimpleIPClient] for TVs with only Simple IP control and
+// by [SerialClient] for professional displays controlled over RS-232. The
+// other features of the package need the REST API.
+type TV interface {
+	// PowerStatus returns "active" if the TV is on and "standby" if it
+	// is off, or a transitional status on some models.
+	PowerStatus(ctx context.Context) (string, error)
+
+	// SettledPowerStatus returns the power status of the TV once it is
+	// "active" or "standby".
+	SettledPowerStatus(ctx context.Context) (string, error)
+
+	// SetPowerStatus turns the TV on (status == true) or off.
+	SetPowerStatus(ctx context.Context, status bool) error
+
+	// SelectedInput returns the URI of the input the TV is showing.
+	SelectedInput(ctx context.Context) (string, error)
+
+	// SetInput selects the input of the TV with the given URI.
+	SetInput(ctx context.Context, uri string) error
+
+	// Inputs returns the external inputs of the TV.
+	Inputs(ctx context.Context) ([]Input, error)
+
+	// InputLabels returns a map of input URIs to labels and labels to
+	// URIs, for looking up inputs by either.
+	InputLabels(ctx context.Context) (map[string]string, error)
+
+	// VolumeInformation returns the volume of the TV's audio outputs.
+	VolumeInformation(ctx context.Context) ([]VolumeInfo, error)
+
+	// SetAudioVolume sets the volume of an audio output to a level, or
+	// changes it by an amount prefixed with "+" or "-".
+	SetAudioVolume(ctx context.Context, target, volume string) error
+
+	// SetAudioMute mutes (mute == true) or unmutes the TV.
+	SetAudioMute(ctx context.Context, mute bool) error
+}
+
+var (
+	_ TV = (*RESTClient)(nil)
+	_ TV = (*SimpleIPClient)(nil)
+	_ TV = (*SerialClient)(nil)
+)
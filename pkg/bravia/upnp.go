@@ -0,0 +1,156 @@
+//nolint:goerr113 // dynamic errors are OK
+package bravia
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// upnpPort is the port of the UPnP (DLNA) services of the TV.
+const upnpPort = "52323"
+
+// renderingControlService is the UPnP service type of RenderingControl,
+// which older firmware that rejects the audio REST API still supports for
+// setting the volume.
+const renderingControlService = "urn:schemas-upnp-org:service:RenderingControl:1"
+
+// upnpEnvelope is the SOAP request body for calling a UPnP action. The
+// action, the service type, the arguments and the action again are
+// substituted.
+const upnpEnvelope = `<?xml version="1.0"?>` +
+	`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+	`<s:Body><u:%s xmlns:u="%s">%s</u:%s></s:Body>` +
+	`</s:Envelope>`
+
+// renderingControlURL returns the standard control URL of the UPnP
+// RenderingControl service of the TV at baseURL.
+func renderingControlURL(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	return "http://" + net.JoinHostPort(u.Hostname(), upnpPort) + "/upnp/control/RenderingControl"
+}
+
+// useRenderingControl returns true if err from the audio REST API means
+// the TV does not support it, so UPnP RenderingControl should be used
+// instead for the volume of target. An illegal state error, such as when
+// the TV is in standby, does not fall back as the TV does support the API.
+func (c *RESTClient) useRenderingControl(err error, target string) bool {
+	return c.RenderingControlURL != "" && (target == "" || target == "speaker") &&
+		(IsSonyCode(err, SonyCodeNoSuchMethod, SonyCodeUnsupportedOperation, SonyCodeNotImplemented) || isUnknownService(err))
+}
+
+// renderingControl calls a UPnP RenderingControl action on the master
+// channel of the TV, with the extra arguments given as name and value
+// pairs, and returns the values in the response keyed by name.
+func (c *RESTClient) renderingControl(ctx context.Context, action string, args ...string) (map[string]string, error) {
+	var b strings.Builder
+	b.WriteString("<InstanceID>0</InstanceID><Channel>Master</Channel>")
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, "<%s>%s</%s>", args[i], args[i+1], args[i])
+	}
+	body := fmt.Sprintf(upnpEnvelope, action, renderingControlService, b.String(), action)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.RenderingControlURL, bytes.NewReader([]byte(body)))
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPACTION", fmt.Sprintf(`"%s#%s"`, renderingControlService, action))
+	var resp []byte
+	err = c.guard(func() error {
+		resp, err = c.do(req)
+		return err
+	})
+	diag.TVResponse("RenderingControl", action, resp, err)
+	if err != nil {
+		return nil, fmt.Errorf("upnp %s: %w", action, err)
+	}
+	var envelope struct {
+		Body struct {
+			Response struct {
+				Values []struct {
+					XMLName xml.Name
+					Value   string `xml:",chardata"`
+				} `xml:",any"`
+			} `xml:",any"`
+		}
+	}
+	if err := xml.Unmarshal(resp, &envelope); err != nil {
+		return nil, InvalidResponseError{wrapped: err, Body: resp}
+	}
+	values := map[string]string{}
+	for _, v := range envelope.Body.Response.Values {
+		values[v.XMLName.Local] = v.Value
+	}
+	return values, nil
+}
+
+// upnpVolumeInformation returns the volume and mute state of the speakers
+// of the TV from UPnP RenderingControl.
+func (c *RESTClient) upnpVolumeInformation(ctx context.Context) ([]VolumeInfo, error) {
+	volume, err := c.upnpVolume(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mute, err := c.renderingControl(ctx, "GetMute")
+	if err != nil {
+		return nil, err
+	}
+	muted := mute["CurrentMute"] == "1" || mute["CurrentMute"] == "true"
+	return []VolumeInfo{{Target: "speaker", Volume: volume, Mute: muted, MaxVolume: 100}}, nil
+}
+
+func (c *RESTClient) upnpVolume(ctx context.Context) (int, error) {
+	values, err := c.renderingControl(ctx, "GetVolume")
+	if err != nil {
+		return 0, err
+	}
+	volume, err := strconv.Atoi(values["CurrentVolume"])
+	if err != nil {
+		return 0, InvalidResponseError{wrapped: err, Body: []byte(values["CurrentVolume"])}
+	}
+	return volume, nil
+}
+
+// upnpSetVolume sets the volume of the speakers of the TV with UPnP
+// RenderingControl, which only sets absolute volumes, so a relative volume
+// is added to the current one.
+func (c *RESTClient) upnpSetVolume(ctx context.Context, volume string) error {
+	n, err := strconv.Atoi(volume)
+	if err != nil {
+		return fmt.Errorf("invalid volume %q", volume)
+	}
+	if strings.HasPrefix(volume, "+") || strings.HasPrefix(volume, "-") {
+		current, err := c.upnpVolume(ctx)
+		if err != nil {
+			return err
+		}
+		n += current
+	}
+	if n < 0 {
+		n = 0
+	} else if n > 100 {
+		n = 100
+	}
+	_, err = c.renderingControl(ctx, "SetVolume", "DesiredVolume", strconv.Itoa(n))
+	return err
+}
+
+// upnpSetMute mutes (mute == true) or unmutes the TV with UPnP
+// RenderingControl.
+func (c *RESTClient) upnpSetMute(ctx context.Context, mute bool) error {
+	desired := "0"
+	if mute {
+		desired = "1"
+	}
+	_, err := c.renderingControl(ctx, "SetMute", "DesiredMute", desired)
+	return err
+}
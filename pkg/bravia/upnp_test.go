@@ -0,0 +1,75 @@
+package bravia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRenderingControlFallback(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	_, c := newFakeTV(t, func(string, []json.RawMessage) (any, []any) {
+		return nil, []any{SonyCodeNoSuchMethod, "No Such Method"}
+	})
+	c.versions = map[string]string{} // skip version negotiation
+
+	volume, mute := 20, "0"
+	var actions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, action, _ := strings.Cut(r.Header.Get("SOAPACTION"), "#")
+		action = strings.Trim(action, `"`)
+		actions = append(actions, action)
+		result := ""
+		switch action {
+		case "GetVolume":
+			result = fmt.Sprintf("<CurrentVolume>%d</CurrentVolume>", volume)
+		case "GetMute":
+			result = "<CurrentMute>" + mute + "</CurrentMute>"
+		case "SetVolume":
+			_, v, _ := strings.Cut(string(body), "<DesiredVolume>")
+			fmt.Sscanf(v, "%d", &volume) //nolint:errcheck // checked by the test
+		case "SetMute":
+			_, m, _ := strings.Cut(string(body), "<DesiredMute>")
+			mute = m[:1]
+		}
+		fmt.Fprintf(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+			`<u:%sResponse xmlns:u="%s">%s</u:%sResponse></s:Body></s:Envelope>`, action, renderingControlService, result, action)
+	}))
+	t.Cleanup(srv.Close)
+	c.RenderingControlURL = srv.URL
+
+	is.NoErr(c.SetAudioVolume(ctx, "speaker", "+5"))
+	is.NoErr(c.SetAudioMute(ctx, true))
+	infos, err := c.VolumeInformation(ctx)
+	is.NoErr(err)
+	is.Equal([]VolumeInfo{{Target: "speaker", Volume: 25, Mute: true, MaxVolume: 100}}, infos)
+	is.Equal([]string{"GetVolume", "SetVolume", "SetMute", "GetVolume", "GetMute"}, actions)
+
+	err = c.SetAudioVolume(ctx, "headphone", "5")
+	is.True(IsUnsupported(err)) // headphone volume should not fall back to UPnP
+}
+
+func TestRenderingControlNoFallbackInIllegalState(t *testing.T) {
+	is := is.New(t)
+	_, c := newFakeTV(t, func(string, []json.RawMessage) (any, []any) {
+		return nil, []any{SonyCodeIllegalState, "Illegal State"}
+	})
+	c.versions = map[string]string{} // skip version negotiation
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected UPnP request %s", r.Header.Get("SOAPACTION"))
+	}))
+	t.Cleanup(srv.Close)
+	c.RenderingControlURL = srv.URL
+
+	err := c.SetAudioVolume(context.Background(), "speaker", "+5")
+	is.True(IsSonyCode(err, SonyCodeIllegalState)) // expected illegal state error from the REST API
+}
@@ -0,0 +1,62 @@
+package bravia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotConfirmed is returned when the TV does not confirm that a change of
+// power status or input requested with a [VerifyPolicy] took effect.
+var ErrNotConfirmed = errors.New("change not confirmed by TV")
+
+// VerifyPolicy describes how changes of the power status and input of the
+// TV are verified, as the TV occasionally ignores them, such as just after
+// waking. The zero value does not verify changes.
+type VerifyPolicy struct {
+	// Attempts is the maximum number of times a change is requested
+	// until the TV confirms it. Zero means changes are not verified.
+	Attempts int
+
+	// Interval is how long to wait after requesting a change before
+	// checking that it took effect.
+	Interval time.Duration
+}
+
+// do calls set, then if verifying, waits and calls confirmed until it
+// returns true, calling set again each time it does not until the attempts
+// are exhausted. An error from confirmed is treated as unconfirmed, as the
+// TV may still be changing state.
+func (p VerifyPolicy) do(ctx context.Context, what string, set func() error, confirmed func() (bool, error)) error {
+	if err := set(); err != nil || p.Attempts <= 0 {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		if err := sleep(ctx, p.Interval); err != nil {
+			return err
+		}
+		ok, err := confirmed()
+		if err == nil && ok {
+			return nil
+		}
+		if attempt >= p.Attempts {
+			if err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrNotConfirmed, what, err)
+			}
+			return fmt.Errorf("%w: %s", ErrNotConfirmed, what)
+		}
+		diag.Event("%s not confirmed; requesting it again (attempt %d of %d)", what, attempt+1, p.Attempts)
+		if err := set(); err != nil {
+			return err
+		}
+	}
+}
+
+// isSelectedInput returns true if selected, the URI of the content the TV
+// is showing, is the input uri. A tuner source such as "tv:dvbt" is
+// selected when any of its channels is.
+func isSelectedInput(selected, uri string) bool {
+	return strings.EqualFold(selected, uri) || (!strings.Contains(uri, "?") && strings.HasPrefix(selected, uri+"?"))
+}
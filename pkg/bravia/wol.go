@@ -0,0 +1,105 @@
+//nolint:goerr113 // dynamic errors are OK
+package bravia
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// wolAddr is where Wake-on-LAN magic packets are sent.
+	wolAddr = "255.255.255.255:9"
+
+	// wolPollInterval is how often the TV is polled, and the magic packet
+	// resent, while waiting for the TV to wake.
+	wolPollInterval = time.Second
+
+	// wolTimeout is how long to wait for the TV to respond after sending
+	// a Wake-on-LAN magic packet.
+	wolTimeout = 30 * time.Second
+)
+
+// SendWOL broadcasts a Wake-on-LAN magic packet for the given MAC address.
+func SendWOL(mac string) error {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return fmt.Errorf("wake-on-lan: %w", err)
+	}
+	// A magic packet is 6 bytes of 0xff followed by the MAC address
+	// repeated 16 times.
+	packet := append(bytes.Repeat([]byte{0xff}, 6), bytes.Repeat(hw, 16)...)
+	conn, err := net.Dial("udp", wolAddr)
+	if err != nil {
+		return fmt.Errorf("wake-on-lan: %w", err)
+	}
+	defer conn.Close() //nolint:errcheck // nothing useful to do
+	if _, err := conn.Write(packet); err != nil {
+		return fmt.Errorf("wake-on-lan: %w", err)
+	}
+	return nil
+}
+
+// errNoResponse is returned when the TV does not respond within the HTTP
+// client's timeout.
+var errNoResponse = errors.New("TV did not respond")
+
+// IsUnreachable returns true if err is an error communicating with the TV,
+// as opposed to an error response from the TV. A request cancelled or
+// timed out by its context does not show the TV is unreachable.
+func IsUnreachable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var serr SonyError
+	var herr HTTPStatusError
+	var ierr InvalidResponseError
+	return err != nil && !errors.As(err, &serr) && !errors.As(err, &herr) && !errors.As(err, &ierr)
+}
+
+// wake sends Wake-on-LAN magic packets to the TV until it responds to the
+// REST API or wolTimeout has elapsed. It is used when the TV's eco mode
+// turns off its network interface in standby.
+func (c *RESTClient) wake(ctx context.Context) error {
+	diag.Event("TV unreachable, sending Wake-on-LAN to %s", c.MAC)
+	deadline := time.Now().Add(wolTimeout)
+	for time.Now().Before(deadline) {
+		if err := SendWOL(c.MAC); err != nil {
+			return err
+		}
+		c.Breaker.reset() // the TV may be reachable once woken
+		// Polling is the retry here, so only server errors are retried.
+		if _, err := c.PowerStatus(withRetryable(ctx, isServerError)); !IsUnreachable(err) {
+			return nil
+		}
+		if err := sleep(ctx, wolPollInterval); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("wake-on-lan: TV did not respond within %v", wolTimeout)
+}
+
+// WOLMode returns whether Wake-on-LAN is enabled on the TV.
+func (c *RESTClient) WOLMode(ctx context.Context) (bool, error) {
+	type wolModeResponse struct {
+		Enabled bool `json:"enabled"`
+	}
+	resp, err := post[wolModeResponse](ctx, c, "system", "getWolMode", "1.0", nil)
+	if err != nil {
+		return false, err
+	}
+	if resp == nil {
+		return false, noResult("getWolMode")
+	}
+	return resp.Enabled, nil
+}
+
+// SetWOLMode enables or disables Wake-on-LAN on the TV.
+func (c *RESTClient) SetWOLMode(ctx context.Context, enabled bool) error {
+	param := map[string]bool{"enabled": enabled}
+	_, err := post[empty](ctx, c, "system", "setWolMode", "1.0", param)
+	return err
+}
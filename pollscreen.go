@@ -0,0 +1,94 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// polledScreen is a [ScreenBackend] for platforms where offscreen can query
+// whether the display is asleep and the monitor present but has no events
+// it can follow for both, such as macOS. The state is queried every
+// interval, and straight away whenever wake receives, such as on a display
+// power notification from the OS. Changes are processed with the same rules
+// as [Screen.Watch] by the embedded [FakeScreen].
+type polledScreen struct {
+	*FakeScreen
+
+	// query returns whether the display is asleep and whether the
+	// monitor is present.
+	query func() (asleep, present bool, err error)
+
+	// blank puts the display to sleep and unblank wakes it. Either is
+	// nil if it cannot be done.
+	blank   func() error
+	unblank func() error
+}
+
+// newPolledScreen returns a polledScreen with the state returned by query,
+// which is queried again every interval and whenever wake receives until the
+// screen is closed. wake may be nil.
+func newPolledScreen(query func() (asleep, present bool, err error), interval time.Duration, wake <-chan struct{}) (*polledScreen, error) {
+	asleep, present, err := query()
+	if err != nil {
+		return nil, err
+	}
+	s := &polledScreen{FakeScreen: NewFakeScreen(asleep, present), query: query}
+	go s.poll(interval, wake)
+	return s, nil
+}
+
+func (s *polledScreen) poll(interval time.Duration, wake <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+		asleep, present, err := s.query()
+		if err != nil {
+			warnf("%v", err)
+			continue
+		}
+		// A monitor going away does so before the screen saver changes
+		// and one appearing after, so the watcher is told the new state
+		// only once.
+		if !present && s.IsPresent() {
+			s.send(fakeAbsent)
+		}
+		if asleep && !s.IsScreenSaverOn() {
+			s.send(fakeSSOn)
+		} else if !asleep && s.IsScreenSaverOn() {
+			s.send(fakeSSOff)
+		}
+		if present && !s.IsPresent() {
+			s.send(fakePresent)
+		}
+	}
+}
+
+// Blank puts the display to sleep.
+func (s *polledScreen) Blank() error {
+	if s.blank == nil {
+		return errors.New("cannot blank the screen with this screen backend")
+	}
+	if err := s.blank(); err != nil {
+		return fmt.Errorf("could not put display to sleep: %w", err)
+	}
+	return nil
+}
+
+// Unblank wakes the display.
+func (s *polledScreen) Unblank() error {
+	if s.unblank == nil {
+		return errors.New("cannot unblank the screen with this screen backend")
+	}
+	if err := s.unblank(); err != nil {
+		return fmt.Errorf("could not wake display: %w", err)
+	}
+	return nil
+}
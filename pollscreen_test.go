@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestPolledScreen(t *testing.T) {
+	is := is.New(t)
+	var mu sync.Mutex
+	asleep, present := false, false
+	set := func(a, p bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		asleep, present = a, p
+	}
+	queried := make(chan struct{}, 1)
+	query := func() (bool, bool, error) {
+		mu.Lock()
+		a, p := asleep, present
+		mu.Unlock()
+		queried <- struct{}{}
+		return a, p, nil
+	}
+	wake := make(chan struct{})
+	s, err := newPolledScreen(query, time.Hour, wake)
+	is.NoErr(err)
+	<-queried
+	is.True(!s.IsPresent())
+
+	changes := make(chan bool, 4)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	// poll sets the state and has it queried, returning once the query
+	// has been made so the next state cannot be seen early.
+	poll := func(a, p bool) {
+		set(a, p)
+		wake <- struct{}{}
+		<-queried
+	}
+	poll(true, true) // plugged in while asleep
+	is.Equal(true, <-changes)
+	poll(false, true)
+	is.Equal(false, <-changes)
+	poll(true, false) // unplugged: not passed on
+	poll(true, true)
+	is.Equal(true, <-changes)
+	s.Sync()
+	is.Equal(0, len(changes)) // unexpected change passed on
+
+	is.True(s.Blank() != nil)
+	s.Close()
+	is.NoErr(<-done)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// maxReconnectBackoff is the longest to wait between attempts to reconnect
+// to the X server.
+const maxReconnectBackoff = 30 * time.Second
+
+// reconnectingScreen is a [ScreenBackend] that reconnects to the X server
+// when the connection is lost, such as when the display manager restarts,
+// instead of returning from Watch. Attempts to reconnect back off
+// exponentially from xRetryInterval to maxReconnectBackoff.
+type reconnectingScreen struct {
+	dial    func() (ScreenBackend, error)
+	backoff time.Duration // before the first attempt to reconnect
+
+	mu     sync.Mutex
+	screen ScreenBackend
+	done   chan struct{} // closed by Close
+	closed bool
+
+	// watcherSet holds the watchers called alongside the watcher given to
+	// Watch, whichever screen is current.
+	watcherSet
+}
+
+// newReconnectingScreen returns a reconnectingScreen starting with the given
+// screen and reconnecting by calling dial.
+func newReconnectingScreen(screen ScreenBackend, dial func() (ScreenBackend, error)) *reconnectingScreen {
+	return &reconnectingScreen{
+		dial:    dial,
+		backoff: xRetryInterval,
+		screen:  screen,
+		done:    make(chan struct{}),
+	}
+}
+
+func (r *reconnectingScreen) current() ScreenBackend {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.screen
+}
+
+// Close closes the current screen, causing Watch to return, and stops any
+// attempt to reconnect.
+func (r *reconnectingScreen) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		close(r.done)
+		r.screen.Close()
+	}
+}
+
+// IsScreenSaverOn returns the state of the screen saver of the current
+// screen.
+func (r *reconnectingScreen) IsScreenSaverOn() bool {
+	return r.current().IsScreenSaverOn()
+}
+
+// IsPresent returns whether the monitor of the current screen is present.
+func (r *reconnectingScreen) IsPresent() bool {
+	return r.current().IsPresent()
+}
+
+// Blank forces the screen saver of the current screen on.
+func (r *reconnectingScreen) Blank() error {
+	return r.current().Blank()
+}
+
+// Unblank forces the screen saver of the current screen off.
+func (r *reconnectingScreen) Unblank() error {
+	return r.current().Unblank()
+}
+
+// Watch watches the current screen until it is closed, reconnecting when
+// the connection is lost. Once reconnected, the screen saver state is passed
+// to the watcher if it changed while disconnected, with the same rules as
+// [Screen.Watch]. The watchers added with AddWatcher are called too, as for
+// a [Screen].
+func (r *reconnectingScreen) Watch(watcher ScreenWatcher) error {
+	return r.WatchContext(context.Background(), watcher)
+}
+
+// WatchContext is like [reconnectingScreen.Watch] but also returns nil once
+// ctx is done, including while reconnecting.
+func (r *reconnectingScreen) WatchContext(ctx context.Context, watcher ScreenWatcher) error {
+	watcher = r.fanOut(watcher)
+	for {
+		old := r.current()
+		err := watchContext(ctx, old, watcher)
+		if !errors.Is(err, ErrXConnLost) {
+			return err
+		}
+		warnf("%v; reconnecting", err)
+		screen := r.reconnect(ctx)
+		if screen == nil { // closed or cancelled while reconnecting
+			return nil
+		}
+		diag.Event("reconnected to X server")
+		if screen.IsPresent() && (!old.IsPresent() || screen.IsScreenSaverOn() != old.IsScreenSaverOn()) {
+			if err := watcher.SSChange(screen.IsScreenSaverOn()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// reconnect dials until it succeeds, backing off between attempts, and makes
+// the new screen the current one. It returns nil if the reconnectingScreen
+// is closed or ctx is done first.
+func (r *reconnectingScreen) reconnect(ctx context.Context) ScreenBackend {
+	backoff := r.backoff
+	for {
+		select {
+		case <-r.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		screen, err := r.dial()
+		if err != nil {
+			diag.Event("could not reconnect to X server: %v", err)
+			if backoff *= 2; backoff > maxReconnectBackoff {
+				backoff = maxReconnectBackoff
+			}
+			continue
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.closed {
+			screen.Close()
+			return nil
+		}
+		r.screen = screen
+		return screen
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// lostScreen is a [FakeScreen] whose connection is lost when it is closed.
+type lostScreen struct {
+	*FakeScreen
+}
+
+func (s lostScreen) Watch(watcher ScreenWatcher) error {
+	if err := s.FakeScreen.Watch(watcher); err != nil {
+		return err
+	}
+	return ErrXConnLost
+}
+
+func TestReconnectingScreen(t *testing.T) {
+	is := is.New(t)
+	first := NewFakeScreen(false /* ssOn */, true /* present */)
+	second := NewFakeScreen(true /* ssOn */, true /* present */)
+	dials := 0
+	r := newReconnectingScreen(lostScreen{first}, func() (ScreenBackend, error) {
+		dials++
+		if dials == 1 {
+			return nil, ErrXConnLost // X server not back yet
+		}
+		return lostScreen{second}, nil
+	})
+	r.backoff = time.Millisecond
+
+	changes := make(chan bool, 1) // as Send waits for the change
+	done := make(chan error)
+	go func() {
+		done <- r.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	is.NoErr(first.Send(fakeSSOn))
+	is.Equal(true, <-changes)
+	is.NoErr(first.Send(fakeSSOff))
+	is.Equal(false, <-changes)
+
+	// The screen saver came on while disconnected.
+	first.Close()
+	is.Equal(true, <-changes)
+	is.Equal(dials, 2)
+	is.True(r.IsScreenSaverOn())
+
+	is.NoErr(second.Send(fakeSSOff))
+	is.Equal(false, <-changes)
+
+	r.Close()
+	is.NoErr(<-done)
+}
+
+func TestReconnectingScreenContext(t *testing.T) {
+	is := is.New(t)
+	first := NewFakeScreen(false /* ssOn */, true /* present */)
+	r := newReconnectingScreen(lostScreen{first}, func() (ScreenBackend, error) {
+		return nil, ErrXConnLost // X server never comes back
+	})
+	r.backoff = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- watchContext(ctx, r, ScreenWatcherFunc(func(bool) error { return nil }))
+	}()
+
+	first.Close()
+	cancel() // while reconnecting
+	is.NoErr(<-done)
+
+	// Screens that cannot watch with a context are closed instead.
+	s := NewFakeScreen(false /* ssOn */, true /* present */)
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		done <- watchContext(ctx, s, ScreenWatcherFunc(func(bool) error { return nil }))
+	}()
+	cancel()
+	is.NoErr(<-done)
+	is.NoErr(s.Send(fakeSSOn)) // does not block as the screen is closed
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// retargetableScreen is a [ScreenBackend] whose screen can be replaced while
+// it is being watched, for `run` to switch to managing another monitor
+// without restarting, such as after the cable is moved to another port.
+type retargetableScreen struct {
+	mu     sync.Mutex
+	screen ScreenBackend
+	closed bool
+}
+
+// newRetargetableScreen returns a retargetableScreen starting with the given
+// screen, which it takes ownership of.
+func newRetargetableScreen(screen ScreenBackend) *retargetableScreen {
+	return &retargetableScreen{screen: screen}
+}
+
+func (r *retargetableScreen) current() ScreenBackend {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.screen
+}
+
+// retarget replaces the current screen with the given one, which it takes
+// ownership of, closing the current screen. Watch carries on watching the
+// new screen.
+func (r *retargetableScreen) retarget(screen ScreenBackend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		screen.Close()
+		return
+	}
+	old := r.screen
+	r.screen = screen
+	old.Close()
+}
+
+// Close closes the current screen, causing Watch to return.
+func (r *retargetableScreen) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		r.screen.Close()
+	}
+}
+
+// IsScreenSaverOn returns the state of the screen saver of the current
+// screen.
+func (r *retargetableScreen) IsScreenSaverOn() bool {
+	return r.current().IsScreenSaverOn()
+}
+
+// IsPresent returns whether the monitor of the current screen is present.
+func (r *retargetableScreen) IsPresent() bool {
+	return r.current().IsPresent()
+}
+
+// Blank forces the screen saver of the current screen on.
+func (r *retargetableScreen) Blank() error {
+	return r.current().Blank()
+}
+
+// Unblank forces the screen saver of the current screen off.
+func (r *retargetableScreen) Unblank() error {
+	return r.current().Unblank()
+}
+
+// Watch watches the current screen until it is closed, moving on to the new
+// screen when retargeted. The screen saver state of the new screen is then
+// passed to the watcher if it differs from the old one, with the same rules
+// as [Screen.Watch].
+func (r *retargetableScreen) Watch(watcher ScreenWatcher) error {
+	return r.WatchContext(context.Background(), watcher)
+}
+
+// WatchContext is like [retargetableScreen.Watch] but also returns nil once
+// ctx is done.
+func (r *retargetableScreen) WatchContext(ctx context.Context, watcher ScreenWatcher) error {
+	for {
+		old := r.current()
+		err := watchContext(ctx, old, watcher)
+		r.mu.Lock()
+		screen, closed := r.screen, r.closed
+		r.mu.Unlock()
+		if screen == old || closed || ctx.Err() != nil {
+			return err
+		}
+		// The old screen was closed by retarget, so its error, if any,
+		// is just that of it being closed.
+		diag.Event("retargeted screen")
+		if screen.IsPresent() && (!old.IsPresent() || screen.IsScreenSaverOn() != old.IsScreenSaverOn()) {
+			if err := watcher.SSChange(screen.IsScreenSaverOn()); err != nil {
+				return err
+			}
+		}
+	}
+}
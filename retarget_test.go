@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRetargetableScreen(t *testing.T) {
+	is := is.New(t)
+	first := NewFakeScreen(false /* ssOn */, true /* present */)
+	second := NewFakeScreen(true /* ssOn */, true /* present */)
+	r := newRetargetableScreen(first)
+
+	changes := make(chan bool, 1) // as Send waits for the change
+	done := make(chan error)
+	go func() {
+		done <- r.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	is.NoErr(first.Send(fakeSSOn))
+	is.Equal(true, <-changes)
+	is.NoErr(first.Send(fakeSSOff))
+	is.Equal(false, <-changes)
+
+	// The new monitor has its screen saver on.
+	r.retarget(second)
+	is.Equal(true, <-changes)
+	is.True(r.IsScreenSaverOn())
+	is.NoErr(first.Send(fakeSSOn)) // does not block as the screen is closed
+
+	is.NoErr(second.Send(fakeSSOff))
+	is.Equal(false, <-changes)
+
+	r.Close()
+	is.NoErr(<-done)
+}
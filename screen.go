@@ -2,11 +2,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/anoopengineer/edidparser/edid"
 	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/dpms"
 	"github.com/jezek/xgb/randr"
 	"github.com/jezek/xgb/screensaver"
 	"github.com/jezek/xgb/xproto"
@@ -26,9 +32,131 @@ type Screen struct {
 
 	manufacturerID string
 	productCode    uint16
+	serial         string // or empty to match any (see [Screen.MatchSerial])
+	output         string // or empty to match by EDID (see [Screen.MatchOutput])
+
+	// noScreenSaver is true if the X server has no SCREENSAVER extension,
+	// so the screen saver state must be polled (see [Screen.UseIdleTime]).
+	noScreenSaver bool
+
+	// poll returns whether the screen is blanked when the screen saver
+	// state is polled every pollInterval instead of following the X
+	// screen saver (see [Screen.UseDPMS] and [Screen.UseIdleTime]), and
+	// polled names what is polled for messages. poll is nil otherwise.
+	poll         func() (bool, error)
+	pollInterval time.Duration
+	polled       string
+
+	// hybrid is whether the screen saver events are followed alongside
+	// the polled DPMS power level, whichever changes first, and polledOn
+	// is the last polled state (see [Screen.UseDPMSWithScreenSaver]).
+	hybrid   bool
+	polledOn bool
+
+	// wakeOnInput is whether the screen saver turns off on the first user
+	// input rather than when the X screen saver deactivates (see
+	// [Screen.WakeOnInput]).
+	wakeOnInput bool
+
+	// blankDPMS is whether [Screen.Blank] forces DPMS off rather than
+	// activating the screen saver (see [Screen.BlankWithDPMS]).
+	blankDPMS bool
+
+	// blankOutput is whether [Screen.Blank] turns off only the monitor's
+	// output (see [Screen.BlankOutput]).
+	blankOutput bool
+
+	// hotplugSettle is how long to wait after a RANDR event for further
+	// events before checking whether the monitor is present (see
+	// [Screen.DebounceHotplug]). It is zero to check on every event.
+	hotplugSettle time.Duration
+
+	// presenceGrace is how long the monitor must be gone before it is
+	// taken to be absent (see [Screen.PresenceGrace]), and grace is the
+	// timer running while it is gone but not yet taken to be absent. It
+	// is only used by [Screen.Watch].
+	presenceGrace time.Duration
+	grace         *time.Timer
+
+	// edidAtom is the atom of the EDID output property, and connections
+	// are the last connection states of the outputs seen in RANDR events,
+	// to tell the events that may change the monitor's presence (see
+	// [Screen.presenceChange]). connections is only used by [Screen.Watch].
+	edidAtom    xproto.Atom
+	connections map[randr.Output]byte
+
+	// lock is a backend whose screen saver is on while the session is
+	// locked, or nil if locking is not watched (see [Screen.UseLock]).
+	lock ScreenBackend
+
+	// blanked is whether the screen saver (or DPMS) has blanked the
+	// screen and locked is whether the session is locked. The screen saver
+	// is taken to be on while either is true. They are only used by
+	// [Screen.Watch] once it is running.
+	blanked bool
+	locked  bool
+
+	// events and locks receive the events from the X server and the
+	// changes of the session lock. Each is fed by a goroutine started by
+	// the first [Screen.WatchContext] and shared by later ones, so no
+	// event is read twice or lost between them. stop is closed by
+	// [Screen.Close] to stop the goroutines.
+	events    chan xEvent
+	locks     chan xEvent
+	startRead sync.Once
+	stop      chan struct{}
+
+	// watcherSet holds the watchers called alongside the watcher given to
+	// [Screen.Watch] (see [watcherSet.AddWatcher]).
+	watcherSet
 
 	ssOn    atomic.Bool
 	present atomic.Bool
+	closed  atomic.Bool
+}
+
+// ScreenBackend is a source of screen saver and monitor presence events that
+// drives a [ScreenWatcher]. [Screen] is the X11 implementation.
+type ScreenBackend interface {
+	// Watch calls the watcher when the screen saver changes state while
+	// the monitor is present, until the backend is closed.
+	Watch(watcher ScreenWatcher) error
+	// IsScreenSaverOn returns the current state of the screen saver.
+	IsScreenSaverOn() bool
+	// IsPresent returns whether the managed monitor is present.
+	IsPresent() bool
+	// Blank forces the screen saver on.
+	Blank() error
+	// Unblank forces the screen saver off.
+	Unblank() error
+	// Close stops the backend, causing Watch to return.
+	Close()
+}
+
+// ContextWatcher is implemented by screen backends whose Watch can also be
+// stopped by cancelling a context, rather than only by closing the backend
+// from another goroutine. [Screen] implements it.
+type ContextWatcher interface {
+	// WatchContext is like Watch but also returns nil once ctx is done.
+	WatchContext(ctx context.Context, watcher ScreenWatcher) error
+}
+
+// watchContext watches screen until it is closed or ctx is done. Backends
+// that are not a [ContextWatcher] are closed when ctx is done to stop them.
+func watchContext(ctx context.Context, screen ScreenBackend, watcher ScreenWatcher) error {
+	if cw, ok := screen.(ContextWatcher); ok {
+		return cw.WatchContext(ctx, watcher)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			screen.Close()
+		case <-stop:
+		}
+	}()
+	return screen.Watch(watcher)
 }
 
 // ScreenWatcher is a callback interface that is called by [Watch] when the
@@ -46,42 +174,55 @@ func (swf ScreenWatcherFunc) SSChange(ssOn bool) error {
 	return swf(ssOn)
 }
 
-// NewScreen returns a new Screen with a connection to the X server for the
-// given display, with the RANDR and SCREENSAVER extensions initialised (i.e.
-// verified that the X server has these extensions). The manufacturerID and
-// productCode are used for monitor presence detection.
+// NewScreen returns a new Screen using the given connection to the X server,
+// with the RANDR and SCREENSAVER extensions initialised (i.e. verified that
+// the X server has these extensions). The manufacturerID and productCode are
+// used for monitor presence detection. The Screen takes ownership of the
+// connection and closes it in [Screen.Close].
 //
-// An error is returned if the connection to the X server could not be
-// established, the extensions are not present on the server or the current
-// screen saver state or monitor presence could not be queried.
-func NewScreen(display, manufacturerID string, productCode uint16) (*Screen, error) {
-	c, err := xgb.NewConnDisplay(display)
-	if err != nil {
-		return nil, fmt.Errorf("could not open display %s: %w", display, err)
-	}
-
+// X servers without the SCREENSAVER extension, such as Xwayland, are
+// supported but have no screen saver events, so [Screen.HasScreenSaver]
+// returns false and the screen saver stays off unless it is polled with
+// [Screen.UseIdleTime] or [Screen.UseDPMS].
+//
+// An error is returned if the RANDR extension is not present on the server
+// or the current screen saver state or monitor presence could not be
+// queried.
+func NewScreen(c *xgb.Conn, manufacturerID string, productCode uint16) (*Screen, error) {
 	// Intitialise the RANDR and SCREENSAVER extensions. These will fail if the
 	// X11 server does not support these extensions.
 	if err := randr.Init(c); err != nil {
 		return nil, fmt.Errorf("could not initialise RANDR extension: %w", err)
 	}
-	if err := screensaver.Init(c); err != nil {
-		return nil, fmt.Errorf("could not initialise SCREENSAVER extension: %w", err)
-	}
 
 	s := &Screen{
 		xconn:          c,
 		rootWin:        xproto.Setup(c).DefaultScreen(c).Root,
 		manufacturerID: manufacturerID,
 		productCode:    productCode,
+		events:         make(chan xEvent),
+		locks:          make(chan xEvent),
+		stop:           make(chan struct{}),
+	}
+	if err := screensaver.Init(c); err != nil {
+		diag.Event("could not initialise SCREENSAVER extension: %v", err)
+		s.noScreenSaver = true
 	}
+	edidAtom, err := xproto.InternAtom(c, false /* OnlyIfExists */, 4, "EDID").Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not intern X11 atom: %w", err)
+	}
+	s.edidAtom = edidAtom.Atom
 
 	// Set the initial state of the screen saver and monitor presence.
-	ssOn, err := s.queryScreenSaver()
-	if err != nil {
-		return nil, fmt.Errorf("could not query screen saver state: %w", err)
+	if !s.noScreenSaver {
+		ssOn, err := s.queryScreenSaver()
+		if err != nil {
+			return nil, fmt.Errorf("could not query screen saver state: %w", err)
+		}
+		s.blanked = ssOn
+		s.ssOn.Store(ssOn)
 	}
-	s.ssOn.Store(ssOn)
 
 	present, err := s.queryPresence()
 	if err != nil {
@@ -92,10 +233,193 @@ func NewScreen(display, manufacturerID string, productCode uint16) (*Screen, err
 	return s, nil
 }
 
+// UseDPMS makes the screen saver state follow the DPMS power level of the
+// monitor instead of the X screen saver, for setups that put the monitor to
+// sleep with DPMS (`xset dpms`) rather than running a screen saver. The
+// screen saver is on while the monitor is in standby, suspend or off, and the
+// power level is polled every interval as DPMS has no events.
+//
+// An error is returned if the X server does not have the DPMS extension or
+// the power level could not be queried.
+func (s *Screen) UseDPMS(interval time.Duration) error {
+	if err := dpms.Init(s.xconn); err != nil {
+		return fmt.Errorf("could not initialise DPMS extension: %w", err)
+	}
+	off, err := s.queryDPMS()
+	if err != nil {
+		return fmt.Errorf("could not query DPMS power level: %w", err)
+	}
+	s.blanked = off
+	s.ssOn.Store(s.blanked || s.locked)
+	s.poll, s.pollInterval, s.polled = s.queryDPMS, interval, "DPMS power level"
+	return nil
+}
+
+// BlankWithDPMS makes [Screen.Blank] force the monitor off with DPMS, as
+// `xset dpms force off` does, instead of activating the X screen saver,
+// which some setups honour far more reliably. Forcing the power level needs
+// DPMS enabled in the X server, so the screen saver is activated instead
+// while it is disabled, leaving DPMS as the user set it (see [blankDPMS]).
+//
+// An error is returned if the X server does not have the DPMS extension.
+func (s *Screen) BlankWithDPMS() error {
+	if err := dpms.Init(s.xconn); err != nil {
+		return fmt.Errorf("could not initialise DPMS extension: %w", err)
+	}
+	s.blankDPMS = true
+	return nil
+}
+
+// UseDPMSWithScreenSaver makes the screen saver state follow both the X
+// screen saver and the DPMS power level of the monitor, for setups that have
+// both enabled with different timeouts. Whichever turns on first blanks the
+// screen and whichever turns off first unblanks it; the other turning the
+// same way later is then ignored, so the watcher is called once per blank
+// and unblank cycle. The power level is polled every interval.
+//
+// An error is returned if the X server does not have the DPMS extension or
+// the screen saver or power level could not be queried.
+func (s *Screen) UseDPMSWithScreenSaver(interval time.Duration) error {
+	if err := s.UseDPMS(interval); err != nil {
+		return err
+	}
+	ssOn, err := s.queryScreenSaver()
+	if err != nil {
+		return fmt.Errorf("could not query screen saver: %w", err)
+	}
+	s.hybrid, s.polledOn = true, s.blanked
+	s.blanked = s.blanked || ssOn
+	s.ssOn.Store(s.blanked || s.locked)
+	return nil
+}
+
+// HasScreenSaver returns whether the X server has the SCREENSAVER extension,
+// without which there are no screen saver events.
+func (s *Screen) HasScreenSaver() bool {
+	return !s.noScreenSaver
+}
+
+// UseIdleTime makes the screen saver state follow the time since the last
+// user input instead of the X screen saver events, for X servers without the
+// SCREENSAVER extension or whose screen saver never activates. The screen
+// saver is on once there has been no input for the timeout, and the idle
+// time is polled every interval. The idle time comes from the SCREENSAVER
+// extension if the X server has it, otherwise from the IDLETIME counter of
+// the SYNC extension.
+//
+// An error is returned if the X server has neither extension or the idle
+// time could not be queried.
+func (s *Screen) UseIdleTime(interval, timeout time.Duration) error {
+	idle := s.queryIdle
+	if s.noScreenSaver {
+		counter, err := newIdleCounter(s.xconn)
+		if err != nil {
+			return err
+		}
+		idle = counter.idle
+	}
+	s.poll = func() (bool, error) {
+		d, err := idle()
+		return d >= timeout, err
+	}
+	blanked, err := s.poll()
+	if err != nil {
+		return fmt.Errorf("could not query idle time: %w", err)
+	}
+	s.blanked = blanked
+	s.ssOn.Store(s.blanked || s.locked)
+	s.pollInterval, s.polled = interval, "idle time"
+	return nil
+}
+
+// MatchSerial makes the monitor also be identified by its EDID serial
+// number, to tell apart identical monitors (see [edidSerialMatches]).
+//
+// An error is returned if the monitor presence could not be queried.
+func (s *Screen) MatchSerial(serial string) error {
+	s.serial = serial
+	present, err := s.queryPresence()
+	if err != nil {
+		return fmt.Errorf("could not query TV presence: %w", err)
+	}
+	s.present.Store(present)
+	return nil
+}
+
+// MatchOutput makes the monitor be identified by the name of the RANDR
+// output it is connected to, e.g. HDMI-A-1, instead of by its EDID. The
+// monitor is present while the output is connected.
+//
+// An error is returned if the monitor presence could not be queried.
+func (s *Screen) MatchOutput(name string) error {
+	s.output = name
+	present, err := s.queryPresence()
+	if err != nil {
+		return fmt.Errorf("could not query TV presence: %w", err)
+	}
+	s.present.Store(present)
+	return nil
+}
+
+// WakeOnInput makes the screen saver turn off on the first keyboard or mouse
+// input while the screen is blanked, so the TV starts turning on before the
+// X screen saver or locker has deactivated. Input is noticed with an alarm
+// on the IDLETIME counter of the SYNC extension, which triggers when the
+// counter drops back after the session has been idle for a second, so input
+// while in use does not flood us with events.
+//
+// An error is returned if the X server does not have the SYNC extension or
+// the alarm could not be created.
+func (s *Screen) WakeOnInput() error {
+	counter, err := newIdleCounter(s.xconn)
+	if err != nil {
+		return err
+	}
+	if err := counter.alarmOnInput(time.Second); err != nil {
+		return err
+	}
+	s.wakeOnInput = true
+	return nil
+}
+
+// DebounceHotplug makes the screen wait until there have been no RANDR
+// events for the settle time before checking whether the monitor is present,
+// as plugging in a cable generates a burst of events that would otherwise
+// each query the X server for the monitor.
+func (s *Screen) DebounceHotplug(settle time.Duration) {
+	s.hotplugSettle = settle
+}
+
+// PresenceGrace makes the screen wait for the grace period after the monitor
+// drops off RANDR before taking it to be absent, as TVs briefly disappear
+// with HDMI handshake glitches while powering on and off. If the monitor is
+// back within the grace period, it is taken to have been present all along.
+func (s *Screen) PresenceGrace(grace time.Duration) {
+	s.presenceGrace = grace
+}
+
+// UseLock makes the screen saver also count as on while the session is
+// locked, so locking the session turns the TV off straight away rather than
+// when the screen saver next turns on. The lock backend's screen saver state
+// is whether the session is locked, or more generally whether anything else,
+// such as the laptop lid being closed, should turn the TV off. The Screen
+// takes ownership of the lock backend and closes it in [Screen.Close].
+func (s *Screen) UseLock(lock ScreenBackend) {
+	s.lock = lock
+	s.locked = lock.IsScreenSaverOn()
+	s.ssOn.Store(s.blanked || s.locked)
+}
+
 // Close closes the screen's connection to the X server. This will cause
 // [Screen.Watch] to return.
 func (s *Screen) Close() {
+	if !s.closed.Swap(true) {
+		close(s.stop)
+	}
 	s.xconn.Close()
+	if s.lock != nil {
+		s.lock.Close()
+	}
 }
 
 // IsScreenSaverOn returns the current state of the screen saver.
@@ -108,68 +432,440 @@ func (s *Screen) IsPresent() bool {
 	return s.present.Load()
 }
 
-// Blank forces the screen saver to an active/enabled state.
+// Blank forces the screen saver to an active/enabled state, the monitor off
+// with DPMS (see [Screen.BlankWithDPMS]) or just its output off (see
+// [Screen.BlankOutput]).
 func (s *Screen) Blank() error {
+	if s.blankOutput {
+		return s.disableOutput()
+	}
+	if s.blankDPMS {
+		return blankDPMS(xDPMS{s.xconn}, s.activateScreenSaver)
+	}
+	return s.activateScreenSaver()
+}
+
+func (s *Screen) activateScreenSaver() error {
 	return xproto.ForceScreenSaverChecked(s.xconn, xproto.ScreenSaverActive).Check()
 }
 
+// dpmsControl is the DPMS requests made to blank the screen with DPMS, so
+// it can be tested without an X server. [xDPMS] is the X11 implementation.
+type dpmsControl interface {
+	// enabled returns whether DPMS is enabled in the X server.
+	enabled() (bool, error)
+	// forceLevel forces the power level of the monitor.
+	forceLevel(level uint16) error
+}
+
+// blankDPMS forces the monitor off with DPMS if DPMS is enabled, and calls
+// fallback to blank the screen otherwise. DPMS is never enabled, as that
+// would override the user turning it off with `xset -dpms` for good.
+func blankDPMS(d dpmsControl, fallback func() error) error {
+	enabled, err := d.enabled()
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		diag.Event("DPMS is disabled; activating the screen saver instead")
+		return fallback()
+	}
+	return d.forceLevel(dpms.DPMSModeOff)
+}
+
+// xDPMS is the [dpmsControl] of an X server.
+type xDPMS struct{ c *xgb.Conn }
+
+func (x xDPMS) enabled() (bool, error) {
+	info, err := dpms.Info(x.c).Reply()
+	if err != nil {
+		return false, fmt.Errorf("could not query DPMS: %w", err)
+	}
+	return info.State, nil
+}
+
+func (x xDPMS) forceLevel(level uint16) error {
+	if err := dpms.ForceLevelChecked(x.c, level).Check(); err != nil {
+		return fmt.Errorf("could not force DPMS power level: %w", err)
+	}
+	return nil
+}
+
+// Unblank turns back on the output turned off by [Screen.Blank] if blanking
+// only the output (see [Screen.BlankOutput]), deactivates the screen saver
+// and, if the X server has DPMS enabled and the monitor is not on, forces the
+// monitor on with DPMS. It is the mirror of [Screen.Blank]. The screen is
+// woken even if the output cannot be turned back on.
+func (s *Screen) Unblank() error {
+	var outputErr error
+	if s.blankOutput {
+		outputErr = s.enableOutput()
+	}
+	if err := s.wake(); err != nil {
+		return err
+	}
+	return outputErr
+}
+
+// wake deactivates the screen saver and forces the monitor on with DPMS if
+// it is enabled and the monitor is not on.
+func (s *Screen) wake() error {
+	if err := xproto.ForceScreenSaverChecked(s.xconn, xproto.ScreenSaverReset).Check(); err != nil {
+		return fmt.Errorf("could not deactivate screen saver: %w", err)
+	}
+	if err := dpms.Init(s.xconn); err != nil {
+		// No DPMS, so the monitor cannot have been put to sleep.
+		return nil //nolint:nilerr // not an error
+	}
+	info, err := dpms.Info(s.xconn).Reply()
+	if err != nil {
+		return fmt.Errorf("could not query DPMS power level: %w", err)
+	}
+	if !info.State || info.PowerLevel == dpms.DPMSModeOn {
+		return nil
+	}
+	if err := dpms.ForceLevelChecked(s.xconn, dpms.DPMSModeOn).Check(); err != nil {
+		return fmt.Errorf("could not force DPMS on: %w", err)
+	}
+	return nil
+}
+
 // Watch loops while the connection to the X server is open (see
 // [Screen.Close]) calling the given watcher when the state of the screen saver
 // changes, but only if the screen's monitor is present. If the screen's
 // monitor becomes present the state of the screen saver at that time is passed
-// to the watcher.
+// to the watcher. If the connection is lost other than by closing the screen,
+// [ErrXConnLost] is returned.
+//
+// The watchers added with [watcherSet.AddWatcher] are called too, after
+// the given watcher, which may be nil if there are any.
 func (s *Screen) Watch(watcher ScreenWatcher) error {
-	// Listen for randr events (monitor plug/unplug)
-	err := randr.SelectInputChecked(s.xconn, s.rootWin, randr.NotifyMaskOutputChange).Check()
+	return s.WatchContext(context.Background(), watcher)
+}
+
+// WatchContext is like [Screen.Watch] but also returns nil once ctx is done,
+// leaving the connection to the X server open. The screen can then be
+// watched again, with the X events arriving in the meantime handled then.
+func (s *Screen) WatchContext(ctx context.Context, watcher ScreenWatcher) error {
+	watcher = s.fanOut(watcher)
+
+	// Listen for randr events (monitor plug/unplug, EDID changes)
+	mask := uint16(randr.NotifyMaskOutputChange | randr.NotifyMaskOutputProperty)
+	err := randr.SelectInputChecked(s.xconn, s.rootWin, mask).Check()
 	if err != nil {
 		return fmt.Errorf("could not watch RANDR events: %w", err)
 	}
 
 	// Listen for screensaver events (screensaver on/off)
 	// For some reason, screensaver wants the root window as a "Drawable"
-	drawableRoot := xproto.Drawable(s.rootWin)
-	err = screensaver.SelectInputChecked(s.xconn, drawableRoot, screensaver.EventNotifyMask).Check()
-	if err != nil {
-		return fmt.Errorf("could not watch SCREENSAVER events: %w", err)
+	if !s.noScreenSaver {
+		drawableRoot := xproto.Drawable(s.rootWin)
+		err = screensaver.SelectInputChecked(s.xconn, drawableRoot, screensaver.EventNotifyMask).Check()
+		if err != nil {
+			return fmt.Errorf("could not watch SCREENSAVER events: %w", err)
+		}
 	}
 
-	for {
-		ev, err := s.xconn.WaitForEvent()
-		if err != nil {
-			return fmt.Errorf("could not wait for events: %w", err)
+	// Wait for X events in another goroutine so the DPMS power level or
+	// idle time can be polled between them.
+	s.startRead.Do(func() {
+		go s.readEvents()
+		if s.lock != nil {
+			go s.watchLock()
 		}
-		if ev == nil { // X11 connection closed
-			return nil
+	})
+
+	var poll <-chan time.Time
+	if s.poll != nil {
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	// settled fires once RANDR events have settled when debouncing them.
+	var settle *time.Timer
+	var settled <-chan time.Time
+	defer func() {
+		if settle != nil {
+			settle.Stop()
 		}
-		switch event := ev.(type) {
-		case screensaver.NotifyEvent:
-			isOn := event.State == screensaver.StateOn || event.State == screensaver.StateCycle
-			wasOn := s.ssOn.Swap(isOn)
-			// Send the screensaver state if it changes and the monitor is present
-			if isOn != wasOn && s.IsPresent() {
-				if err := watcher.SSChange(isOn); err != nil {
-					return err
+		s.stopGrace()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-s.events:
+			if !ok { // X11 connection closed
+				if s.closed.Load() {
+					return nil
 				}
+				return ErrXConnLost
 			}
-		case randr.NotifyEvent:
-			// It is too hard to determine from the randr event whether it is for
-			// the display being connected/disconnected, so for every randr event,
-			// just check the presence by checking the randr properties.
+			if e.err != nil {
+				if fatalXError(e.err) {
+					return fmt.Errorf("could not wait for events: %w", e.err)
+				}
+				diag.Event("ignoring X error: %v", e.err)
+				continue
+			}
+			if ev, ok := e.ev.(randr.NotifyEvent); ok && s.hotplugSettle > 0 {
+				if !s.presenceChange(ev) {
+					continue
+				}
+				if settle != nil {
+					settle.Stop()
+				}
+				settle = time.NewTimer(s.hotplugSettle)
+				settled = settle.C
+				continue
+			}
+			if err := s.handleEvent(e.ev, watcher); err != nil {
+				return err
+			}
+		case <-settled:
+			settled = nil
+			if err := s.checkPresence(watcher); err != nil {
+				return err
+			}
+		case <-s.graceExpired():
 			present, err := s.queryPresence()
 			if err != nil {
 				return fmt.Errorf("could not query TV presence: %w", err)
 			}
-			wasPresent := s.present.Swap(present)
-			// If the monitor has just appeared, send the screensaver state
-			if present && !wasPresent {
-				if err := watcher.SSChange(s.IsScreenSaverOn()); err != nil {
-					return err
+			if err := s.endGrace(present, watcher); err != nil {
+				return err
+			}
+		case e := <-s.locks:
+			if e.err != nil {
+				return fmt.Errorf("could not watch session lock: %w", e.err)
+			}
+			if err := s.update(s.blanked, e.locked, watcher); err != nil {
+				return err
+			}
+		case <-poll:
+			blanked, err := s.poll()
+			if err != nil {
+				if s.closed.Load() {
+					return nil
 				}
+				return fmt.Errorf("could not query %s: %w", s.polled, err)
+			}
+			if err := s.handlePoll(blanked, watcher); err != nil {
+				return err
 			}
 		}
 	}
 }
 
+// xEvent is an event or error from the X server, or a change of the session
+// lock.
+type xEvent struct {
+	ev     xgb.Event
+	err    error
+	locked bool
+}
+
+// readEvents sends the events from the X server to s.events until the
+// screen is closed or the connection is lost, when s.events is closed.
+func (s *Screen) readEvents() {
+	defer close(s.events)
+	for {
+		ev, err := s.xconn.WaitForEvent()
+		if ev == nil && err == nil {
+			return
+		}
+		select {
+		case s.events <- xEvent{ev: ev, err: err}:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// watchLock sends changes of the session lock to s.locks until the screen
+// is closed. An error watching the lock is sent as the last event.
+func (s *Screen) watchLock() {
+	err := s.lock.Watch(ScreenWatcherFunc(func(locked bool) error {
+		select {
+		case s.locks <- xEvent{locked: locked}:
+		case <-s.stop:
+		}
+		return nil
+	}))
+	if err != nil && !s.closed.Load() {
+		select {
+		case s.locks <- xEvent{err: err}:
+		case <-s.stop:
+		}
+	}
+}
+
+// handleEvent handles a screen saver or RANDR event from the X server.
+func (s *Screen) handleEvent(ev xgb.Event, watcher ScreenWatcher) error {
+	switch event := ev.(type) {
+	case screensaver.NotifyEvent:
+		isOn := event.State == screensaver.StateOn || event.State == screensaver.StateCycle
+		if s.poll != nil && !s.hybrid {
+			diag.Event("screen saver on=%v (ignored as following %s)", isOn, s.polled)
+			return nil
+		}
+		return s.update(isOn, s.locked, watcher)
+	case syncAlarmNotifyEvent:
+		if !s.wakeOnInput || !s.blanked {
+			return nil
+		}
+		diag.Event("user input while blanked")
+		return s.update(false, s.locked, watcher)
+	case randr.NotifyEvent:
+		if !s.presenceChange(event) {
+			return nil
+		}
+		return s.checkPresence(watcher)
+	}
+	return nil
+}
+
+// handlePoll handles the polled state of whether the screen is blanked.
+func (s *Screen) handlePoll(blanked bool, watcher ScreenWatcher) error {
+	if s.hybrid {
+		// Only act when the power level changes, as the screen saver
+		// may have changed the state since.
+		if blanked == s.polledOn {
+			return nil
+		}
+		s.polledOn = blanked
+	}
+	if blanked == s.blanked {
+		return nil
+	}
+	return s.update(blanked, s.locked, watcher)
+}
+
+// presenceChange returns whether a RANDR event may mean the monitor has been
+// connected or disconnected, so its presence must be checked again by
+// scanning the EDIDs of the outputs. RANDR also sends output change events
+// for mode and CRTC changes, so only those changing the connection state of
+// an output count, along with changes of an output's EDID property, as when
+// one monitor is swapped for another. Other events cannot change presence.
+func (s *Screen) presenceChange(ev randr.NotifyEvent) bool {
+	switch ev.SubCode {
+	case randr.NotifyOutputChange:
+		oc := ev.U.Oc
+		if s.connections == nil {
+			s.connections = map[randr.Output]byte{}
+		}
+		last, seen := s.connections[oc.Output]
+		s.connections[oc.Output] = oc.Connection
+		if seen && last == oc.Connection {
+			diag.Event("ignoring RANDR output change without connection change")
+			return false
+		}
+		return true
+	case randr.NotifyOutputProperty:
+		return ev.U.Op.Atom == s.edidAtom
+	}
+	return false
+}
+
+// checkPresence queries the X server for the presence of the monitor,
+// sending the screen saver state to the watcher if it has just appeared.
+func (s *Screen) checkPresence(watcher ScreenWatcher) error {
+	present, err := s.queryPresence()
+	if err != nil {
+		return fmt.Errorf("could not query TV presence: %w", err)
+	}
+	return s.presenceSeen(present, watcher)
+}
+
+// presenceSeen handles the monitor being seen to be present or not, starting
+// a grace period if it has gone (see [Screen.PresenceGrace]).
+func (s *Screen) presenceSeen(present bool, watcher ScreenWatcher) error {
+	if !present && s.IsPresent() && s.presenceGrace > 0 {
+		if s.grace == nil {
+			diag.Event("monitor gone; waiting %v before taking it as absent", s.presenceGrace)
+			s.grace = time.NewTimer(s.presenceGrace)
+		}
+		return nil
+	}
+	return s.setPresent(present, watcher)
+}
+
+// setPresent records whether the monitor is present, ending any grace
+// period, and passes the state of the screen saver to the watcher if the
+// monitor has just appeared.
+func (s *Screen) setPresent(present bool, watcher ScreenWatcher) error {
+	if s.grace != nil {
+		diag.Event("monitor back within grace period")
+	}
+	s.stopGrace()
+	wasPresent := s.present.Swap(present)
+	diag.Event("monitor present=%v (was %v)", present, wasPresent)
+	// If the monitor has just appeared, send the screensaver state
+	if present && !wasPresent {
+		return watcher.SSChange(s.IsScreenSaverOn())
+	}
+	return nil
+}
+
+// endGrace ends the grace period once it has expired, with the monitor
+// present or not as it now is.
+func (s *Screen) endGrace(present bool, watcher ScreenWatcher) error {
+	s.grace = nil
+	return s.setPresent(present, watcher)
+}
+
+// graceExpired returns the channel of the grace period timer, or nil if the
+// monitor is not in a grace period.
+func (s *Screen) graceExpired() <-chan time.Time {
+	if s.grace == nil {
+		return nil
+	}
+	return s.grace.C
+}
+
+func (s *Screen) stopGrace() {
+	if s.grace != nil {
+		s.grace.Stop()
+		s.grace = nil
+	}
+}
+
+// update records whether the screen is blanked and the session locked,
+// sending the resulting state of the screen saver to the watcher if it
+// changes and the monitor is present.
+func (s *Screen) update(blanked, locked bool, watcher ScreenWatcher) error {
+	s.blanked, s.locked = blanked, locked
+	isOn := blanked || locked
+	wasOn := s.ssOn.Swap(isOn)
+	diag.Event("screen saver on=%v (was %v, monitor present=%v)", isOn, wasOn, s.IsPresent())
+	// Send the screensaver state if it changes and the monitor is present
+	if isOn != wasOn && s.IsPresent() {
+		return watcher.SSChange(isOn)
+	}
+	return nil
+}
+
+// queryDPMS queries the X server for whether DPMS has put the monitor to
+// sleep.
+func (s *Screen) queryDPMS() (bool, error) {
+	info, err := dpms.Info(s.xconn).Reply()
+	if err != nil {
+		return false, fmt.Errorf("DPMS Info failed: %w", err)
+	}
+	return info.State && info.PowerLevel != dpms.DPMSModeOn, nil
+}
+
+// queryIdle queries the X server for the time since the last user input.
+func (s *Screen) queryIdle() (time.Duration, error) {
+	info, err := screensaver.QueryInfo(s.xconn, xproto.Drawable(s.rootWin)).Reply()
+	if err != nil {
+		return 0, fmt.Errorf("QueryInfo failed: %w", err)
+	}
+	return time.Duration(info.MsSinceUserInput) * time.Millisecond, nil
+}
+
 // queryScreenSaver queries the X server for the state of the screen saver.
 func (s *Screen) queryScreenSaver() (bool, error) {
 	info, err := screensaver.QueryInfo(s.xconn, xproto.Drawable(s.rootWin)).Reply()
@@ -181,9 +877,12 @@ func (s *Screen) queryScreenSaver() (bool, error) {
 
 // queryPresence queries the X server for the presence of the screen's monitor.
 func (s *Screen) queryPresence() (bool, error) {
+	if s.output != "" {
+		return s.queryOutput()
+	}
 	var present bool
 	err := RangeEDID(s.xconn, s.rootWin, func(_ randr.Output, e *edid.Edid) (bool, error) {
-		if e.ManufacturerId == s.manufacturerID && e.ProductCode == s.productCode {
+		if e.ManufacturerId == s.manufacturerID && e.ProductCode == s.productCode && edidSerialMatches(e, s.serial) {
 			present = true
 			return false /* stop ranging */, nil
 		}
@@ -192,6 +891,59 @@ func (s *Screen) queryPresence() (bool, error) {
 	return present, err
 }
 
+// queryOutput queries the X server for whether the screen's output is
+// connected.
+func (s *Screen) queryOutput() (bool, error) {
+	r, err := randr.GetScreenResourcesCurrent(s.xconn, s.rootWin).Reply()
+	if err != nil {
+		return false, fmt.Errorf("could not get screens: %w", err)
+	}
+	for _, output := range r.Outputs {
+		oi, err := randr.GetOutputInfo(s.xconn, output, r.ConfigTimestamp).Reply()
+		if err != nil {
+			return false, fmt.Errorf("could not get info for output: %w", err)
+		}
+		if string(oi.Name) == s.output {
+			return oi.Connection == randr.ConnectionConnected, nil
+		}
+	}
+	return false, nil
+}
+
+// edidSerialMatches returns whether serial is the serial number of the
+// monitor with the given EDID, or is empty. Monitors give their serial
+// number as a string, a number, or both; serial matches either.
+func edidSerialMatches(e *edid.Edid, serial string) bool {
+	if serial == "" || sameSerial(strings.Trim(e.MonitorSerialNumber, " \n\x00"), serial) {
+		return true
+	}
+	return e.SerialNumber != 0 && sameSerial(strconv.FormatUint(uint64(e.SerialNumber), 10), serial)
+}
+
+// edidSerial returns the serial number of the monitor with the given EDID:
+// its serial number string if it has one, otherwise its serial number as a
+// decimal number, or empty if it has neither.
+func edidSerial(e *edid.Edid) string {
+	if serial := strings.Trim(e.MonitorSerialNumber, " \n\x00"); serial != "" {
+		return serial
+	}
+	if e.SerialNumber != 0 {
+		return strconv.FormatUint(uint64(e.SerialNumber), 10)
+	}
+	return ""
+}
+
+// sameSerial returns whether two serial numbers are the same, either as
+// strings or as numbers given in decimal or hex with a 0x prefix.
+func sameSerial(a, b string) bool {
+	if a == b {
+		return a != ""
+	}
+	na, erra := strconv.ParseUint(a, 0, 32)
+	nb, errb := strconv.ParseUint(b, 0, 32)
+	return erra == nil && errb == nil && na == nb
+}
+
 // RangeEDIDFunc is called by [RangeEDID] for each X11 xrandr output that has
 // EDID data. The function returns a bool that tells [RangeEDID] whether to
 // continue ranging over subsequent outputs or not, and an error that if not
@@ -222,21 +974,14 @@ func RangeEDID(c *xgb.Conn, root xproto.Window, fn RangeEDIDFunc) error {
 	}
 
 	for _, output := range r.Outputs {
-		// the length of 64 gives a maximum EDID data size of 256 bytes (4 * 64).
-		// EDID maxes out at 256 bytes long, so should be fine.
-		const offset, length, del, pending = 0, 64, false, false
-		// https://cgit.freedesktop.org/xorg/proto/randrproto/tree/randrproto.txt#n872
-		opr, err := randr.GetOutputProperty(c, output, edidAtom.Atom, xproto.AtomAny, offset, length, del, pending).Reply()
+		data, err := outputEDID(c, output, edidAtom.Atom)
 		if err != nil {
-			return fmt.Errorf("could not get output properties: %w", err)
-		}
-		if opr.BytesAfter != 0 {
-			return fmt.Errorf("EDID data too large. Max is 256 bytes, got %d bytes", 256+opr.BytesAfter)
+			return err
 		}
-		if len(opr.Data) == 0 {
+		if len(data) == 0 {
 			continue
 		}
-		ed, err := edid.NewEdid(opr.Data)
+		ed, err := edid.NewEdid(data)
 		if err != nil {
 			return fmt.Errorf("could not parse EDID data: %w", err)
 		}
@@ -246,3 +991,118 @@ func RangeEDID(c *xgb.Conn, root xproto.Window, fn RangeEDIDFunc) error {
 	}
 	return nil
 }
+
+// outputEDID returns the raw EDID data of an X11 xrandr output, or nil if
+// the output has no EDID property. edidAtom is the interned "EDID" atom.
+func outputEDID(c *xgb.Conn, output randr.Output, edidAtom xproto.Atom) ([]byte, error) {
+	// the length of 64 gives a maximum EDID data size of 256 bytes (4 * 64).
+	// EDID maxes out at 256 bytes long, so should be fine.
+	const offset, length, del, pending = 0, 64, false, false
+	// https://cgit.freedesktop.org/xorg/proto/randrproto/tree/randrproto.txt#n872
+	opr, err := randr.GetOutputProperty(c, output, edidAtom, xproto.AtomAny, offset, length, del, pending).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not get output properties: %w", err)
+	}
+	if opr.BytesAfter != 0 {
+		return nil, fmt.Errorf("EDID data too large. Max is 256 bytes, got %d bytes", 256+opr.BytesAfter)
+	}
+	return opr.Data, nil
+}
+
+// EDIDByOutputName returns the raw EDID data of the monitor connected to the
+// X11 xrandr output with the given name, e.g. HDMI-A-1. An error is returned
+// if there is no such output or it has no EDID.
+func EDIDByOutputName(c *xgb.Conn, name string) ([]byte, error) {
+	root := xproto.Setup(c).DefaultScreen(c).Root
+	r, err := randr.GetScreenResourcesCurrent(c, root).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not get screens: %w", err)
+	}
+	edidAtom, err := xproto.InternAtom(c, false /* OnlyIfExists */, 4, "EDID").Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not intern X11 atom: %w", err)
+	}
+	for _, output := range r.Outputs {
+		oi, err := randr.GetOutputInfo(c, output, r.ConfigTimestamp).Reply()
+		if err != nil {
+			return nil, fmt.Errorf("could not get info for output: %w", err)
+		}
+		if string(oi.Name) != name {
+			continue
+		}
+		data, err := outputEDID(c, output, edidAtom.Atom)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("output %s has no EDID", name)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("no output %s", name)
+}
+
+// OutputInfo describes an X11 xrandr output and the monitor connected to it,
+// from the monitor's EDID if it has one.
+type OutputInfo struct {
+	Output       string `json:"output"`
+	Connection   string `json:"connection"` // connected, disconnected or unknown
+	Name         string `json:"name,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	ProductCode  uint16 `json:"productCode,omitempty"`
+	Serial       string `json:"serial,omitempty"`
+}
+
+// ListOutputs returns all X11 xrandr outputs, whether connected or not, with
+// the details of their monitors.
+func ListOutputs(c *xgb.Conn) ([]OutputInfo, error) {
+	root := xproto.Setup(c).DefaultScreen(c).Root
+	r, err := randr.GetScreenResourcesCurrent(c, root).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not get screens: %w", err)
+	}
+	edidAtom, err := xproto.InternAtom(c, false /* OnlyIfExists */, 4, "EDID").Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not intern X11 atom: %w", err)
+	}
+	outputs := make([]OutputInfo, 0, len(r.Outputs))
+	for _, output := range r.Outputs {
+		info, err := outputInfo(c, output, r.ConfigTimestamp, edidAtom.Atom)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, info)
+	}
+	return outputs, nil
+}
+
+// outputConnections names the RANDR connection states as in [OutputInfo].
+var outputConnections = map[byte]string{
+	randr.ConnectionConnected:    "connected",
+	randr.ConnectionDisconnected: "disconnected",
+	randr.ConnectionUnknown:      "unknown",
+}
+
+// outputInfo returns the details of an xrandr output and its monitor.
+func outputInfo(c *xgb.Conn, output randr.Output, configTimestamp xproto.Timestamp, edidAtom xproto.Atom) (OutputInfo, error) {
+	oi, err := randr.GetOutputInfo(c, output, configTimestamp).Reply()
+	if err != nil {
+		return OutputInfo{}, fmt.Errorf("could not get info for output: %w", err)
+	}
+	info := OutputInfo{Output: string(oi.Name), Connection: outputConnections[oi.Connection]}
+	data, err := outputEDID(c, output, edidAtom)
+	if err != nil {
+		return OutputInfo{}, err
+	}
+	if len(data) != 0 {
+		e, err := edid.NewEdid(data)
+		if err != nil {
+			return OutputInfo{}, fmt.Errorf("could not parse EDID data of %s: %w", info.Output, err)
+		}
+		info.Name = strings.Trim(e.MonitorName, " \n\x00")
+		info.Manufacturer = e.ManufacturerId
+		info.ProductCode = e.ProductCode
+		info.Serial = edidSerial(e)
+	}
+	return info, nil
+}
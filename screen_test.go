@@ -0,0 +1,189 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jezek/xgb/dpms"
+	"github.com/jezek/xgb/randr"
+	"github.com/jezek/xgb/screensaver"
+	"github.com/jezek/xgb/xproto"
+	"github.com/matryer/is"
+)
+
+func TestScreenPresenceChange(t *testing.T) {
+	is := is.New(t)
+	const edidAtom = 42
+	s := &Screen{edidAtom: edidAtom}
+	outputChange := func(output randr.Output, connection byte) randr.NotifyEvent {
+		return randr.NotifyEvent{
+			SubCode: randr.NotifyOutputChange,
+			U:       randr.NotifyDataUnion{Oc: randr.OutputChange{Output: output, Connection: connection}},
+		}
+	}
+	propertyChange := func(atom xproto.Atom) randr.NotifyEvent {
+		return randr.NotifyEvent{
+			SubCode: randr.NotifyOutputProperty,
+			U:       randr.NotifyDataUnion{Op: randr.OutputProperty{Output: 1, Atom: atom}},
+		}
+	}
+
+	is.True(s.presenceChange(outputChange(1, randr.ConnectionConnected)))     // first seen
+	is.True(!s.presenceChange(outputChange(1, randr.ConnectionConnected)))    // mode change
+	is.True(s.presenceChange(outputChange(2, randr.ConnectionConnected)))     // other output
+	is.True(s.presenceChange(outputChange(1, randr.ConnectionDisconnected)))  // unplugged
+	is.True(!s.presenceChange(outputChange(1, randr.ConnectionDisconnected))) // still unplugged
+	is.True(s.presenceChange(propertyChange(edidAtom)))
+	is.True(!s.presenceChange(propertyChange(edidAtom + 1)))
+	is.True(!s.presenceChange(randr.NotifyEvent{SubCode: randr.NotifyCrtcChange}))
+}
+
+type fakeDPMS struct {
+	on     bool
+	levels []uint16
+}
+
+func (f *fakeDPMS) enabled() (bool, error) { return f.on, nil }
+
+func (f *fakeDPMS) forceLevel(level uint16) error {
+	f.levels = append(f.levels, level)
+	return nil
+}
+
+func TestBlankDPMS(t *testing.T) {
+	is := is.New(t)
+	var fellBack bool
+	fallback := func() error {
+		fellBack = true
+		return nil
+	}
+
+	d := &fakeDPMS{on: true}
+	is.NoErr(blankDPMS(d, fallback))
+	is.Equal(d.levels, []uint16{dpms.DPMSModeOff})
+	is.True(!fellBack)
+
+	d = &fakeDPMS{on: false} // xset -dpms
+	is.NoErr(blankDPMS(d, fallback))
+	is.Equal(len(d.levels), 0) // DPMS must be left alone while disabled
+	is.True(fellBack)
+}
+
+// presenceWatcher records the screen saver states passed to it.
+type presenceWatcher struct{ changes []bool }
+
+func (w *presenceWatcher) SSChange(ssOn bool) error {
+	w.changes = append(w.changes, ssOn)
+	return nil
+}
+
+func TestScreenPresenceGrace(t *testing.T) {
+	is := is.New(t)
+	w := &presenceWatcher{}
+	s := &Screen{presenceGrace: time.Hour}
+	s.present.Store(true)
+
+	// The monitor comes back within the grace period.
+	is.NoErr(s.presenceSeen(false, w))
+	is.True(s.IsPresent())
+	is.True(s.graceExpired() != nil)
+	is.NoErr(s.presenceSeen(true, w))
+	is.True(s.IsPresent())
+	is.Equal(s.graceExpired(), nil)
+	is.Equal(w.changes, nil) // no change while in grace
+
+	// A second glitch restarts the grace period rather than stacking.
+	is.NoErr(s.presenceSeen(false, w))
+	timer := s.grace
+	is.NoErr(s.presenceSeen(false, w))
+	is.Equal(s.grace, timer)
+
+	// Closing the screen ends the watch, which stops the timer.
+	s.stopGrace()
+	is.Equal(s.graceExpired(), nil)
+	is.True(!timer.Stop()) // already stopped
+}
+
+func TestScreenPresenceGraceExpires(t *testing.T) {
+	is := is.New(t)
+	w := &presenceWatcher{}
+	s := &Screen{presenceGrace: time.Millisecond}
+	s.present.Store(true)
+	s.ssOn.Store(true)
+
+	is.NoErr(s.presenceSeen(false, w))
+	<-s.graceExpired()
+	is.NoErr(s.endGrace(false, w))
+	is.True(!s.IsPresent())
+	is.Equal(s.graceExpired(), nil)
+	is.Equal(w.changes, nil)
+
+	// Back after the grace period: the screen saver state is passed on.
+	is.NoErr(s.presenceSeen(true, w))
+	is.True(s.IsPresent())
+	is.Equal(w.changes, []bool{true})
+}
+
+func TestScreenNoPresenceGrace(t *testing.T) {
+	is := is.New(t)
+	w := &presenceWatcher{}
+	s := &Screen{}
+	s.present.Store(true)
+	is.NoErr(s.presenceSeen(false, w))
+	is.True(!s.IsPresent())
+	is.Equal(s.graceExpired(), nil)
+}
+
+func TestScreenDPMSWithScreenSaver(t *testing.T) {
+	ssEvent := func(on bool) screensaver.NotifyEvent {
+		if on {
+			return screensaver.NotifyEvent{State: screensaver.StateOn}
+		}
+		return screensaver.NotifyEvent{State: screensaver.StateOff}
+	}
+	newScreen := func() *Screen {
+		s := &Screen{poll: func() (bool, error) { return false, nil }, polled: "DPMS power level", hybrid: true}
+		s.present.Store(true)
+		return s
+	}
+
+	t.Run("screen saver first", func(t *testing.T) {
+		is := is.New(t)
+		w := &presenceWatcher{}
+		s := newScreen()
+		is.NoErr(s.handleEvent(ssEvent(true), w))
+		is.NoErr(s.handlePoll(true, w)) // DPMS off later: ignored
+		is.Equal(w.changes, []bool{true})
+		is.NoErr(s.handleEvent(ssEvent(false), w))
+		is.NoErr(s.handlePoll(false, w)) // DPMS back on: ignored
+		is.Equal(w.changes, []bool{true, false})
+		is.NoErr(s.handlePoll(false, w)) // unchanged
+		is.Equal(w.changes, []bool{true, false})
+	})
+
+	t.Run("DPMS first", func(t *testing.T) {
+		is := is.New(t)
+		w := &presenceWatcher{}
+		s := newScreen()
+		is.NoErr(s.handlePoll(true, w))
+		is.NoErr(s.handleEvent(ssEvent(true), w)) // screen saver later: ignored
+		is.Equal(w.changes, []bool{true})
+		is.NoErr(s.handlePoll(false, w))
+		is.NoErr(s.handleEvent(ssEvent(false), w)) // screen saver off: ignored
+		is.Equal(w.changes, []bool{true, false})
+		is.NoErr(s.handlePoll(true, w)) // still reported as blanked once
+		is.NoErr(s.handlePoll(true, w))
+		is.Equal(w.changes, []bool{true, false, true})
+	})
+
+	t.Run("DPMS on first", func(t *testing.T) {
+		is := is.New(t)
+		w := &presenceWatcher{}
+		s := newScreen()
+		is.NoErr(s.handleEvent(ssEvent(true), w))
+		is.NoErr(s.handlePoll(true, w))
+		is.NoErr(s.handlePoll(false, w)) // DPMS back on: unblanked
+		is.NoErr(s.handleEvent(ssEvent(false), w))
+		is.Equal(w.changes, []bool{true, false})
+	})
+}
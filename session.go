@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// sessionActivity tracks whether the systemd logind session of offscreen is
+// the active session of its seat. It is not while the user has switched to
+// another virtual terminal, when the screen saver state of our session means
+// nothing, so the TV is left alone until the session is active again.
+//
+// The methods of sessionActivity can be called on a nil *sessionActivity,
+// which is always active, for when the session is not followed.
+type sessionActivity struct {
+	conn *dbusConn
+	path string // object path of the session
+
+	mu       sync.Mutex
+	inactive bool
+	// onActive are called when the session becomes active again.
+	onActive []func()
+}
+
+// newSessionActivity connects to the system bus and starts tracking whether
+// our logind session is active. The session is found as for
+// [NewLogindDBusScreen].
+func newSessionActivity() (*sessionActivity, error) {
+	const sessionIface = "org.freedesktop.login1.Session"
+	c, err := dialSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	fail := func(format string, err error) (*sessionActivity, error) {
+		c.Close()
+		return nil, fmt.Errorf(format, err)
+	}
+	path, err := logindSession(c)
+	if err != nil {
+		return fail("%w", err)
+	}
+	if err := c.addMatch("type='signal',sender='org.freedesktop.login1',path='" + path + "',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged'"); err != nil {
+		return fail("%w", err)
+	}
+	v, err := c.getProperty("org.freedesktop.login1", path, sessionIface, "Active")
+	if err != nil {
+		return fail("could not get logind session state: %w", err)
+	}
+	active, _ := v.(bool)
+	sa := &sessionActivity{conn: c, path: path, inactive: !active}
+	go sa.run()
+	return sa, nil
+}
+
+// Close stops tracking the session.
+func (sa *sessionActivity) Close() {
+	if sa != nil {
+		sa.conn.Close()
+	}
+}
+
+// active returns whether the session is active.
+func (sa *sessionActivity) active() bool {
+	if sa == nil {
+		return true
+	}
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	return !sa.inactive
+}
+
+// notifyActive has f called whenever the session becomes active again.
+func (sa *sessionActivity) notifyActive(f func()) {
+	if sa == nil {
+		return
+	}
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.onActive = append(sa.onActive, f)
+}
+
+func (sa *sessionActivity) run() {
+	for sig := range sa.conn.signals {
+		sa.handle(sig)
+	}
+	diag.Event("stopped tracking logind session: %v", errDBusClosed)
+}
+
+func (sa *sessionActivity) handle(sig *dbus.Signal) {
+	if string(sig.Path) != sa.path || sig.Name != propertiesChanged {
+		return
+	}
+	changedIface, _ := dbusArg[string](sig.Body, 0)
+	changed, _ := dbusArg[map[string]dbus.Variant](sig.Body, 1)
+	active, ok := changed["Active"].Value().(bool)
+	if changedIface != "org.freedesktop.login1.Session" || !ok {
+		return
+	}
+	sa.mu.Lock()
+	wasActive := !sa.inactive
+	sa.inactive = !active
+	onActive := sa.onActive
+	sa.mu.Unlock()
+	if active == wasActive {
+		return
+	}
+	diag.Event("logind session active=%v", active)
+	if active {
+		for _, f := range onActive {
+			f()
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/matryer/is"
+)
+
+const testSessionPath = "/org/freedesktop/login1/session/_32"
+
+// activeChanged returns the signal of the test session's Active property
+// changing.
+func activeChanged(active bool) *dbus.Signal {
+	return &dbus.Signal{
+		Path: testSessionPath,
+		Name: propertiesChanged,
+		Body: []any{"org.freedesktop.login1.Session", map[string]dbus.Variant{"Active": dbus.MakeVariant(active)}, []string{}},
+	}
+}
+
+func TestSessionActivity(t *testing.T) {
+	is := is.New(t)
+	fb := newFakeBus(t, func(call *fakeCall) ([]any, error) {
+		switch call.member {
+		case "GetSession":
+			return []any{dbus.ObjectPath(testSessionPath)}, nil
+		case "Get":
+			is.Equal(call.body[1], "Active")
+			return []any{dbus.MakeVariant(true)}, nil
+		}
+		return nil, nil
+	})
+	t.Setenv("DBUS_SYSTEM_BUS_ADDRESS", fb.address)
+	sa, err := newSessionActivity()
+	is.NoErr(err)
+	defer sa.Close()
+	is.True(sa.active())
+
+	sa = &sessionActivity{path: testSessionPath}
+	activated := 0
+	sa.notifyActive(func() { activated++ })
+	sa.handle(activeChanged(false))
+	is.True(!sa.active())
+	sa.handle(activeChanged(false))
+	sa.handle(activeChanged(true))
+	is.True(sa.active())
+	is.Equal(activated, 1)
+
+	var none *sessionActivity
+	is.True(none.active())
+}
+
+func TestControllerFollowsSession(t *testing.T) {
+	is := is.New(t)
+	tc, sim, screen := newTestController(t)
+	tc.session = &sessionActivity{path: testSessionPath}
+	tc.session.notifyActive(tc.sessionActive)
+
+	is.NoErr(screen.Send(fakeSSOff))
+	is.Equal("active", sim.power)
+
+	// Switched to another VT and the screen saver comes on.
+	tc.session.handle(activeChanged(false))
+	is.NoErr(screen.Send(fakeSSOn))
+	is.Equal("active", sim.power) // TV turned off while session inactive
+
+	// Switched back, bringing the TV in line with the screen saver.
+	tc.session.handle(activeChanged(true))
+	is.Equal("standby", sim.power) // TV not turned off on switching back
+}
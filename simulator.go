@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"foxygo.at/offscreen/pkg/bravia"
+)
+
+// braviaSim simulates enough of a Sony Bravia TV's REST IP control and
+// IRCC-IP endpoints to exercise offscreen without a TV. It serves HTTP on a
+// loopback address and reports the requests that change its state.
+type braviaSim struct {
+	mu      sync.Mutex
+	power   string // "active" or "standby"
+	input   string // URI of the selected input
+	saving  string // power saving mode
+	scene   string // scene setting
+	inputs  []simInput
+	offline bool
+
+	// logf is called to report changes to the state of the TV.
+	logf func(format string, args ...any)
+}
+
+type simInput struct {
+	URI        string `json:"uri"`
+	Label      string `json:"label"`
+	Title      string `json:"title"`
+	Connection bool   `json:"connection"`
+}
+
+// newBraviaSim returns a simulated TV in standby with four HDMI inputs,
+// labelled with the given labels (which may be empty). HDMI 1 is selected.
+func newBraviaSim(logf func(format string, args ...any), labels ...string) *braviaSim {
+	sim := &braviaSim{power: "standby", saving: "off", scene: "auto", logf: logf}
+	for i := 0; i < 4; i++ {
+		in := simInput{
+			URI:        fmt.Sprintf("extInput:hdmi?port=%d", i+1),
+			Title:      fmt.Sprintf("HDMI %d", i+1),
+			Connection: true,
+		}
+		if i < len(labels) {
+			in.Label = labels[i]
+		}
+		sim.inputs = append(sim.inputs, in)
+	}
+	sim.input = sim.inputs[0].URI
+	return sim
+}
+
+// Start serves the simulated TV on a loopback address, returning the
+// host:port to use as the TV's hostname and a function to stop serving.
+func (sim *braviaSim) Start() (string, func(), error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("could not listen for simulated TV: %w", err)
+	}
+	srv := &http.Server{Handler: sim} //nolint:gosec // loopback only
+	go srv.Serve(l)                   //nolint:errcheck // returns when closed
+	return l.Addr().String(), func() { srv.Close() }, nil
+}
+
+// SetOffline makes the simulated TV drop all connections, as if it was
+// unplugged from the network.
+func (sim *braviaSim) SetOffline(offline bool) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.offline = offline
+}
+
+// SelectInput selects an input on the simulated TV as if another host or
+// the remote control had done so.
+func (sim *braviaSim) SelectInput(uri string) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.input = uri
+}
+
+// ServeHTTP handles REST IP control and IRCC-IP requests.
+func (sim *braviaSim) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+
+	if sim.offline {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/IRCC") {
+		sim.ircc(string(body))
+		return
+	}
+
+	var req struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, sonyErr := sim.call(req.Method, req.Params)
+	resp := map[string]any{"id": 1}
+	switch {
+	case sonyErr != nil:
+		resp["error"] = sonyErr
+	case result != nil:
+		resp["result"] = []any{result}
+	default:
+		resp["result"] = []any{}
+	}
+	json.NewEncoder(w).Encode(resp) //nolint:errcheck,gosec // nothing to do
+}
+
+func (sim *braviaSim) call(method string, params []json.RawMessage) (any, []any) {
+	var param map[string]any
+	if len(params) > 0 {
+		json.Unmarshal(params[0], &param) //nolint:errcheck,gosec // missing params are handled below
+	}
+	switch method {
+	case "getPowerStatus":
+		return map[string]string{"status": sim.power}, nil
+	case "setPowerStatus":
+		on, ok := param["status"].(bool)
+		if !ok {
+			return nil, []any{bravia.SonyCodeIllegalArgument, "Illegal Argument"}
+		}
+		sim.setPower(on)
+		return nil, nil
+	case "getPlayingContentInfo":
+		if sim.power != "active" {
+			return nil, []any{bravia.SonyCodeDisplayIsTurnedOff, "Display Is Turned off"}
+		}
+		return map[string]string{"source": "extInput:hdmi", "uri": sim.input}, nil
+	case "getCurrentExternalInputsStatus":
+		return sim.inputs, nil
+	case "setPlayContent":
+		uri, _ := param["uri"].(string)
+		for _, in := range sim.inputs {
+			if in.URI == uri {
+				sim.input = uri
+				sim.logf("TV input → %s", uri)
+				return nil, nil
+			}
+		}
+		return nil, []any{bravia.SonyCodeIllegalArgument, "Illegal Argument"}
+	case "getPowerSavingMode":
+		return map[string]string{"mode": sim.saving}, nil
+	case "setPowerSavingMode":
+		mode, _ := param["mode"].(string)
+		switch mode {
+		case "off", "low", "high", "pictureOff":
+			sim.saving = mode
+			sim.logf("TV power saving → %s", mode)
+			return nil, nil
+		}
+		return nil, []any{bravia.SonyCodeIllegalArgument, "Illegal Argument"}
+	case "getSceneSetting":
+		return map[string]string{"currentValue": sim.scene}, nil
+	case "setSceneSetting":
+		scene, _ := param["value"].(string)
+		switch scene {
+		case "auto", "auto24pSync", "general", "game", "graphics":
+			sim.scene = scene
+			sim.logf("TV scene → %s", scene)
+			return nil, nil
+		}
+		return nil, []any{bravia.SonyCodeIllegalArgument, "Illegal Argument"}
+	case "setPictureQualitySettings":
+		sim.logf("TV picture settings → %v", param["settings"])
+		return nil, nil
+	}
+	return nil, []any{bravia.SonyCodeNoSuchMethod, "No Such Method"}
+}
+
+func (sim *braviaSim) ircc(body string) {
+	_, code, _ := strings.Cut(body, "<IRCCCode>")
+	code, _, _ = strings.Cut(code, "</IRCCCode>")
+	if code != bravia.IRCCPower {
+		sim.logf("TV key %s", code)
+		return
+	}
+	sim.setPower(sim.power != "active")
+}
+
+func (sim *braviaSim) setPower(on bool) {
+	sim.power = "standby"
+	if on {
+		sim.power = "active"
+	}
+	sim.logf("TV power → %s", sim.power)
+}
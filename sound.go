@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"foxygo.at/offscreen/pkg/bravia"
+)
+
+// tvSetting is a setting of the TV, such as a [bravia.SoundSetting] or
+// [bravia.PictureSetting], which differ only in type.
+type tvSetting interface {
+	~struct {
+		Target string `json:"target"`
+		Value  string `json:"value"`
+	}
+}
+
+// parseSettings parses settings of the form "target=value,target=value",
+// with kind naming the kind of settings in errors, e.g. "sound".
+func parseSettings[T tvSetting](kind, spec string) ([]T, error) {
+	var settings []T
+	for _, setting := range strings.Split(spec, ",") {
+		target, value, ok := strings.Cut(strings.TrimSpace(setting), "=")
+		if !ok || target == "" || value == "" {
+			return nil, fmt.Errorf("%w: bad %s setting %q: expected target=value", ErrUsage, kind, setting)
+		}
+		settings = append(settings, T{Target: target, Value: value})
+	}
+	return settings, nil
+}
+
+// parseSoundSettings parses sound settings of the form
+// "target=value,target=value", e.g. "soundMode=cinema,nightMode=on". An
+// empty spec results in no settings.
+func parseSoundSettings(spec string) ([]bravia.SoundSetting, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	return parseSettings[bravia.SoundSetting]("sound", spec)
+}
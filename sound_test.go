@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"foxygo.at/offscreen/pkg/bravia"
+	"github.com/matryer/is"
+)
+
+func TestParseSoundSettings(t *testing.T) {
+	is := is.New(t)
+
+	settings, err := parseSoundSettings("soundMode=cinema, nightMode=on")
+	is.NoErr(err) // failed to parse sound settings
+	is.Equal([]bravia.SoundSetting{{Target: "soundMode", Value: "cinema"}, {Target: "nightMode", Value: "on"}}, settings)
+
+	settings, err = parseSoundSettings("")
+	is.NoErr(err)              // failed to parse empty sound settings
+	is.Equal(0, len(settings)) // expected no settings
+}
+
+func TestParseSoundSettingsErrors(t *testing.T) {
+	for _, spec := range []string{"soundMode", "soundMode=", "=cinema", "soundMode=cinema,"} {
+		t.Run(spec, func(t *testing.T) {
+			is := is.New(t)
+			_, err := parseSoundSettings(spec)
+			is.True(errors.Is(err, ErrUsage)) // expected usage error
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// screenSaverInhibitors tracks the applications, such as video players and
+// browsers, that inhibit the screen saver through the
+// [org.freedesktop.ScreenSaver] interface on the session bus, so the TV is
+// not turned off while they play even if the screen saver comes on anyway.
+//
+// The Inhibit and UnInhibit calls and the cookies returned by Inhibit are
+// seen by monitoring the bus, whichever screen saver service answers them.
+// The inhibitions of applications that exit without calling UnInhibit are
+// dropped when they leave the bus. The interface has no way to list the
+// inhibitions held, so those made before tracking starts are not seen.
+//
+// The methods of screenSaverInhibitors can be called on a nil
+// *screenSaverInhibitors and do nothing, for when inhibitors are not
+// respected.
+//
+// [org.freedesktop.ScreenSaver]: https://specifications.freedesktop.org/idle-inhibit-spec/latest/
+type screenSaverInhibitors struct {
+	conn *dbusConn
+
+	mu sync.Mutex
+	// calls are the applications of the Inhibit calls waiting for their
+	// replies, by the caller and serial of the call.
+	calls map[inhibitCall]string
+	// cookies are the inhibitions held, by cookie.
+	cookies map[uint32]inhibition
+}
+
+type inhibitCall struct {
+	sender string
+	serial uint32
+}
+
+type inhibition struct {
+	sender string // unique name of the application on the bus
+	app    string
+}
+
+// newScreenSaverInhibitors connects to the session bus and starts tracking
+// screen saver inhibitors.
+func newScreenSaverInhibitors() (*screenSaverInhibitors, error) {
+	const iface = "org.freedesktop.ScreenSaver"
+	c, err := dialSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	err = c.becomeMonitor(
+		"type='method_call',interface='"+iface+"',member='Inhibit'",
+		"type='method_call',interface='"+iface+"',member='UnInhibit'",
+		"type='method_return',sender='"+iface+"'",
+		"type='signal',sender='org.freedesktop.DBus',interface='org.freedesktop.DBus',member='NameOwnerChanged'",
+	)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	si := &screenSaverInhibitors{
+		conn:    c,
+		calls:   map[inhibitCall]string{},
+		cookies: map[uint32]inhibition{},
+	}
+	go si.run()
+	return si, nil
+}
+
+// Close stops tracking screen saver inhibitors.
+func (si *screenSaverInhibitors) Close() {
+	if si != nil {
+		si.conn.Close()
+	}
+}
+
+// inhibitedBy returns the applications inhibiting the screen saver, sorted
+// and without duplicates.
+func (si *screenSaverInhibitors) inhibitedBy() []string {
+	if si == nil {
+		return nil
+	}
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	seen := map[string]bool{}
+	var apps []string
+	for _, in := range si.cookies {
+		if !seen[in.app] {
+			seen[in.app] = true
+			apps = append(apps, in.app)
+		}
+	}
+	sort.Strings(apps)
+	return apps
+}
+
+func (si *screenSaverInhibitors) run() {
+	for msg := range si.conn.messages {
+		si.handle(msg)
+	}
+	diag.Event("stopped tracking screen saver inhibitors: %v", errDBusClosed)
+}
+
+func (si *screenSaverInhibitors) handle(msg *dbus.Message) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	member := dbusHeader(msg, dbus.FieldMember)
+	switch {
+	case msg.Type == dbus.TypeMethodCall && member == "Inhibit":
+		app, _ := dbusArg[string](msg.Body, 0)
+		si.calls[inhibitCall{dbusHeader(msg, dbus.FieldSender), msg.Serial()}] = app
+	case msg.Type == dbus.TypeMethodReply:
+		dest := dbusHeader(msg, dbus.FieldDestination)
+		replySerial, _ := msg.Headers[dbus.FieldReplySerial].Value().(uint32)
+		call := inhibitCall{dest, replySerial}
+		app, ok := si.calls[call]
+		if !ok {
+			return
+		}
+		delete(si.calls, call)
+		if cookie, ok := dbusArg[uint32](msg.Body, 0); ok {
+			diag.Event("screen saver inhibited by %s (cookie %d)", app, cookie)
+			si.cookies[cookie] = inhibition{sender: dest, app: app}
+		}
+	case msg.Type == dbus.TypeMethodCall && member == "UnInhibit":
+		cookie, _ := dbusArg[uint32](msg.Body, 0)
+		if in, ok := si.cookies[cookie]; ok {
+			diag.Event("screen saver no longer inhibited by %s (cookie %d)", in.app, cookie)
+			delete(si.cookies, cookie)
+		}
+	case msg.Type == dbus.TypeSignal && member == "NameOwnerChanged":
+		name, _ := dbusArg[string](msg.Body, 0)
+		if newOwner, _ := dbusArg[string](msg.Body, 2); newOwner != "" {
+			return
+		}
+		for cookie, in := range si.cookies {
+			if in.sender == name {
+				diag.Event("screen saver no longer inhibited by %s (cookie %d): left the bus", in.app, cookie)
+				delete(si.cookies, cookie)
+			}
+		}
+		for call := range si.calls {
+			if call.sender == name {
+				delete(si.calls, call)
+			}
+		}
+	}
+}
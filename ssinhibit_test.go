@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/matryer/is"
+)
+
+func newTestInhibitors() *screenSaverInhibitors {
+	return &screenSaverInhibitors{calls: map[inhibitCall]string{}, cookies: map[uint32]inhibition{}}
+}
+
+// monitored returns a message as a monitor sees it, with the given serial,
+// header fields and body.
+func monitored(typ dbus.Type, serial uint32, fields map[dbus.HeaderField]any, body ...any) *dbus.Message {
+	msg := &dbus.Message{Type: typ, Headers: map[dbus.HeaderField]dbus.Variant{}, Body: body}
+	if typ != dbus.TypeMethodReply {
+		msg.Headers[dbus.FieldPath] = dbus.MakeVariant(dbus.ObjectPath("/"))
+		msg.Headers[dbus.FieldInterface] = dbus.MakeVariant("org.example")
+	}
+	for f, v := range fields {
+		msg.Headers[f] = dbus.MakeVariant(v)
+	}
+	if len(body) > 0 {
+		msg.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(body...))
+	}
+	// The serial is only set when sending, so is patched into the encoded
+	// message.
+	var b bytes.Buffer
+	if err := msg.EncodeTo(&b, binary.LittleEndian); err != nil {
+		panic(err)
+	}
+	binary.LittleEndian.PutUint32(b.Bytes()[8:], serial)
+	msg, err := dbus.DecodeMessage(&b)
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+// unInhibit returns the UnInhibit call of the application with the given
+// unique name for the given cookie.
+func unInhibit(sender string, cookie uint32) *dbus.Message {
+	return monitored(dbus.TypeMethodCall, 1, map[dbus.HeaderField]any{dbus.FieldSender: sender, dbus.FieldMember: "UnInhibit"}, cookie)
+}
+
+// inhibit has the application with the given unique name inhibit the screen
+// saver, with the call having the given serial and the reply the cookie.
+func (si *screenSaverInhibitors) inhibit(sender, app string, serial, cookie uint32) {
+	si.handle(monitored(dbus.TypeMethodCall, serial, map[dbus.HeaderField]any{dbus.FieldSender: sender, dbus.FieldMember: "Inhibit"}, app, "Playing video"))
+	si.handle(monitored(dbus.TypeMethodReply, 1, map[dbus.HeaderField]any{dbus.FieldReplySerial: serial, dbus.FieldDestination: sender}, cookie))
+}
+
+func TestScreenSaverInhibitorsMonitor(t *testing.T) {
+	is := is.New(t)
+	fb := newFakeBus(t, nil)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", fb.address)
+	si, err := newScreenSaverInhibitors()
+	is.NoErr(err)
+	defer si.Close()
+	is.Equal(fb.called(), []string{"org.freedesktop.DBus.Hello", "org.freedesktop.DBus.Monitoring.BecomeMonitor"})
+
+	// The calls between other connections are seen.
+	fb.send(monitored(dbus.TypeMethodCall, 3, map[dbus.HeaderField]any{dbus.FieldSender: ":1.7", dbus.FieldMember: "Inhibit"}, "mpv", "Playing video"))
+	fb.send(monitored(dbus.TypeMethodReply, 1, map[dbus.HeaderField]any{dbus.FieldReplySerial: uint32(3), dbus.FieldDestination: ":1.7"}, uint32(42)))
+	deadline := time.Now().Add(time.Second)
+	for si.inhibitedBy() == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	is.Equal(si.inhibitedBy(), []string{"mpv"})
+}
+
+func TestScreenSaverInhibitors(t *testing.T) {
+	is := is.New(t)
+	si := newTestInhibitors()
+	is.Equal(si.inhibitedBy(), nil)
+	si.inhibit(":1.7", "mpv", 3, 42)
+	si.inhibit(":1.9", "firefox", 3, 43)
+	si.inhibit(":1.9", "firefox", 4, 44)
+	is.Equal(si.inhibitedBy(), []string{"firefox", "mpv"})
+
+	// A reply to another call is not a cookie.
+	si.handle(monitored(dbus.TypeMethodReply, 1, map[dbus.HeaderField]any{dbus.FieldReplySerial: uint32(5), dbus.FieldDestination: ":1.7"}, uint32(45)))
+	is.Equal(len(si.cookies), 3)
+
+	si.handle(unInhibit(":1.7", 42))
+	is.Equal(si.inhibitedBy(), []string{"firefox"})
+
+	// Firefox crashes.
+	si.handle(monitored(dbus.TypeSignal, 1, map[dbus.HeaderField]any{dbus.FieldMember: "NameOwnerChanged"}, ":1.9", ":1.9", ""))
+	is.Equal(si.inhibitedBy(), nil)
+}
+
+func TestControllerRespectsInhibitors(t *testing.T) {
+	is := is.New(t)
+	tc, sim, screen := newTestController(t)
+	tc.ssInhibitors = newTestInhibitors()
+
+	is.NoErr(screen.Send(fakeSSOff))
+	is.Equal("active", sim.power)
+
+	tc.ssInhibitors.inhibit(":1.7", "mpv", 3, 42)
+	is.NoErr(screen.Send(fakeSSOn))
+	is.Equal("active", sim.power) // TV turned off while inhibited
+
+	is.NoErr(screen.Send(fakeSSOff))
+	tc.ssInhibitors.handle(unInhibit(":1.7", 42))
+	is.NoErr(screen.Send(fakeSSOn))
+	is.Equal("standby", sim.power) // TV not turned off
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+)
+
+// watcherSet is the watchers added to a screen backend with AddWatcher, to
+// be called along with the watcher passed to its Watch, so one screen can
+// drive several consumers such as the TV logic, a logger and a notifier.
+// [Screen] and [reconnectingScreen] embed it; the latter keeps its watchers
+// across reconnections to the X server.
+type watcherSet struct {
+	mu       sync.Mutex
+	watchers []ScreenWatcher
+}
+
+// AddWatcher adds a watcher to be called along with the watcher passed to
+// Watch. It may be called while Watch is running, in which case the watcher
+// is called from the next change.
+func (ws *watcherSet) AddWatcher(watcher ScreenWatcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.watchers = append(ws.watchers, watcher)
+}
+
+// RemoveWatcher removes a watcher added with AddWatcher. Watchers are
+// compared with ==, so a [ScreenWatcherFunc], which is not comparable, must
+// be added and removed by pointer.
+func (ws *watcherSet) RemoveWatcher(watcher ScreenWatcher) {
+	if t := reflect.TypeOf(watcher); t == nil || !t.Comparable() {
+		return // cannot have been added as one to remove
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for i, w := range ws.watchers {
+		if w == watcher {
+			ws.watchers = append(ws.watchers[:i:i], ws.watchers[i+1:]...)
+			return
+		}
+	}
+}
+
+// fanOut returns a watcher that calls watcher, if not nil, then the added
+// watchers. The error of watcher is returned, stopping Watch as usual, but
+// errors of the added watchers are only warned about, so a failing logger or
+// notifier does not stop the TV logic.
+func (ws *watcherSet) fanOut(watcher ScreenWatcher) ScreenWatcher {
+	return ScreenWatcherFunc(func(ssOn bool) error {
+		var err error
+		if watcher != nil {
+			err = watcher.SSChange(ssOn)
+		}
+		ws.mu.Lock()
+		watchers := ws.watchers
+		ws.mu.Unlock()
+		for _, w := range watchers {
+			if werr := w.SSChange(ssOn); werr != nil {
+				warnf("screen watcher: %v", werr)
+			}
+		}
+		return err
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestScreenWatchers(t *testing.T) {
+	is := is.New(t)
+	s := &Screen{}
+	var calls []string
+	record := func(name string, err error) ScreenWatcherFunc {
+		return func(ssOn bool) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+	logger := record("logger", nil)
+	notifier := record("notifier", errors.New("notifier failed"))
+	s.AddWatcher(&logger)
+	s.AddWatcher(&notifier)
+
+	watcher := s.fanOut(record("tv", nil))
+	is.NoErr(watcher.SSChange(true)) // errors of added watchers are only warned about
+	is.Equal(calls, []string{"tv", "logger", "notifier"})
+
+	calls = nil
+	err := s.fanOut(record("tv", errors.New("tv failed"))).SSChange(true)
+	is.Equal(err.Error(), "tv failed")
+	is.Equal(calls, []string{"tv", "logger", "notifier"})
+
+	calls = nil
+	s.RemoveWatcher(&notifier)
+	s.RemoveWatcher(record("not added", nil)) // not comparable, so ignored
+	is.NoErr(s.fanOut(nil).SSChange(false))
+	is.Equal(calls, []string{"logger"})
+}
+
+func TestReconnectingScreenWatchers(t *testing.T) {
+	is := is.New(t)
+	first := NewFakeScreen(false /* ssOn */, true /* present */)
+	second := NewFakeScreen(false /* ssOn */, true /* present */)
+	r := newReconnectingScreen(lostScreen{first}, func() (ScreenBackend, error) {
+		return lostScreen{second}, nil
+	})
+	r.backoff = time.Millisecond
+	logged := make(chan bool, 1) // as Send waits for the change
+	logger := ScreenWatcherFunc(func(ssOn bool) error {
+		logged <- ssOn
+		return nil
+	})
+	r.AddWatcher(&logger)
+
+	done := make(chan error)
+	go func() { done <- r.Watch(nil) }()
+
+	is.NoErr(first.Send(fakeSSOn))
+	is.Equal(true, <-logged)
+	first.Close() // reconnects to second, whose screen saver is off
+	is.Equal(false, <-logged)
+	is.NoErr(second.Send(fakeSSOn))
+	is.Equal(true, <-logged) // added watchers survive reconnecting
+
+	r.Close()
+	is.NoErr(<-done)
+}
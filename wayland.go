@@ -0,0 +1,340 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Opcodes of the requests and events of the Wayland protocol extensions used
+// by [WaylandScreen].
+const (
+	extIdleNotifierGetIdleNotification = 1 // request
+	extIdleNotificationIdled           = 0 // event
+	extIdleNotificationResumed         = 1 // event
+
+	wlrOutputManagerHead     = 0 // event
+	wlrOutputManagerDone     = 1 // event
+	wlrOutputManagerFinished = 2 // event
+
+	wlrOutputHeadName        = 0  // event
+	wlrOutputHeadDescription = 1  // event
+	wlrOutputHeadMode        = 3  // event
+	wlrOutputHeadFinished    = 9  // event
+	wlrOutputHeadMake        = 10 // event, since version 2
+	wlrOutputHeadModel       = 11 // event, since version 2
+	wlrOutputHeadSerial      = 12 // event, since version 2
+
+	wlrOutputModeFinished = 3 // event
+)
+
+// edidVendors maps the EDID manufacturer IDs of TV makers to the make that
+// compositors report for outputs, which comes from the PNP ID registry.
+var edidVendors = map[string]string{
+	"SNY": "Sony",
+}
+
+// WaylandScreen is a [ScreenBackend] for Wayland compositors such as sway and
+// Hyprland, where there is no X screen saver to watch. The screen saver is
+// taken to be on once the session has been idle for a timeout, as notified by
+// the compositor with the [ext-idle-notify-v1] protocol, so the compositor
+// must support it. GNOME does not; use [NewMutterDBusScreen] there.
+//
+// The monitor is looked for among the outputs of the compositor with the
+// [wlr-output-management] protocol, matching on the make and model that the
+// compositor derives from the monitor's EDID. The make is the vendor name
+// (e.g. "Sony") or the manufacturer ID, and the model is the monitor name,
+// which cannot be matched with the product code, or the product code in hex
+// (e.g. "0xF903"), which must match, as must the serial number if both it
+// and the head's are known. Compositors without wlr-output-management
+// (e.g. KDE Plasma) cannot tell us about their outputs, so the monitor is
+// taken to be always present.
+//
+// [ext-idle-notify-v1]: https://wayland.app/protocols/ext-idle-notify-v1
+// [wlr-output-management]: https://wayland.app/protocols/wlr-output-management-unstable-v1
+type WaylandScreen struct {
+	conn *wlConn
+
+	manufacturerID string
+	productCode    uint16
+	serial         string // or empty to match any (see [WaylandScreen.MatchSerial])
+	output         string // or empty to match by EDID (see [WaylandScreen.MatchOutput])
+
+	// heads are the outputs connected to the compositor by object ID. It
+	// is nil if the compositor does not support wlr-output-management.
+	heads map[uint32]*wlHead
+
+	ssOn    atomic.Bool
+	present atomic.Bool
+	closed  atomic.Bool
+}
+
+// wlHead is an output connected to the compositor, as described by
+// wlr-output-management.
+type wlHead struct {
+	name        string
+	description string
+	make        string
+	model       string
+	serial      string
+}
+
+// NewWaylandScreen returns a new WaylandScreen connected to the compositor
+// for the given Wayland display (see [dialWayland]). The screen saver turns
+// on once the session has been idle for idleTimeout. The manufacturerID and
+// productCode are used for monitor presence detection.
+//
+// An error is returned if the compositor does not support ext-idle-notify-v1.
+func NewWaylandScreen(display string, idleTimeout time.Duration, manufacturerID string, productCode uint16) (*WaylandScreen, error) {
+	c, err := dialWayland(display)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newWaylandScreen(c, idleTimeout, manufacturerID, productCode)
+	if err != nil {
+		c.Close() //nolint:errcheck // nothing to do about it
+		return nil, err
+	}
+	return s, nil
+}
+
+func newWaylandScreen(c *wlConn, idleTimeout time.Duration, manufacturerID string, productCode uint16) (*WaylandScreen, error) {
+	s := &WaylandScreen{
+		conn:           c,
+		manufacturerID: manufacturerID,
+		productCode:    productCode,
+	}
+
+	registry := c.newObject("wl_registry")
+	if err := c.request(wlDisplayID, wlDisplayGetRegistry, registry); err != nil {
+		return nil, err
+	}
+	globals := map[string]wlGlobal{}
+	err := c.roundtrip(func(ev wlEvent) error {
+		if ev.obj == registry && ev.opcode == wlRegistryGlobal {
+			name, iface, version := ev.uint(), ev.string(), ev.uint()
+			if _, ok := globals[iface]; !ok {
+				globals[iface] = wlGlobal{name: name, version: version}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seatGlobal, ok := globals["wl_seat"]
+	if !ok {
+		return nil, errors.New("Wayland compositor has no seat")
+	}
+	notifierGlobal, ok := globals["ext_idle_notifier_v1"]
+	if !ok {
+		return nil, errors.New("Wayland compositor does not support ext-idle-notify-v1 (use --screen-backend=mutter under GNOME)")
+	}
+	seat, err := c.bind(registry, seatGlobal, "wl_seat", 1)
+	if err != nil {
+		return nil, err
+	}
+	notifier, err := c.bind(registry, notifierGlobal, "ext_idle_notifier_v1", 1)
+	if err != nil {
+		return nil, err
+	}
+	notification := c.newObject("ext_idle_notification_v1")
+	timeout := uint32(idleTimeout.Milliseconds())
+	if err := c.request(notifier, extIdleNotifierGetIdleNotification, notification, timeout, seat); err != nil {
+		return nil, err
+	}
+
+	if g, ok := globals["zwlr_output_manager_v1"]; ok {
+		s.heads = map[uint32]*wlHead{}
+		version := g.version
+		if version > 2 {
+			version = 2
+		}
+		if _, err := c.bind(registry, g, "zwlr_output_manager_v1", version); err != nil {
+			return nil, err
+		}
+	} else {
+		diag.Event("Wayland compositor does not support wlr-output-management; assuming monitor is present")
+		s.present.Store(true)
+	}
+
+	// Set the initial monitor presence from the heads sent when binding
+	// the output manager.
+	if err := c.roundtrip(func(ev wlEvent) error { return s.handle(ev, nil) }); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// MatchSerial makes the monitor also be identified by its EDID serial
+// number, to tell apart identical monitors. Heads whose serial number the
+// compositor does not send still match.
+func (s *WaylandScreen) MatchSerial(serial string) {
+	s.serial = serial
+	if s.heads != nil {
+		s.presenceChange(nil) //nolint:errcheck // no watcher, no error
+	}
+}
+
+// MatchOutput makes the monitor be identified by the name of the output it
+// is connected to, e.g. HDMI-A-1, instead of by its EDID. Compositors without
+// wlr-output-management cannot tell us about their outputs, so the monitor is
+// still taken to be always present.
+func (s *WaylandScreen) MatchOutput(name string) {
+	s.output = name
+	if s.heads != nil {
+		s.presenceChange(nil) //nolint:errcheck // no watcher, no error
+	}
+}
+
+// Close closes the connection to the compositor. This will cause
+// [WaylandScreen.Watch] to return.
+func (s *WaylandScreen) Close() {
+	s.closed.Store(true)
+	s.conn.Close() //nolint:errcheck // nothing to do about it
+}
+
+// IsScreenSaverOn returns whether the session is idle.
+func (s *WaylandScreen) IsScreenSaverOn() bool {
+	return s.ssOn.Load()
+}
+
+// IsPresent returns whether the screen's monitor is present or not.
+func (s *WaylandScreen) IsPresent() bool {
+	return s.present.Load()
+}
+
+// Blank returns an error as Wayland clients cannot make the session idle.
+func (s *WaylandScreen) Blank() error {
+	return errors.New("cannot blank the screen on Wayland")
+}
+
+// Unblank returns an error as Wayland clients cannot make the session
+// active.
+func (s *WaylandScreen) Unblank() error {
+	return errors.New("cannot unblank the screen on Wayland")
+}
+
+// Watch loops while the connection to the compositor is open (see
+// [WaylandScreen.Close]) calling the given watcher when the session becomes
+// idle or active, with the same rules as [Screen.Watch].
+func (s *WaylandScreen) Watch(watcher ScreenWatcher) error {
+	for {
+		ev, err := s.conn.next()
+		if err != nil {
+			if s.closed.Load() {
+				return nil
+			}
+			return err
+		}
+		if err := s.handle(ev, watcher); err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles an event from the compositor, calling watcher if it is not
+// nil and the screen saver state should be passed on.
+func (s *WaylandScreen) handle(ev wlEvent, watcher ScreenWatcher) error {
+	switch s.conn.objects[ev.obj] {
+	case "ext_idle_notification_v1":
+		switch ev.opcode {
+		case extIdleNotificationIdled:
+			return s.ssChange(true, watcher)
+		case extIdleNotificationResumed:
+			return s.ssChange(false, watcher)
+		}
+	case "zwlr_output_manager_v1":
+		switch ev.opcode {
+		case wlrOutputManagerHead:
+			id := ev.uint()
+			s.conn.objects[id] = "zwlr_output_head_v1"
+			s.heads[id] = &wlHead{}
+		case wlrOutputManagerDone:
+			return s.presenceChange(watcher)
+		case wlrOutputManagerFinished:
+			diag.Event("Wayland output manager finished")
+		}
+	case "zwlr_output_head_v1":
+		head := s.heads[ev.obj]
+		switch ev.opcode {
+		case wlrOutputHeadName:
+			head.name = ev.string()
+		case wlrOutputHeadDescription:
+			head.description = ev.string()
+		case wlrOutputHeadMake:
+			head.make = ev.string()
+		case wlrOutputHeadModel:
+			head.model = ev.string()
+		case wlrOutputHeadSerial:
+			head.serial = ev.string()
+		case wlrOutputHeadMode:
+			s.conn.objects[ev.uint()] = "zwlr_output_mode_v1"
+		case wlrOutputHeadFinished:
+			delete(s.heads, ev.obj)
+			delete(s.conn.objects, ev.obj)
+		}
+	case "zwlr_output_mode_v1":
+		if ev.opcode == wlrOutputModeFinished {
+			delete(s.conn.objects, ev.obj)
+		}
+	}
+	return nil
+}
+
+// ssChange records the screen saver state and passes it to the watcher if
+// it changed while the monitor is present.
+func (s *WaylandScreen) ssChange(isOn bool, watcher ScreenWatcher) error {
+	wasOn := s.ssOn.Swap(isOn)
+	diag.Event("screen saver on=%v (was %v, monitor present=%v)", isOn, wasOn, s.IsPresent())
+	if isOn != wasOn && s.IsPresent() && watcher != nil {
+		return watcher.SSChange(isOn)
+	}
+	return nil
+}
+
+// presenceChange looks for the monitor among the heads once the compositor
+// has finished describing them, passing the screen saver state to the
+// watcher if the monitor has just appeared.
+func (s *WaylandScreen) presenceChange(watcher ScreenWatcher) error {
+	present := false
+	for _, head := range s.heads {
+		if (s.output != "" && head.name == s.output) || (s.output == "" && head.matches(s.manufacturerID, s.productCode, s.serial)) {
+			present = true
+			break
+		}
+	}
+	wasPresent := s.present.Swap(present)
+	diag.Event("monitor present=%v (was %v)", present, wasPresent)
+	if present && !wasPresent && watcher != nil {
+		return watcher.SSChange(s.IsScreenSaverOn())
+	}
+	return nil
+}
+
+// matches returns whether the head is the monitor with the given EDID
+// manufacturer ID, product code and serial number (if not empty), as far as
+// can be told from its make, model and serial number. Compositors with
+// version 1 of wlr-output-management do not send the make, model and serial
+// number, so the make is taken from the start of the description.
+func (h *wlHead) matches(manufacturerID string, productCode uint16, serial string) bool {
+	vendor := h.make
+	if vendor == "" {
+		vendor, _, _ = strings.Cut(h.description, " ")
+	}
+	if !strings.EqualFold(vendor, manufacturerID) && !strings.EqualFold(vendor, edidVendors[manufacturerID]) {
+		return false
+	}
+	if serial != "" && h.serial != "" && !sameSerial(h.serial, serial) {
+		return false
+	}
+	if strings.HasPrefix(h.model, "0x") {
+		code, err := strconv.ParseUint(h.model[2:], 16, 16)
+		return err == nil && uint16(code) == productCode
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// fakeCompositor is a Wayland compositor that supports just enough of the
+// protocol for a [WaylandScreen]: a seat, ext-idle-notify-v1 and
+// wlr-output-management with a single Sony TV head.
+type fakeCompositor struct {
+	t    *testing.T
+	conn *wlConn // for sending events to the client
+
+	// notification is the ID of the client's idle notification object.
+	notification chan uint32
+}
+
+func newFakeCompositor(t *testing.T) (socket string, fc *fakeCompositor) {
+	t.Helper()
+	socket = filepath.Join(t.TempDir(), "wayland-test")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	fc = &fakeCompositor{t: t, notification: make(chan uint32, 1)}
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		fc.conn = newWLConn(c)
+		fc.serve(c)
+	}()
+	return socket, fc
+}
+
+// serve reads requests from the client and responds to them until the
+// client disconnects.
+func (fc *fakeCompositor) serve(r io.Reader) {
+	const registry, head = 2, 0xff000000
+	globals := []string{"wl_seat", "ext_idle_notifier_v1", "zwlr_output_manager_v1"}
+	bound := map[uint32]string{}
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return
+		}
+		obj := binary.LittleEndian.Uint32(hdr[0:])
+		word := binary.LittleEndian.Uint32(hdr[4:])
+		ev := wlEvent{obj: obj, opcode: uint16(word), args: make([]byte, word>>16-8)}
+		if _, err := io.ReadFull(r, ev.args); err != nil {
+			return
+		}
+		switch {
+		case obj == wlDisplayID && ev.opcode == wlDisplayGetRegistry:
+			id := ev.uint()
+			for i, iface := range globals {
+				fc.send(id, wlRegistryGlobal, uint32(i+1), iface, uint32(2))
+			}
+		case obj == wlDisplayID && ev.opcode == wlDisplaySync:
+			fc.send(ev.uint(), wlCallbackDone, uint32(0))
+		case obj == registry && ev.opcode == wlRegistryBind:
+			name, iface, _, id := ev.uint(), ev.string(), ev.uint(), ev.uint()
+			bound[id] = iface
+			if globals[name-1] != iface {
+				fc.t.Errorf("bound global %d as %s", name, iface)
+			}
+			if iface == "zwlr_output_manager_v1" {
+				fc.send(id, wlrOutputManagerHead, uint32(head))
+				fc.send(head, wlrOutputHeadName, "HDMI-A-1")
+				fc.send(head, wlrOutputHeadMake, "Sony")
+				fc.send(head, wlrOutputHeadModel, "0xF903")
+				fc.send(id, wlrOutputManagerDone, uint32(1))
+			}
+		case bound[obj] == "ext_idle_notifier_v1" && ev.opcode == extIdleNotifierGetIdleNotification:
+			fc.notification <- ev.uint()
+		}
+	}
+}
+
+func (fc *fakeCompositor) send(obj uint32, opcode uint16, args ...any) {
+	if err := fc.conn.request(obj, opcode, args...); err != nil {
+		fc.t.Error(err)
+	}
+}
+
+func TestWaylandScreen(t *testing.T) {
+	is := is.New(t)
+	socket, fc := newFakeCompositor(t)
+
+	s, err := NewWaylandScreen(socket, time.Minute, "SNY", 63747)
+	is.NoErr(err)
+	is.True(s.IsPresent())        // Sony head matched
+	is.True(!s.IsScreenSaverOn()) // not idle yet
+	s.MatchOutput("DP-1")
+	is.True(!s.IsPresent()) // no DP-1 output
+	s.MatchOutput("HDMI-A-1")
+	is.True(s.IsPresent()) // Sony head matched by output name
+
+	changes := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ScreenWatcherFunc(func(ssOn bool) error {
+			changes <- ssOn
+			return nil
+		}))
+	}()
+
+	notification := <-fc.notification
+	fc.send(notification, extIdleNotificationIdled)
+	is.Equal(true, <-changes)
+	fc.send(notification, extIdleNotificationResumed)
+	is.Equal(false, <-changes)
+
+	s.Close()
+	is.NoErr(<-done)
+}
+
+func TestWLHeadMatches(t *testing.T) {
+	tests := map[string]struct {
+		head wlHead
+		want bool
+	}{
+		"vendor name":        {wlHead{make: "Sony", model: "SONY TV"}, true},
+		"manufacturer ID":    {wlHead{make: "SNY", model: "0xF903"}, true},
+		"other product code": {wlHead{make: "Sony", model: "0x1234"}, false},
+		"other make":         {wlHead{make: "Dell Inc.", model: "DELL U2720Q"}, false},
+		"description only":   {wlHead{description: "Sony SONY TV  (HDMI-A-1)"}, true},
+		"serial":             {wlHead{make: "Sony", model: "SONY TV", serial: "7001234"}, true},
+		"hex serial":         {wlHead{make: "Sony", model: "SONY TV", serial: "0x006AD492"}, true},
+		"other serial":       {wlHead{make: "Sony", model: "SONY TV", serial: "7005678"}, false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			is.New(t).Equal(tc.want, tc.head.matches("SNY", 63747, "7001234"))
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import "strings"
+
+// windowsEDIDKey returns the registry key under HKEY_LOCAL_MACHINE that
+// holds the EDID of the monitor with the given device interface name, as
+// returned by EnumDisplayDevices with EDD_GET_DEVICE_INTERFACE_NAME, e.g.
+// `\\?\DISPLAY#SNY0F83#5&2c03a83e&0&UID4352#{e6f07b5f-ee97-4a90-b076-33f57bf4eaa7}`.
+// It returns false if the name is not of a display.
+func windowsEDIDKey(deviceID string) (string, bool) {
+	parts := strings.Split(deviceID, "#")
+	if len(parts) != 4 || !strings.EqualFold(strings.TrimPrefix(parts[0], `\\?\`), "DISPLAY") {
+		return "", false
+	}
+	return `SYSTEM\CurrentControlSet\Enum\DISPLAY\` + parts[1] + `\` + parts[2] + `\Device Parameters`, true
+}
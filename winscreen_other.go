@@ -0,0 +1,12 @@
+//go:build !windows
+
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import "errors"
+
+// NewWindowsScreen returns an error as the Windows screen backend needs
+// Windows.
+func NewWindowsScreen(manufacturer string, productCode uint16, serial string) (ScreenBackend, error) {
+	return nil, errors.New("--screen-backend=windows needs offscreen built for Windows")
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWindowsEDIDKey(t *testing.T) {
+	is := is.New(t)
+	key, ok := windowsEDIDKey(`\\?\DISPLAY#SNY0F83#5&2c03a83e&0&UID4352#{e6f07b5f-ee97-4a90-b076-33f57bf4eaa7}`)
+	is.True(ok)
+	is.Equal(key, `SYSTEM\CurrentControlSet\Enum\DISPLAY\SNY0F83\5&2c03a83e&0&UID4352\Device Parameters`)
+
+	_, ok = windowsEDIDKey(`\\?\USB#VID_046D#123#{guid}`)
+	is.True(!ok)
+	_, ok = windowsEDIDKey(`Monitor\Default_Monitor`)
+	is.True(!ok)
+}
@@ -0,0 +1,322 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/anoopengineer/edidparser/edid"
+)
+
+// Windows API functions, messages and constants used by the Windows screen
+// backend.
+var (
+	user32   = syscall.NewLazyDLL("user32.dll")
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+	wtsapi32 = syscall.NewLazyDLL("wtsapi32.dll")
+
+	procRegisterClassExW                 = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW                  = user32.NewProc("CreateWindowExW")
+	procDefWindowProcW                   = user32.NewProc("DefWindowProcW")
+	procGetMessageW                      = user32.NewProc("GetMessageW")
+	procDispatchMessageW                 = user32.NewProc("DispatchMessageW")
+	procPostMessageW                     = user32.NewProc("PostMessageW")
+	procEnumDisplayDevicesW              = user32.NewProc("EnumDisplayDevicesW")
+	procRegisterPowerSettingNotification = user32.NewProc("RegisterPowerSettingNotification")
+	procSetThreadExecutionState          = kernel32.NewProc("SetThreadExecutionState")
+	procWTSRegisterSessionNotification   = wtsapi32.NewProc("WTSRegisterSessionNotification")
+)
+
+const (
+	wmDisplayChange       = 0x007e
+	wmSysCommand          = 0x0112
+	wmPowerBroadcast      = 0x0218
+	wmDeviceChange        = 0x0219
+	wmWTSSessionChange    = 0x02b1
+	pbtPowerSettingChange = 0x8013
+	wtsSessionLock        = 0x7
+	wtsSessionUnlock      = 0x8
+	scMonitorPower        = 0xf170
+	hwndBroadcast         = 0xffff
+	esDisplayRequired     = 0x2
+
+	eddGetDeviceInterfaceName = 0x1
+	displayDeviceActive       = 0x1
+
+	// winPollInterval is how often the Windows screen backend polls for
+	// the monitor, in case a display change message is missed.
+	winPollInterval = 10 * time.Second
+)
+
+// guidConsoleDisplayState is the power setting whose changes are sent when
+// the console display turns off, on or dims.
+var guidConsoleDisplayState = syscall.GUID{
+	Data1: 0x6fe69556, Data2: 0x704a, Data3: 0x47a0,
+	Data4: [8]byte{0x8f, 0x24, 0xc2, 0x8d, 0x93, 0x6f, 0xda, 0x47},
+}
+
+type wndClassEx struct {
+	size       uint32
+	style      uint32
+	wndProc    uintptr
+	clsExtra   int32
+	wndExtra   int32
+	instance   syscall.Handle
+	icon       syscall.Handle
+	cursor     syscall.Handle
+	background syscall.Handle
+	menuName   *uint16
+	className  *uint16
+	iconSm     syscall.Handle
+}
+
+type winMsg struct {
+	hwnd    syscall.Handle
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+	private uint32
+}
+
+type displayDevice struct {
+	cb           uint32
+	deviceName   [32]uint16
+	deviceString [128]uint16
+	stateFlags   uint32
+	deviceID     [128]uint16
+	deviceKey    [128]uint16
+}
+
+type powerBroadcastSetting struct {
+	powerSetting syscall.GUID
+	dataLength   uint32
+	data         [1]byte
+}
+
+// winDisplay is the state of the console display and session as told by the
+// broadcast messages to our hidden window. It is shared by all Windows
+// screens.
+var winDisplay struct {
+	once    sync.Once
+	err     error
+	off     atomic.Bool
+	locked  atomic.Bool
+	changed chan struct{} // signalled on every change, including monitors
+}
+
+// NewWindowsScreen returns a [ScreenBackend] for Windows that follows the
+// console display being turned off and on (GUID_CONSOLE_DISPLAY_STATE power
+// setting notifications) and the session being locked and unlocked (WTS
+// session notifications); the screen saver is on while either is. The
+// monitor with the given EDID manufacturer ID, product code and, if not
+// empty, serial number is present while it is an active display device,
+// whose EDID is read from the registry. Blanking turns the monitors off with
+// SC_MONITORPOWER and unblanking turns them back on.
+func NewWindowsScreen(manufacturer string, productCode uint16, serial string) (ScreenBackend, error) {
+	winDisplay.once.Do(func() {
+		winDisplay.changed = make(chan struct{}, 1)
+		started := make(chan error)
+		go runWindowLoop(started)
+		winDisplay.err = <-started
+	})
+	if winDisplay.err != nil {
+		return nil, winDisplay.err
+	}
+	query := func() (bool, bool, error) {
+		present, err := windowsMonitorPresent(manufacturer, productCode, serial)
+		return winDisplay.off.Load() || winDisplay.locked.Load(), present, err
+	}
+	s, err := newPolledScreen(query, winPollInterval, winDisplay.changed)
+	if err != nil {
+		return nil, err
+	}
+	s.blank = func() error {
+		return postMessage(hwndBroadcast, wmSysCommand, scMonitorPower, 2)
+	}
+	s.unblank = func() error {
+		procSetThreadExecutionState.Call(esDisplayRequired) //nolint:errcheck,gosec // best effort
+		return postMessage(hwndBroadcast, wmSysCommand, scMonitorPower, ^uintptr(0))
+	}
+	return s, nil
+}
+
+// runWindowLoop creates a hidden window that registers for display power
+// and session notifications and runs its message loop, never returning
+// unless that fails. The result of setting up the window is sent on started.
+func runWindowLoop(started chan<- error) {
+	// Messages for a window are received by the thread that created it.
+	runtime.LockOSThread()
+	if err := createNotifyWindow(); err != nil {
+		started <- err
+		return
+	}
+	started <- nil
+	var m winMsg
+	for {
+		r, _, err := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(r) <= 0 {
+			warnf("Windows message loop ended: %v", err)
+			return
+		}
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m))) //nolint:errcheck,gosec // no useful result
+	}
+}
+
+func createNotifyWindow() error {
+	className, err := syscall.UTF16PtrFromString("offscreen")
+	if err != nil {
+		return err
+	}
+	wc := wndClassEx{
+		wndProc:   syscall.NewCallback(wndProc),
+		className: className,
+	}
+	wc.size = uint32(unsafe.Sizeof(wc))
+	if r, _, err := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc))); r == 0 {
+		return fmt.Errorf("could not register window class: %w", err)
+	}
+	// A hidden top-level window rather than a message-only one, as only
+	// top-level windows receive WM_DISPLAYCHANGE.
+	r, _, err := procCreateWindowExW.Call(0, uintptr(unsafe.Pointer(className)), uintptr(unsafe.Pointer(className)), 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	if r == 0 {
+		return fmt.Errorf("could not create window: %w", err)
+	}
+	hwnd := syscall.Handle(r)
+	if r, _, err := procRegisterPowerSettingNotification.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&guidConsoleDisplayState)), 0); r == 0 {
+		return fmt.Errorf("could not register for display power notifications: %w", err)
+	}
+	if r, _, err := procWTSRegisterSessionNotification.Call(uintptr(hwnd), 0 /* NOTIFY_FOR_THIS_SESSION */); r == 0 {
+		return fmt.Errorf("could not register for session notifications: %w", err)
+	}
+	return nil
+}
+
+func wndProc(hwnd syscall.Handle, msg uint32, wParam, lParam uintptr) uintptr {
+	switch msg {
+	case wmPowerBroadcast:
+		if wParam != pbtPowerSettingChange {
+			break
+		}
+		setting := *(**powerBroadcastSetting)(unsafe.Pointer(&lParam))
+		if setting.powerSetting == guidConsoleDisplayState {
+			// 0 is off, 1 on and 2 dimmed, which is still on.
+			off := setting.data[0] == 0
+			diag.Event("console display off=%v", off)
+			winDisplay.off.Store(off)
+			winDisplayChanged()
+		}
+		return 1
+	case wmWTSSessionChange:
+		switch wParam {
+		case wtsSessionLock:
+			diag.Event("session locked")
+			winDisplay.locked.Store(true)
+		case wtsSessionUnlock:
+			diag.Event("session unlocked")
+			winDisplay.locked.Store(false)
+		}
+		winDisplayChanged()
+		return 0
+	case wmDisplayChange, wmDeviceChange:
+		winDisplayChanged()
+	}
+	r, _, _ := procDefWindowProcW.Call(uintptr(hwnd), uintptr(msg), wParam, lParam)
+	return r
+}
+
+func winDisplayChanged() {
+	select {
+	case winDisplay.changed <- struct{}{}:
+	default:
+	}
+}
+
+// windowsMonitorPresent returns whether the monitor with the given EDID
+// manufacturer ID, product code and serial number (or any if empty) is an
+// active display device.
+func windowsMonitorPresent(manufacturer string, productCode uint16, serial string) (bool, error) {
+	for adapter := uint32(0); ; adapter++ {
+		ad := displayDevice{}
+		ad.cb = uint32(unsafe.Sizeof(ad))
+		if r, _, _ := procEnumDisplayDevicesW.Call(0, uintptr(adapter), uintptr(unsafe.Pointer(&ad)), 0); r == 0 {
+			return false, nil
+		}
+		for monitor := uint32(0); ; monitor++ {
+			md := displayDevice{}
+			md.cb = uint32(unsafe.Sizeof(md))
+			r, _, _ := procEnumDisplayDevicesW.Call(uintptr(unsafe.Pointer(&ad.deviceName[0])), uintptr(monitor), uintptr(unsafe.Pointer(&md)), eddGetDeviceInterfaceName)
+			if r == 0 {
+				break
+			}
+			if md.stateFlags&displayDeviceActive == 0 {
+				continue
+			}
+			key, ok := windowsEDIDKey(syscall.UTF16ToString(md.deviceID[:]))
+			if !ok {
+				continue
+			}
+			data, err := readRegistryBinary(key, "EDID")
+			if err != nil {
+				diag.Event("could not read EDID of %s: %v", key, err)
+				continue
+			}
+			e, err := edid.NewEdid(data)
+			if err != nil {
+				continue
+			}
+			if e.ManufacturerId == manufacturer && e.ProductCode == productCode && edidSerialMatches(e, serial) {
+				return true, nil
+			}
+		}
+	}
+}
+
+// readRegistryBinary reads a binary value of a key under
+// HKEY_LOCAL_MACHINE.
+func readRegistryBinary(key, name string) ([]byte, error) {
+	keyp, err := syscall.UTF16PtrFromString(key)
+	if err != nil {
+		return nil, err
+	}
+	namep, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	var h syscall.Handle
+	if err := syscall.RegOpenKeyEx(syscall.HKEY_LOCAL_MACHINE, keyp, 0, syscall.KEY_READ, &h); err != nil {
+		return nil, err
+	}
+	defer syscall.RegCloseKey(h) //nolint:errcheck // read only
+	// Get the size first, as EDIDs with extension blocks can be any
+	// multiple of 128 bytes.
+	var typ, n uint32
+	if err := syscall.RegQueryValueEx(h, namep, nil, &typ, nil, &n); err != nil {
+		return nil, err
+	}
+	if typ != syscall.REG_BINARY {
+		return nil, errors.New("EDID is not a binary value")
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, n)
+	if err := syscall.RegQueryValueEx(h, namep, nil, &typ, &buf[0], &n); err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func postMessage(hwnd, msg, wParam, lParam uintptr) error {
+	if r, _, err := procPostMessageW.Call(hwnd, msg, wParam, lParam); r == 0 {
+		return err
+	}
+	return nil
+}
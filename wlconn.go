@@ -0,0 +1,225 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// wlDisplayID is the object ID of the wl_display singleton, which exists on
+// every Wayland connection.
+const wlDisplayID = 1
+
+// Opcodes of the requests and events of the core Wayland objects.
+const (
+	wlDisplaySync        = 0 // request
+	wlDisplayGetRegistry = 1 // request
+	wlDisplayError       = 0 // event
+	wlDisplayDeleteID    = 1 // event
+	wlRegistryBind       = 0 // request
+	wlRegistryGlobal     = 0 // event
+	wlCallbackDone       = 0 // event
+)
+
+// wlByteOrder is the byte order of the Wayland wire protocol, which is that
+// of the host. Wayland is only used on little-endian hosts in practice.
+var wlByteOrder = binary.LittleEndian
+
+// wlConn is a minimal client connection to a Wayland compositor. It speaks
+// just enough of the wire protocol for offscreen to bind the globals it
+// needs and receive their events: requests and events with integer, string
+// and object arguments. File descriptor arguments are not supported.
+type wlConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	nextID uint32
+	// objects maps the IDs of live objects to their interface names, so
+	// events can be dispatched by interface.
+	objects map[uint32]string
+}
+
+// wlEvent is an event received from the compositor. Its arguments are
+// decoded in order with its methods.
+type wlEvent struct {
+	obj    uint32
+	opcode uint16
+	args   []byte
+}
+
+// wlGlobal is a global object advertised by the compositor's registry.
+type wlGlobal struct {
+	name    uint32
+	version uint32
+}
+
+// dialWayland connects to the Wayland compositor for the given display
+// ($WAYLAND_DISPLAY), which is the name of a socket in $XDG_RUNTIME_DIR or
+// an absolute path to a socket. An empty display means "wayland-0".
+func dialWayland(display string) (*wlConn, error) {
+	if display == "" {
+		display = "wayland-0"
+	}
+	path := display
+	if !filepath.IsAbs(path) {
+		dir := os.Getenv("XDG_RUNTIME_DIR")
+		if dir == "" {
+			return nil, fmt.Errorf("could not find Wayland display %s: $XDG_RUNTIME_DIR is not set", display)
+		}
+		path = filepath.Join(dir, display)
+	}
+	c, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to Wayland display %s: %w", display, err)
+	}
+	return newWLConn(c), nil
+}
+
+func newWLConn(c net.Conn) *wlConn {
+	return &wlConn{
+		conn:    c,
+		r:       bufio.NewReader(c),
+		nextID:  wlDisplayID + 1,
+		objects: map[uint32]string{wlDisplayID: "wl_display"},
+	}
+}
+
+// Close closes the connection to the compositor.
+func (c *wlConn) Close() error {
+	return c.conn.Close()
+}
+
+// newObject allocates the ID of a new client object of the given interface.
+func (c *wlConn) newObject(iface string) uint32 {
+	id := c.nextID
+	c.nextID++
+	c.objects[id] = iface
+	return id
+}
+
+// request sends a request to an object. The arguments are encoded by type:
+// uint32 for uint, object and new_id arguments, int32 for int arguments and
+// string for string arguments.
+func (c *wlConn) request(obj uint32, opcode uint16, args ...any) error {
+	b := make([]byte, 8, 64)
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case uint32:
+			b = wlByteOrder.AppendUint32(b, arg)
+		case int32:
+			b = wlByteOrder.AppendUint32(b, uint32(arg))
+		case string:
+			b = wlByteOrder.AppendUint32(b, uint32(len(arg)+1))
+			b = append(b, arg...)
+			b = append(b, make([]byte, 1+wlPad(len(arg)+1))...) // NUL and padding
+		default:
+			panic(fmt.Sprintf("unsupported Wayland argument type %T", arg))
+		}
+	}
+	wlByteOrder.PutUint32(b[0:], obj)
+	wlByteOrder.PutUint32(b[4:], uint32(len(b))<<16|uint32(opcode))
+	if _, err := c.conn.Write(b); err != nil {
+		return fmt.Errorf("could not send Wayland request: %w", err)
+	}
+	return nil
+}
+
+// bind binds a global advertised by the registry, returning the ID of the
+// new object. version should not exceed the version of the global.
+func (c *wlConn) bind(registry uint32, g wlGlobal, iface string, version uint32) (uint32, error) {
+	id := c.newObject(iface)
+	return id, c.request(registry, wlRegistryBind, g.name, iface, version, id)
+}
+
+// next returns the next event from the compositor. Errors reported by the
+// compositor with wl_display.error are returned as errors, and the IDs of
+// deleted objects are forgotten.
+func (c *wlConn) next() (wlEvent, error) {
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(c.r, hdr[:]); err != nil {
+			return wlEvent{}, fmt.Errorf("could not read Wayland event: %w", err)
+		}
+		ev := wlEvent{obj: wlByteOrder.Uint32(hdr[0:])}
+		word := wlByteOrder.Uint32(hdr[4:])
+		ev.opcode = uint16(word)
+		size := int(word >> 16)
+		if size < len(hdr) {
+			return wlEvent{}, fmt.Errorf("invalid Wayland event size %d", size)
+		}
+		ev.args = make([]byte, size-len(hdr))
+		if _, err := io.ReadFull(c.r, ev.args); err != nil {
+			return wlEvent{}, fmt.Errorf("could not read Wayland event: %w", err)
+		}
+		if ev.obj != wlDisplayID {
+			return ev, nil
+		}
+		switch ev.opcode {
+		case wlDisplayError:
+			obj, code, msg := ev.uint(), ev.uint(), ev.string()
+			return wlEvent{}, fmt.Errorf("compositor error on %s@%d (code %d): %s", c.objects[obj], obj, code, msg)
+		case wlDisplayDeleteID:
+			delete(c.objects, ev.uint())
+		}
+	}
+}
+
+// roundtrip waits for the compositor to process all requests sent so far,
+// passing the events received in the meantime to fn.
+func (c *wlConn) roundtrip(fn func(wlEvent) error) error {
+	callback := c.newObject("wl_callback")
+	if err := c.request(wlDisplayID, wlDisplaySync, callback); err != nil {
+		return err
+	}
+	for {
+		ev, err := c.next()
+		if err != nil {
+			return err
+		}
+		if ev.obj == callback && ev.opcode == wlCallbackDone {
+			return nil
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+}
+
+// uint decodes the next argument of the event as a uint, object or new_id.
+// A missing argument decodes as zero.
+func (ev *wlEvent) uint() uint32 {
+	if len(ev.args) < 4 {
+		ev.args = nil
+		return 0
+	}
+	v := wlByteOrder.Uint32(ev.args)
+	ev.args = ev.args[4:]
+	return v
+}
+
+// string decodes the next argument of the event as a string. A null or
+// missing string decodes as the empty string.
+func (ev *wlEvent) string() string {
+	n := int(ev.uint())
+	if n == 0 || n > len(ev.args) {
+		ev.args = nil
+		return ""
+	}
+	s := string(ev.args[:n-1]) // drop the NUL terminator
+	if n+wlPad(n) > len(ev.args) {
+		ev.args = nil
+	} else {
+		ev.args = ev.args[n+wlPad(n):]
+	}
+	return s
+}
+
+// wlPad returns the padding needed after n bytes to align to 32 bits.
+func wlPad(n int) int {
+	return (4 - n%4) % 4
+}
@@ -0,0 +1,85 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"context"
+	"os"
+
+	"foxygo.at/offscreen/pkg/bravia"
+)
+
+// macCache caches the MAC addresses of TVs, keyed by hostname.
+var macCache = hostCache{what: "mac cache", file: "macs.json", dir: os.UserCacheDir}
+
+// cachedMAC returns the cached MAC address of the TV at hostname, or the
+// empty string if it is not known.
+func cachedMAC(hostname string) string {
+	return macCache.get(hostname)
+}
+
+// cacheMAC saves the MAC address of the TV at hostname in the cache.
+func cacheMAC(hostname, mac string) error {
+	return macCache.set(hostname, mac)
+}
+
+// discoverMAC gets the MAC address of the TV from its system information,
+// or from its network settings if the system information does not have it,
+// if it is not already known. It caches it against hostname so the TV can be
+// woken later when it is not reachable. Failures are only warnings.
+func discoverMAC(ctx context.Context, c *bravia.RESTClient, hostname string) {
+	if c.MAC != "" {
+		return
+	}
+	info, err := c.SystemInformation(ctx)
+	if err == nil && info.MACAddr != "" {
+		c.MAC = info.MACAddr
+	} else {
+		netifs, nerr := c.NetworkSettings(ctx)
+		if nerr != nil && err == nil {
+			err = nerr
+		}
+		c.MAC = connectedMAC(netifs)
+	}
+	if c.MAC == "" {
+		if err != nil {
+			warnf("could not discover MAC address of TV: %v", err)
+		} else {
+			diag.Event("TV did not report its MAC address")
+		}
+		return
+	}
+	diag.Event("discovered TV MAC address %s", c.MAC)
+	if err := cacheMAC(hostname, c.MAC); err != nil {
+		warnf("could not cache MAC address of TV: %v", err)
+	}
+}
+
+// checkWOLMode warns if Wake-on-LAN is disabled on the TV, as the TV will
+// not wake from the magic packets sent if it is unreachable. It is only
+// checked if the MAC address of the TV is known, as otherwise no magic
+// packets are sent anyway.
+func checkWOLMode(ctx context.Context, c *bravia.RESTClient) {
+	if c.MAC == "" {
+		return
+	}
+	enabled, err := c.WOLMode(ctx)
+	if err != nil {
+		diag.Event("could not get Wake-on-LAN mode: %v", err)
+		return
+	}
+	if !enabled {
+		warnf("Wake-on-LAN is disabled on the TV so it cannot be woken if unreachable; enable it with `offscreen tv wolmode on`")
+	}
+}
+
+// connectedMAC returns the MAC address of the first of the network
+// interfaces that has an IPv4 address, which is the one to send Wake-on-LAN
+// packets to, or the empty string if there is none.
+func connectedMAC(netifs []bravia.NetworkInterface) string {
+	for _, n := range netifs {
+		if n.IPAddrV4 != "" && n.HWAddr != "" {
+			return n.HWAddr
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// ErrXAuth is a sentinel error for when the X server refuses our connection
+// because we could not present a valid authorisation cookie. It will
+// typically be wrapped so should be checked with `errors.Is()`.
+var ErrXAuth = errors.New("X11 authorisation refused")
+
+// ErrXConnLost is a sentinel error for when the connection to the X server is
+// lost, such as when the X server exits. It will typically be wrapped so
+// should be checked with `errors.Is()`.
+var ErrXConnLost = errors.New("lost connection to X server")
+
+// xRetryInterval is how long to wait between attempts to connect to the X
+// server while waiting for it to become available.
+const xRetryInterval = 500 * time.Millisecond
+
+// xFlags is a kong CLI struct to be embedded in command structs that connect
+// to an X11 server. When run from a system-level unit or an early session
+// hook, $DISPLAY and $XAUTHORITY may not be set yet and the X server may not
+// yet be accepting connections, so the authority file can be given
+// explicitly and connecting can be retried for a while.
+type xFlags struct {
+	Display    []string      `env:"DISPLAY" sep:"none" help:"X11 display to connect to. Repeat to watch the displays of several seats at once with run"`
+	XAuthority string        `name:"xauthority" env:"XAUTHORITY" type:"path" help:"X11 authority file holding the cookie for the display"`
+	XTimeout   time.Duration `default:"0s" help:"How long to keep retrying to connect to the X11 display (0 to try once)"`
+}
+
+// dial connects to the X server of the first display given by the flags.
+func (xf *xFlags) dial() (*xgb.Conn, error) {
+	return xf.dialDisplay(xf.displays()[0])
+}
+
+// dialDisplay connects to the X server of the given display with the
+// authority file and timeout given by the flags.
+func (xf *xFlags) dialDisplay(display string) (*xgb.Conn, error) {
+	return DialX(display, xf.XAuthority, xf.XTimeout)
+}
+
+// displays returns the displays given by the flags, or the default display
+// (the empty string) if none are given.
+func (xf *xFlags) displays() []string {
+	if len(xf.Display) == 0 {
+		return []string{""}
+	}
+	return xf.Display
+}
+
+// DialX connects to the X server for the given display. If xauthority is not
+// empty, it names the file that holds the authorisation cookie for the
+// display, overriding $XAUTHORITY.
+//
+// If timeout is greater than zero, connection attempts that fail because the
+// X server cannot be reached are retried until timeout has elapsed. A single
+// attempt that does not complete within timeout is abandoned. Authorisation
+// failures are not retried and are returned wrapping [ErrXAuth].
+func DialX(display, xauthority string, timeout time.Duration) (*xgb.Conn, error) {
+	if xauthority != "" {
+		if _, err := os.Stat(xauthority); err != nil {
+			return nil, fmt.Errorf("could not read X authority file: %w", err)
+		}
+		// xgb only looks at the environment to find the authority file.
+		if err := os.Setenv("XAUTHORITY", xauthority); err != nil {
+			return nil, fmt.Errorf("could not set XAUTHORITY: %w", err)
+		}
+	}
+
+	if timeout <= 0 {
+		c, err := xgb.NewConnDisplay(display)
+		return c, xDialError(display, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		c, err := dialXTimeout(display, time.Until(deadline))
+		if err == nil {
+			return c, nil
+		}
+		err = xDialError(display, err)
+		if errors.Is(err, ErrXAuth) || time.Now().Add(xRetryInterval).After(deadline) {
+			return nil, err
+		}
+		time.Sleep(xRetryInterval)
+	}
+}
+
+// dialXTimeout makes a single attempt to connect to the X server, giving up
+// after timeout. If the attempt completes after we have given up on it, the
+// connection is closed.
+func dialXTimeout(display string, timeout time.Duration) (*xgb.Conn, error) {
+	type result struct {
+		c   *xgb.Conn
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		c, err := xgb.NewConnDisplay(display)
+		ch <- result{c, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-ch:
+		return r.c, r.err
+	case <-timer.C:
+		go func() {
+			if r := <-ch; r.c != nil {
+				r.c.Close()
+			}
+		}()
+		return nil, fmt.Errorf("timed out after %v", timeout)
+	}
+}
+
+// fatalXError returns whether an error received from the X server while
+// waiting for events means the X server can no longer be relied on. X
+// errors for unchecked requests arrive as events and are usually benign, such
+// as for an output or window that went away before the request reached the
+// server, so they are not fatal, but the server running out of memory or
+// failing internally is. Errors that are not X errors are fatal too. A lost
+// connection is not an error from xgb; its events just end.
+func fatalXError(err error) bool {
+	var xerr xgb.Error
+	if !errors.As(err, &xerr) {
+		return true
+	}
+	switch xerr.(type) {
+	case xproto.AllocError, xproto.ImplementationError:
+		return true
+	}
+	return false
+}
+
+// xDialError turns the terse errors from xgb into something that tells the
+// user what to look at. It returns nil if err is nil.
+func xDialError(display string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if display == "" {
+		display = os.Getenv("DISPLAY")
+	}
+	if msg := err.Error(); strings.Contains(msg, "authentication refused") || strings.Contains(msg, "unsupported auth protocol") {
+		authFile := os.Getenv("XAUTHORITY")
+		if authFile == "" {
+			authFile = "$HOME/.Xauthority"
+		}
+		return fmt.Errorf("%w for display %q using %s (set --xauthority to the file holding the cookie for the display): %v", ErrXAuth, display, authFile, err)
+	}
+	if display == "" {
+		return fmt.Errorf("no X11 display given (set --display or $DISPLAY): %w", err)
+	}
+	return fmt.Errorf("could not open display %s: %w", display, err)
+}
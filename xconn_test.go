@@ -0,0 +1,24 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jezek/xgb/randr"
+	"github.com/jezek/xgb/xproto"
+	"github.com/matryer/is"
+)
+
+func TestFatalXError(t *testing.T) {
+	is := is.New(t)
+	// Errors for requests about things that went away are benign.
+	is.True(!fatalXError(xproto.WindowError{}))
+	is.True(!fatalXError(xproto.MatchError{}))
+	is.True(!fatalXError(randr.BadOutputError{}))
+	is.True(!fatalXError(fmt.Errorf("wrapped: %w", xproto.ValueError{})))
+	// The X server failing is not.
+	is.True(fatalXError(xproto.AllocError{}))
+	is.True(fatalXError(xproto.ImplementationError{}))
+	is.True(fatalXError(errors.New("not an X error")))
+}
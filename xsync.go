@@ -0,0 +1,192 @@
+//nolint:goerr113 // dynamic errors in main are OK
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// Minor opcodes of the requests of the X SYNC extension used by
+// [idleCounter]. xgb does not have bindings for SYNC, so the few requests
+// needed are encoded here.
+//
+// https://www.x.org/releases/current/doc/xextproto/sync.html
+const (
+	syncInitialize         = 0
+	syncListSystemCounters = 1
+	syncQueryCounter       = 5
+	syncCreateAlarm        = 8
+
+	syncAlarmNotify = 1 // event, after the extension's first event
+)
+
+// Values of a SYNC alarm set by CreateAlarm, which are given in the order of
+// their bits in the value mask.
+const (
+	syncAlarmCounter   = 1 << 0
+	syncAlarmValueType = 1 << 1
+	syncAlarmValue     = 1 << 2
+	syncAlarmTestType  = 1 << 3
+	syncAlarmDelta     = 1 << 4
+	syncAlarmEvents    = 1 << 5
+
+	syncValueAbsolute      = 0
+	syncNegativeTransition = 1
+)
+
+// idleCounter is the IDLETIME system counter of the X SYNC extension, which
+// counts the milliseconds since the last user input. It is supported by
+// X servers without the SCREENSAVER extension, such as Xwayland.
+type idleCounter struct {
+	xconn  *xgb.Conn
+	opcode byte // major opcode of the SYNC extension
+	id     uint32
+}
+
+// newIdleCounter returns the IDLETIME counter of the X server. An error is
+// returned if the X server does not have the SYNC extension or the counter.
+func newIdleCounter(c *xgb.Conn) (*idleCounter, error) {
+	ext, err := xproto.QueryExtension(c, 4, "SYNC").Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not query SYNC extension: %w", err)
+	}
+	if !ext.Present {
+		return nil, errors.New("X server has no SYNC extension")
+	}
+	ic := &idleCounter{xconn: c, opcode: ext.MajorOpcode}
+	xgb.NewEventFuncs[int(ext.FirstEvent)+syncAlarmNotify] = newSyncAlarmNotifyEvent
+
+	// The extension must be initialised before it is used.
+	if _, err := ic.request(syncInitialize, []byte{3, 1, 0, 0}); err != nil {
+		return nil, fmt.Errorf("could not initialise SYNC extension: %w", err)
+	}
+	reply, err := ic.request(syncListSystemCounters, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not list SYNC counters: %w", err)
+	}
+	counters, err := parseSystemCounters(reply)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := counters["IDLETIME"]
+	if !ok {
+		return nil, errors.New("X server has no IDLETIME counter")
+	}
+	ic.id = id
+	return ic, nil
+}
+
+// idle returns how long it has been since the last user input.
+func (ic *idleCounter) idle() (time.Duration, error) {
+	body := make([]byte, 4)
+	xgb.Put32(body, ic.id)
+	reply, err := ic.request(syncQueryCounter, body)
+	if err != nil {
+		return 0, fmt.Errorf("could not query IDLETIME counter: %w", err)
+	}
+	if len(reply) < 16 {
+		return 0, errors.New("short SYNC QueryCounter reply")
+	}
+	// The value is an INT64 sent as the high 32 bits then the low.
+	ms := int64(int32(xgb.Get32(reply[8:])))<<32 | int64(xgb.Get32(reply[12:]))
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// alarmOnInput creates an alarm that sends a [syncAlarmNotifyEvent] on the
+// first user input after the session has been idle for longer than idle.
+func (ic *idleCounter) alarmOnInput(idle time.Duration) error {
+	alarm, err := ic.xconn.NewId()
+	if err != nil {
+		return fmt.Errorf("could not allocate SYNC alarm: %w", err)
+	}
+	ms := idle.Milliseconds()
+	values := []uint32{
+		ic.id,
+		syncValueAbsolute,
+		uint32(ms >> 32), uint32(ms),
+		syncNegativeTransition,
+		0, 0, // delta
+		1, // events
+	}
+	body := make([]byte, 8+4*len(values))
+	xgb.Put32(body, alarm)
+	xgb.Put32(body[4:], syncAlarmCounter|syncAlarmValueType|syncAlarmValue|syncAlarmTestType|syncAlarmDelta|syncAlarmEvents)
+	for i, v := range values {
+		xgb.Put32(body[8+4*i:], v)
+	}
+	cookie := ic.xconn.NewCookie(true /* checked */, false /* reply */)
+	ic.xconn.NewRequest(ic.encode(syncCreateAlarm, body), cookie)
+	if err := cookie.Check(); err != nil {
+		return fmt.Errorf("could not create SYNC alarm: %w", err)
+	}
+	return nil
+}
+
+// syncAlarmNotifyEvent is sent by the X server when a SYNC alarm created by
+// [idleCounter.alarmOnInput] triggers.
+type syncAlarmNotifyEvent struct {
+	buf []byte
+}
+
+func newSyncAlarmNotifyEvent(buf []byte) xgb.Event {
+	return syncAlarmNotifyEvent{buf: buf}
+}
+
+// Bytes returns the event as sent by the X server.
+func (e syncAlarmNotifyEvent) Bytes() []byte {
+	return e.buf
+}
+
+// String returns the name of the event.
+func (e syncAlarmNotifyEvent) String() string {
+	return "SyncAlarmNotify"
+}
+
+// request sends a SYNC request with the given minor opcode and body, whose
+// length must be a multiple of 4, and returns the reply.
+func (ic *idleCounter) request(minor byte, body []byte) ([]byte, error) {
+	cookie := ic.xconn.NewCookie(true /* checked */, true /* reply */)
+	ic.xconn.NewRequest(ic.encode(minor, body), cookie)
+	return cookie.Reply()
+}
+
+// encode encodes a SYNC request with the given minor opcode and body.
+func (ic *idleCounter) encode(minor byte, body []byte) []byte {
+	buf := make([]byte, 4+len(body))
+	buf[0] = ic.opcode
+	buf[1] = minor
+	xgb.Put16(buf[2:], uint16(len(buf)/4))
+	copy(buf[4:], body)
+	return buf
+}
+
+// parseSystemCounters parses the reply to a SYNC ListSystemCounters request
+// into the IDs of the counters by name.
+func parseSystemCounters(reply []byte) (map[string]uint32, error) {
+	errShort := errors.New("short SYNC ListSystemCounters reply")
+	if len(reply) < 32 {
+		return nil, errShort
+	}
+	n := int(xgb.Get32(reply[8:]))
+	counters := make(map[string]uint32, n)
+	b := 32
+	for i := 0; i < n; i++ {
+		// counter CARD32, resolution INT64, name length CARD16, name
+		// and padding to a multiple of 4.
+		if len(reply) < b+14 {
+			return nil, errShort
+		}
+		id := xgb.Get32(reply[b:])
+		nameLen := int(xgb.Get16(reply[b+12:]))
+		if len(reply) < b+14+nameLen {
+			return nil, errShort
+		}
+		counters[string(reply[b+14:b+14+nameLen])] = id
+		b += xgb.Pad(14 + nameLen)
+	}
+	return counters, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jezek/xgb"
+	"github.com/matryer/is"
+)
+
+func TestParseSystemCounters(t *testing.T) {
+	is := is.New(t)
+	reply := make([]byte, 32)
+	xgb.Put32(reply[8:], 2)
+	for _, c := range []struct {
+		id   uint32
+		name string
+	}{{7, "SERVERTIME"}, {9, "IDLETIME"}} {
+		counter := make([]byte, xgb.Pad(14+len(c.name)))
+		xgb.Put32(counter, c.id)
+		xgb.Put16(counter[12:], uint16(len(c.name)))
+		copy(counter[14:], c.name)
+		reply = append(reply, counter...)
+	}
+	counters, err := parseSystemCounters(reply)
+	is.NoErr(err)
+	is.Equal(counters, map[string]uint32{"SERVERTIME": 7, "IDLETIME": 9})
+
+	_, err = parseSystemCounters(reply[:len(reply)-8])
+	is.True(err != nil) // expected error for truncated reply
+}